@@ -0,0 +1,254 @@
+// Package agent implements an embeddable sFlow v5 agent: packet sampling
+// and counter polling for a set of data sources, with the resulting
+// samples sent to one or more collectors over UDP.
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/kanocz/sflow"
+	"github.com/kanocz/sflow/records"
+)
+
+const (
+	// DefaultPollingInterval is the counter polling interval used
+	// when Config.PollingInterval is not set.
+	DefaultPollingInterval = 20 * time.Second
+
+	// DefaultFlushInterval is the maximum time a flow sample is held
+	// before being sent when Config.FlushInterval is not set.
+	DefaultFlushInterval = time.Second
+
+	// DefaultMaxPendingSamples is the number of samples held for
+	// sending before new flow samples are dropped when
+	// Config.MaxPendingSamples is not set.
+	DefaultMaxPendingSamples = 1024
+)
+
+var (
+	ErrNoCollectors = errors.New("sflow: no collectors configured")
+)
+
+// CounterSource returns the current counter records of a data source.
+// It is called once per polling interval.
+type CounterSource func() []records.Record
+
+// Config describes an Agent.
+type Config struct {
+	// Address is the agent address reported in every datagram.
+	Address    net.IP
+	SubAgentID uint32
+
+	// Collectors are the host:port UDP addresses samples are sent to.
+	Collectors []string
+
+	PollingInterval   time.Duration
+	FlushInterval     time.Duration
+	MaxPendingSamples int
+
+	// MaxDatagramSize limits the size of sent datagrams,
+	// see sflow.Encoder.MaxDatagramSize.
+	MaxDatagramSize int
+
+	// ErrorHandler, if not nil, is called by Run with the errors of
+	// flushing pending samples, which are dropped otherwise.
+	ErrorHandler func(error)
+}
+
+// Agent samples packets and polls counters for its data sources
+// and sends the resulting samples to the configured collectors.
+// It is safe for concurrent use.
+type Agent struct {
+	config Config
+	conns  []net.Conn
+
+	mu      sync.Mutex
+	encoder *sflow.Encoder
+	pending []sflow.Sample
+	sources []*DataSource
+}
+
+// New returns an agent sending to cfg.Collectors.
+func New(cfg Config) (*Agent, error) {
+	if len(cfg.Collectors) == 0 {
+		return nil, ErrNoCollectors
+	}
+
+	if cfg.PollingInterval <= 0 {
+		cfg.PollingInterval = DefaultPollingInterval
+	}
+	if cfg.FlushInterval <= 0 {
+		cfg.FlushInterval = DefaultFlushInterval
+	}
+	if cfg.MaxPendingSamples <= 0 {
+		cfg.MaxPendingSamples = DefaultMaxPendingSamples
+	}
+
+	a := &Agent{
+		config:  cfg,
+		encoder: sflow.NewEncoder(cfg.Address, cfg.SubAgentID, 0),
+	}
+	a.encoder.MaxDatagramSize = cfg.MaxDatagramSize
+
+	for _, collector := range cfg.Collectors {
+		conn, err := net.Dial("udp", collector)
+		if err != nil {
+			a.Close()
+			return nil, err
+		}
+
+		a.conns = append(a.conns, conn)
+	}
+
+	return a, nil
+}
+
+// AddDataSource registers a data source identified by sourceType and
+// index (e.g. 0 and an ifIndex). Packets passed to its Sample method are
+// sampled 1-in-samplingRate; a samplingRate of 0 disables sampling.
+// If counters is not nil it is polled every polling interval.
+func (a *Agent) AddDataSource(sourceType byte, index uint32, samplingRate uint32, counters CounterSource) *DataSource {
+	s := newDataSource(a, sourceType, index, samplingRate, counters)
+
+	a.mu.Lock()
+	a.sources = append(a.sources, s)
+	a.mu.Unlock()
+
+	return s
+}
+
+// Run polls counters and flushes pending samples until ctx is done.
+func (a *Agent) Run(ctx context.Context) error {
+	poll := time.NewTicker(a.config.PollingInterval)
+	defer poll.Stop()
+
+	flush := time.NewTicker(a.config.FlushInterval)
+	defer flush.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			a.handleError(a.Flush())
+			return ctx.Err()
+		case <-poll.C:
+			a.Poll()
+		case <-flush.C:
+			a.handleError(a.Flush())
+		}
+	}
+}
+
+// handleError passes err to the ErrorHandler, if both are not nil.
+func (a *Agent) handleError(err error) {
+	if err != nil && a.config.ErrorHandler != nil {
+		a.config.ErrorHandler(err)
+	}
+}
+
+// Poll collects a counter sample from every data source
+// with a CounterSource and queues it for sending.
+func (a *Agent) Poll() {
+	a.mu.Lock()
+	sources := append([]*DataSource(nil), a.sources...)
+	a.mu.Unlock()
+
+	for _, s := range sources {
+		if sample := s.poll(); sample != nil {
+			a.enqueue(sample, true)
+		}
+	}
+}
+
+// Flush sends all pending samples to the collectors. Samples that
+// cannot be encoded, such as samples larger than MaxDatagramSize, are
+// dropped and reported in the returned error; the others are sent.
+func (a *Agent) Flush() error {
+	a.mu.Lock()
+	if len(a.pending) == 0 {
+		a.mu.Unlock()
+		return nil
+	}
+
+	datagrams, err := a.encoder.EncodeBatch(a.pending)
+	if err != nil {
+		datagrams, err = a.encodeSendable(a.pending)
+	}
+	a.pending = nil
+	a.mu.Unlock()
+
+	for _, datagram := range datagrams {
+		for _, conn := range a.conns {
+			if _, werr := conn.Write(datagram); werr != nil && err == nil {
+				err = werr
+			}
+		}
+	}
+
+	return err
+}
+
+// encodeSendable encodes the samples that can be encoded, and returns
+// an error for the dropped ones. a.mu must be held.
+func (a *Agent) encodeSendable(samples []sflow.Sample) ([][]byte, error) {
+	var sendable []sflow.Sample
+	var err error
+
+	for _, sample := range samples {
+		if serr := a.encoder.CheckSample(sample); serr != nil {
+			if err == nil {
+				err = serr
+			}
+			continue
+		}
+
+		sendable = append(sendable, sample)
+	}
+
+	if err != nil {
+		err = fmt.Errorf("sflow: dropped %d of %d pending samples: %w",
+			len(samples)-len(sendable), len(samples), err)
+	}
+
+	if len(sendable) == 0 {
+		return nil, err
+	}
+
+	datagrams, berr := a.encoder.EncodeBatch(sendable)
+	if berr != nil {
+		return nil, berr
+	}
+
+	return datagrams, err
+}
+
+// Close closes the connections to the collectors.
+func (a *Agent) Close() error {
+	var err error
+
+	for _, conn := range a.conns {
+		if cerr := conn.Close(); cerr != nil && err == nil {
+			err = cerr
+		}
+	}
+
+	return err
+}
+
+// enqueue queues sample for sending. Flow samples are refused once
+// MaxPendingSamples samples are pending; counter samples always fit.
+func (a *Agent) enqueue(sample sflow.Sample, force bool) bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if !force && len(a.pending) >= a.config.MaxPendingSamples {
+		return false
+	}
+
+	a.pending = append(a.pending, sample)
+	return true
+}
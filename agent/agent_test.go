@@ -0,0 +1,218 @@
+package agent
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/kanocz/sflow"
+	"github.com/kanocz/sflow/records"
+)
+
+func TestAgentSamplesAndPolls(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	a, err := New(Config{
+		Address:    net.ParseIP("192.0.2.1"),
+		Collectors: []string{conn.LocalAddr().String()},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer a.Close()
+
+	counters := func() []records.Record {
+		return []records.Record{sflow.GenericInterfaceCounters{Index: 3, InOctets: 42}}
+	}
+
+	s := a.AddDataSource(0, 3, 1, counters)
+
+	header := make([]byte, 64)
+	for i := 0; i < 5; i++ {
+		if !s.Sample(Packet{Input: 3, Protocol: records.HeaderProtocolEthernetISO8023, FrameLength: 1500, Header: header}) {
+			t.Fatalf("expected packet %d to be sampled at rate 1", i)
+		}
+	}
+
+	a.Poll()
+
+	err = a.Flush()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	buf := make([]byte, 65536)
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+	n, _, err := conn.ReadFrom(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dgram, err := sflow.NewDecoder(bytes.NewReader(buf[:n])).Decode()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(dgram.Samples) != 6 {
+		t.Fatalf("expected 6 samples, got %d", len(dgram.Samples))
+	}
+
+	for i, sample := range dgram.Samples[:5] {
+		flow, ok := sample.(*sflow.FlowSample)
+		if !ok {
+			t.Fatalf("expected a FlowSample, got %T", sample)
+		}
+
+		if flow.SequenceNum != uint32(i+1) || flow.SamplePool != uint32(i+1) {
+			t.Errorf("expected sequence number and sample pool %d, got %d and %d",
+				i+1, flow.SequenceNum, flow.SamplePool)
+		}
+	}
+
+	if _, ok := dgram.Samples[5].(*sflow.CounterSample); !ok {
+		t.Fatalf("expected a CounterSample, got %T", dgram.Samples[5])
+	}
+}
+
+func TestDataSourceSamplingRate(t *testing.T) {
+	a := &Agent{config: Config{MaxPendingSamples: 1 << 20}}
+	s := newDataSource(a, 0, 1, 100, nil)
+
+	sampled := 0
+	for i := 0; i < 100000; i++ {
+		if s.Sample(Packet{}) {
+			sampled++
+		}
+	}
+
+	if sampled < 800 || sampled > 1200 {
+		t.Errorf("expected about 1000 samples at rate 100, got %d", sampled)
+	}
+
+	pool, drops, seq := s.Stats()
+	if pool != 100000 || drops != 0 || seq != uint32(sampled) {
+		t.Errorf("unexpected stats: pool %d, drops %d, sequence %d", pool, drops, seq)
+	}
+}
+
+func TestDataSourceQueueFull(t *testing.T) {
+	a := &Agent{config: Config{MaxPendingSamples: 2}}
+	s := newDataSource(a, 0, 1, 1, nil)
+
+	for i := 0; i < 5; i++ {
+		if s.Sample(Packet{}) != (i < 2) {
+			t.Errorf("packet %d: unexpected selection", i)
+		}
+	}
+
+	pool, drops, seq := s.Stats()
+	if pool != 5 || drops != 3 || seq != 2 {
+		t.Errorf("unexpected stats: pool %d, drops %d, sequence %d", pool, drops, seq)
+	}
+
+	a.pending = nil
+	if !s.Sample(Packet{}) {
+		t.Fatal("expected the packet to be queued")
+	}
+
+	flow := a.pending[0].(*sflow.FlowSample)
+	if flow.SequenceNum != 3 || flow.Drops != 3 || flow.SamplePool != 6 {
+		t.Errorf("expected sequence number 3 after the drops, got %+v", flow)
+	}
+}
+
+func TestDataSourceSetSamplingRate(t *testing.T) {
+	a := &Agent{config: Config{MaxPendingSamples: 1 << 20}}
+	s := newDataSource(a, 0, 1, 0, nil)
+
+	if s.Sample(Packet{}) {
+		t.Error("expected no sampling at rate 0")
+	}
+
+	s.SetSamplingRate(1)
+	if s.SamplingRate() != 1 || !s.Sample(Packet{}) {
+		t.Error("expected every packet to be sampled at rate 1")
+	}
+
+	if flow := a.pending[0].(*sflow.FlowSample); flow.SamplingRate != 1 {
+		t.Errorf("expected sampling rate 1, got %d", flow.SamplingRate)
+	}
+}
+
+func TestFlushDropsOversizedSamples(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	handled := make(chan error, 1)
+
+	a, err := New(Config{
+		Address:         net.ParseIP("192.0.2.1"),
+		Collectors:      []string{conn.LocalAddr().String()},
+		MaxDatagramSize: 300,
+		FlushInterval:   time.Millisecond,
+		ErrorHandler:    func(err error) { handled <- err },
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer a.Close()
+
+	s := a.AddDataSource(0, 3, 1, nil)
+
+	// the second packet does not fit into a datagram
+	for _, n := range []int{64, records.MaximumHeaderLength, 64} {
+		s.Sample(Packet{Input: 3, Protocol: records.HeaderProtocolEthernetISO8023, FrameLength: 1500, Header: make([]byte, n)})
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- a.Run(ctx) }()
+
+	select {
+	case err = <-handled:
+	case <-time.After(time.Second):
+		t.Fatal("expected the dropped sample to be reported")
+	}
+
+	cancel()
+	<-done
+
+	if !errors.Is(err, sflow.ErrSampleTooLarge) {
+		t.Errorf("expected ErrSampleTooLarge, got %v", err)
+	}
+
+	var sequenceNums []uint32
+
+	buf := make([]byte, 65536)
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+	for len(sequenceNums) < 2 {
+		n, _, err := conn.ReadFrom(buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		dgram, err := sflow.NewDecoder(bytes.NewReader(buf[:n])).Decode()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for _, sample := range dgram.Samples {
+			sequenceNums = append(sequenceNums, sample.(*sflow.FlowSample).SequenceNum)
+		}
+	}
+
+	if !reflect.DeepEqual(sequenceNums, []uint32{1, 3}) {
+		t.Errorf("expected the samples 1 and 3 to be sent, got %v", sequenceNums)
+	}
+}
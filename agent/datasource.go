@@ -0,0 +1,182 @@
+package agent
+
+import (
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/kanocz/sflow"
+	"github.com/kanocz/sflow/records"
+)
+
+// Packet describes a packet offered to DataSource.Sample.
+type Packet struct {
+	Input  uint32
+	Output uint32
+
+	// Protocol is the header protocol, e.g.
+	// records.HeaderProtocolEthernetISO8023.
+	Protocol    uint32
+	FrameLength uint32
+	Stripped    uint32
+	Header      []byte
+
+	// Records are extra flow records (e.g. records.ExtendedSwitchFlow)
+	// added to the sample.
+	Records []records.Record
+}
+
+// DataSource is a sampling and polling data source of an Agent.
+// It is safe for concurrent use.
+type DataSource struct {
+	agent *Agent
+
+	SourceIdType     byte
+	SourceIdIndexVal uint32
+
+	counters CounterSource
+
+	mu              sync.Mutex
+	rand            *rand.Rand
+	samplingRate    uint32
+	skip            uint32
+	samplePool      uint32
+	drops           uint32
+	flowSequence    uint32
+	counterSequence uint32
+}
+
+func newDataSource(a *Agent, sourceType byte, index uint32, samplingRate uint32, counters CounterSource) *DataSource {
+	s := &DataSource{
+		agent:            a,
+		SourceIdType:     sourceType,
+		SourceIdIndexVal: index,
+		samplingRate:     samplingRate,
+		counters:         counters,
+		rand:             rand.New(rand.NewSource(time.Now().UnixNano() ^ int64(index))),
+	}
+	s.skip = s.nextSkip()
+
+	return s
+}
+
+// nextSkip returns the number of packets to count before the next
+// sample is taken, chosen uniformly from [1, 2*samplingRate-1] so
+// that on average 1 in samplingRate packets is sampled. s.mu must be
+// held, except in newDataSource.
+func (s *DataSource) nextSkip() uint32 {
+	if s.samplingRate <= 1 {
+		return 1
+	}
+
+	return 1 + uint32(s.rand.Int63n(2*int64(s.samplingRate)-1))
+}
+
+// SamplingRate returns the sampling rate of the data source.
+func (s *DataSource) SamplingRate() uint32 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.samplingRate
+}
+
+// SetSamplingRate changes the sampling rate of the data source to
+// 1-in-rate; a rate of 0 disables sampling.
+func (s *DataSource) SetSamplingRate(rate uint32) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.samplingRate = rate
+	s.skip = s.nextSkip()
+}
+
+// Sample offers a packet to the data source and reports whether it was
+// selected. Selected packets are queued as a FlowSample carrying a
+// RawPacketFlow record of p.Header followed by p.Records. Selected
+// packets that do not fit into the queue are counted as drops and do
+// not use up a sequence number.
+func (s *DataSource) Sample(p Packet) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.samplingRate == 0 {
+		return false
+	}
+
+	s.samplePool++
+	s.skip--
+	if s.skip > 0 {
+		return false
+	}
+	s.skip = s.nextSkip()
+
+	sample := &sflow.FlowSample{
+		SequenceNum:      s.flowSequence + 1,
+		SourceIdType:     s.SourceIdType,
+		SourceIdIndexVal: s.SourceIdIndexVal,
+		SamplingRate:     s.samplingRate,
+		SamplePool:       s.samplePool,
+		Drops:            s.drops,
+		Input:            p.Input,
+		Output:           p.Output,
+	}
+
+	header := p.Header
+	if len(header) > records.MaximumHeaderLength {
+		header = header[:records.MaximumHeaderLength]
+	}
+
+	sample.Records = append(sample.Records, records.RawPacketFlow{
+		Protocol:    p.Protocol,
+		FrameLength: p.FrameLength,
+		Stripped:    p.Stripped,
+		HeaderSize:  uint32(len(header)),
+		Header:      append([]byte(nil), header...),
+	})
+	sample.Records = append(sample.Records, p.Records...)
+
+	// s.mu is held while queueing so that samples are queued in
+	// sequence order; the agent does not lock a data source while
+	// holding its own lock.
+	if !s.agent.enqueue(sample, false) {
+		s.drops++
+		return false
+	}
+
+	s.flowSequence++
+	return true
+}
+
+// Stats returns the sample pool, drop count and
+// flow sample sequence number of the data source.
+func (s *DataSource) Stats() (samplePool, drops, sequenceNum uint32) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.samplePool, s.drops, s.flowSequence
+}
+
+// poll returns a counter sample from the data source's
+// CounterSource, or nil if it has none.
+func (s *DataSource) poll() *sflow.CounterSample {
+	if s.counters == nil {
+		return nil
+	}
+
+	recs := s.counters()
+	if len(recs) == 0 {
+		return nil
+	}
+
+	s.mu.Lock()
+	s.counterSequence++
+	seq := s.counterSequence
+	s.mu.Unlock()
+
+	return &sflow.CounterSample{
+		SequenceNum:      seq,
+		SourceIdType:     s.SourceIdType,
+		SourceIdIndexVal: s.SourceIdIndexVal,
+		Records:          recs,
+	}
+}
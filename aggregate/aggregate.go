@@ -0,0 +1,474 @@
+// Package aggregate groups sampled flows by a configurable key and sums
+// their traffic, scaled by the sampling rate, over time windows.
+//
+// The key is made of any of the agent address, the input and output
+// ifIndex, the VLAN, the source and destination address or prefix, the
+// ports, the IP protocol and the source and destination AS numbers from
+// ExtendedGatewayFlow records. Fields that are not part of the key are
+// left zero in the results.
+//
+//	c, err := aggregate.ParseKey("srcip/24,dstport,proto")
+//	c.Window = time.Minute
+//	a := aggregate.New(c)
+//
+//	for _, w := range a.Add(dgram, time.Now()) {
+//		for _, e := range w.Top(10) {
+//			...
+//		}
+//	}
+package aggregate
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kanocz/sflow"
+	"github.com/kanocz/sflow/ipfix"
+)
+
+var (
+	ErrUnknownField = errors.New("aggregate: unknown key field")
+	ErrPrefixLength = errors.New("aggregate: invalid prefix length")
+)
+
+// Field is a part of the aggregation key.
+type Field int
+
+// Key fields
+const (
+	FieldAgent Field = iota
+	FieldInput
+	FieldOutput
+	FieldVLAN
+	FieldSrcIP
+	FieldDstIP
+	FieldSrcPort
+	FieldDstPort
+	FieldProtocol
+	FieldSrcAS
+	FieldDstAS
+)
+
+var fieldNames = map[Field]string{
+	FieldAgent:    "agent",
+	FieldInput:    "input",
+	FieldOutput:   "output",
+	FieldVLAN:     "vlan",
+	FieldSrcIP:    "srcip",
+	FieldDstIP:    "dstip",
+	FieldSrcPort:  "srcport",
+	FieldDstPort:  "dstport",
+	FieldProtocol: "proto",
+	FieldSrcAS:    "srcas",
+	FieldDstAS:    "dstas",
+}
+
+func (f Field) String() string {
+	if name, ok := fieldNames[f]; ok {
+		return name
+	}
+
+	return "field" + strconv.Itoa(int(f))
+}
+
+// Order selects the measure results are ranked by.
+type Order int
+
+// Orders
+const (
+	ByOctets Order = iota
+	ByPackets
+)
+
+// Config configures an Aggregator.
+type Config struct {
+	// Fields are the fields flows are grouped by. Without fields
+	// every window has a single entry holding its totals.
+	Fields []Field
+
+	// SrcPrefixLen and DstPrefixLen mask IPv4 source and destination
+	// addresses to prefixes of the given length. Zero keeps the
+	// whole address.
+	SrcPrefixLen int
+	DstPrefixLen int
+
+	// SrcPrefixLen6 and DstPrefixLen6 do the same for IPv6 addresses.
+	SrcPrefixLen6 int
+	DstPrefixLen6 int
+
+	// Window is the length of the time windows. Windows are aligned
+	// to multiples of Window. Zero aggregates everything into a
+	// single window returned by Flush.
+	Window time.Duration
+
+	// Order is the measure entries are ranked by.
+	Order Order
+}
+
+// ParseKey returns a Config grouping by the comma separated field names
+// in s, such as "srcip/24,dstport,proto". The names are agent, input
+// (or ifindex), output, vlan, srcip, dstip, srcport, dstport, proto,
+// srcas and dstas. Addresses are masked to a prefix with srcip/24, and
+// IPv6 addresses as well with srcip/24/64.
+func ParseKey(s string) (Config, error) {
+	c := Config{}
+
+	for _, name := range strings.Split(s, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+
+		var prefix []string
+		if i := strings.Index(name, "/"); i >= 0 {
+			prefix = strings.Split(name[i+1:], "/")
+			name = name[:i]
+		}
+
+		f, ok := parseField(name)
+		if !ok {
+			return c, fmt.Errorf("%v: %q", ErrUnknownField, name)
+		}
+
+		if prefix != nil {
+			var lengths [2]int
+			var err error
+
+			switch f {
+			case FieldSrcIP:
+				lengths, err = parsePrefix(prefix)
+				c.SrcPrefixLen, c.SrcPrefixLen6 = lengths[0], lengths[1]
+			case FieldDstIP:
+				lengths, err = parsePrefix(prefix)
+				c.DstPrefixLen, c.DstPrefixLen6 = lengths[0], lengths[1]
+			default:
+				err = fmt.Errorf("%v: %s has no prefix", ErrPrefixLength, f)
+			}
+
+			if err != nil {
+				return c, err
+			}
+		}
+
+		c.Fields = append(c.Fields, f)
+	}
+
+	return c, nil
+}
+
+func parseField(name string) (Field, bool) {
+	switch name {
+	case "ifindex":
+		return FieldInput, true
+	case "protocol":
+		return FieldProtocol, true
+	}
+
+	for f, n := range fieldNames {
+		if n == name {
+			return f, true
+		}
+	}
+
+	return 0, false
+}
+
+// parsePrefix parses the IPv4 and optional IPv6 prefix lengths.
+func parsePrefix(s []string) ([2]int, error) {
+	var lengths [2]int
+
+	if len(s) > 2 {
+		return lengths, fmt.Errorf("%v: %q", ErrPrefixLength, strings.Join(s, "/"))
+	}
+
+	for i, l := range s {
+		n, err := strconv.Atoi(l)
+		if err != nil || n < 0 || n > 32+96*i {
+			return lengths, fmt.Errorf("%v: %q", ErrPrefixLength, l)
+		}
+		lengths[i] = n
+	}
+
+	return lengths, nil
+}
+
+// Entry is the traffic of one key within a window.
+type Entry struct {
+	Agent    net.IP
+	Input    uint32
+	Output   uint32
+	VLAN     uint16
+	SrcIP    net.IP
+	DstIP    net.IP
+	SrcPort  uint16
+	DstPort  uint16
+	Protocol uint8
+	SrcAS    uint32
+	DstAS    uint32
+
+	// Samples is the number of flow samples. Packets and Octets
+	// are estimated by scaling them with the sampling rate.
+	Samples uint64
+	Packets uint64
+	Octets  uint64
+}
+
+// Value returns the text form of field f of e.
+func (e *Entry) Value(f Field) string {
+	switch f {
+	case FieldAgent:
+		return address(e.Agent)
+	case FieldInput:
+		return strconv.FormatUint(uint64(e.Input), 10)
+	case FieldOutput:
+		return strconv.FormatUint(uint64(e.Output), 10)
+	case FieldVLAN:
+		return strconv.FormatUint(uint64(e.VLAN), 10)
+	case FieldSrcIP:
+		return address(e.SrcIP)
+	case FieldDstIP:
+		return address(e.DstIP)
+	case FieldSrcPort:
+		return strconv.FormatUint(uint64(e.SrcPort), 10)
+	case FieldDstPort:
+		return strconv.FormatUint(uint64(e.DstPort), 10)
+	case FieldProtocol:
+		return strconv.FormatUint(uint64(e.Protocol), 10)
+	case FieldSrcAS:
+		return strconv.FormatUint(uint64(e.SrcAS), 10)
+	case FieldDstAS:
+		return strconv.FormatUint(uint64(e.DstAS), 10)
+	}
+
+	return ""
+}
+
+func address(ip net.IP) string {
+	if ip == nil {
+		return "-"
+	}
+	return ip.String()
+}
+
+// Window holds the aggregated traffic of a time window.
+type Window struct {
+	// Start and End delimit the window. Without a window length
+	// they are the times of the first and last sample.
+	Start time.Time
+	End   time.Time
+
+	// Entries are ranked by the configured order, largest first.
+	Entries []Entry
+
+	// Total sums all entries.
+	Total Entry
+
+	// Skipped counts flow samples without a decodable IP header.
+	Skipped uint64
+}
+
+// Top returns the n largest entries of w.
+func (w *Window) Top(n int) []Entry {
+	if n < len(w.Entries) {
+		return w.Entries[:n]
+	}
+	return w.Entries
+}
+
+// key is the comparable form of the fields of an Entry.
+type key struct {
+	agent    [net.IPv6len]byte
+	input    uint32
+	output   uint32
+	vlan     uint16
+	srcIP    [net.IPv6len]byte
+	dstIP    [net.IPv6len]byte
+	srcPort  uint16
+	dstPort  uint16
+	protocol uint8
+	srcAS    uint32
+	dstAS    uint32
+}
+
+// Aggregator sums flow samples by key. It is not safe for
+// concurrent use.
+type Aggregator struct {
+	c      Config
+	fields map[Field]bool
+
+	window  *Window
+	entries map[key]int
+}
+
+// New returns an Aggregator configured by c.
+func New(c Config) *Aggregator {
+	a := &Aggregator{
+		c:      c,
+		fields: make(map[Field]bool),
+	}
+
+	for _, f := range c.Fields {
+		a.fields[f] = true
+	}
+
+	return a
+}
+
+// Add adds the flow samples of d received at t. It returns the windows
+// that ended before t, which are no longer updated. Samples that arrive
+// late are counted in the current window.
+func (a *Aggregator) Add(d *sflow.Datagram, t time.Time) []*Window {
+	var done []*Window
+
+	if a.window != nil && a.c.Window > 0 && !t.Before(a.window.End) {
+		done = append(done, a.Flush()...)
+	}
+
+	if a.window == nil {
+		a.window = &Window{Start: t, End: t}
+		if a.c.Window > 0 {
+			a.window.Start = t.Truncate(a.c.Window)
+			a.window.End = a.window.Start.Add(a.c.Window)
+		}
+		a.entries = make(map[key]int)
+	}
+
+	if a.c.Window <= 0 && t.After(a.window.End) {
+		a.window.End = t
+	}
+
+	for _, sample := range d.Samples {
+		s, ok := sample.(*sflow.FlowSample)
+		if !ok {
+			continue
+		}
+
+		f, ok := ipfix.FlowFromSample(s, t)
+		if !ok {
+			a.window.Skipped++
+			continue
+		}
+
+		a.add(d.IpAddress, &f)
+	}
+
+	return done
+}
+
+// Flush ends the current window and returns it, if there is one.
+func (a *Aggregator) Flush() []*Window {
+	w := a.window
+	if w == nil {
+		return nil
+	}
+
+	a.window = nil
+	a.entries = nil
+
+	less := func(x, y *Entry) bool {
+		if a.c.Order == ByPackets && x.Packets != y.Packets {
+			return x.Packets > y.Packets
+		}
+		if x.Octets != y.Octets {
+			return x.Octets > y.Octets
+		}
+		return x.Packets > y.Packets
+	}
+
+	sort.SliceStable(w.Entries, func(i, j int) bool {
+		return less(&w.Entries[i], &w.Entries[j])
+	})
+
+	return []*Window{w}
+}
+
+func (a *Aggregator) add(agent net.IP, f *ipfix.Flow) {
+	k := key{}
+	e := Entry{}
+
+	if a.fields[FieldAgent] && agent != nil {
+		copy(k.agent[:], agent.To16())
+		e.Agent = net.IP(append([]byte(nil), agent...))
+	}
+	if a.fields[FieldInput] {
+		k.input = f.InputInterface
+		e.Input = k.input
+	}
+	if a.fields[FieldOutput] {
+		k.output = f.OutputInterface
+		e.Output = k.output
+	}
+	if a.fields[FieldVLAN] {
+		k.vlan = f.VLAN
+		e.VLAN = k.vlan
+	}
+	if a.fields[FieldSrcIP] {
+		e.SrcIP = mask(f.SrcIP, a.c.SrcPrefixLen, a.c.SrcPrefixLen6)
+		copy(k.srcIP[:], e.SrcIP.To16())
+	}
+	if a.fields[FieldDstIP] {
+		e.DstIP = mask(f.DstIP, a.c.DstPrefixLen, a.c.DstPrefixLen6)
+		copy(k.dstIP[:], e.DstIP.To16())
+	}
+	if a.fields[FieldSrcPort] {
+		k.srcPort = f.SrcPort
+		e.SrcPort = k.srcPort
+	}
+	if a.fields[FieldDstPort] {
+		k.dstPort = f.DstPort
+		e.DstPort = k.dstPort
+	}
+	if a.fields[FieldProtocol] {
+		k.protocol = f.Protocol
+		e.Protocol = k.protocol
+	}
+	if a.fields[FieldSrcAS] {
+		k.srcAS = f.SrcAS
+		e.SrcAS = k.srcAS
+	}
+	if a.fields[FieldDstAS] {
+		k.dstAS = f.DstAS
+		e.DstAS = k.dstAS
+	}
+
+	rate := uint64(f.SamplingRate)
+	if rate == 0 {
+		rate = 1
+	}
+
+	w := a.window
+
+	i, ok := a.entries[k]
+	if !ok {
+		i = len(w.Entries)
+		a.entries[k] = i
+		w.Entries = append(w.Entries, e)
+	}
+
+	for _, e := range []*Entry{&w.Entries[i], &w.Total} {
+		e.Samples++
+		e.Packets += f.Packets * rate
+		e.Octets += f.Octets * rate
+	}
+}
+
+// mask returns ip masked to the IPv4 or IPv6 prefix length,
+// where zero keeps the whole address.
+func mask(ip net.IP, prefixLen, prefixLen6 int) net.IP {
+	if ip4 := ip.To4(); ip4 != nil {
+		if prefixLen > 0 {
+			return ip4.Mask(net.CIDRMask(prefixLen, 8*net.IPv4len))
+		}
+		return ip4
+	}
+
+	if prefixLen6 > 0 {
+		return ip.Mask(net.CIDRMask(prefixLen6, 8*net.IPv6len))
+	}
+	return ip
+}
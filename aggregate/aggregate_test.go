@@ -0,0 +1,206 @@
+package aggregate
+
+import (
+	"net"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/kanocz/sflow"
+	"github.com/kanocz/sflow/records"
+	"github.com/kanocz/sflow/traffic"
+)
+
+func TestParseKey(t *testing.T) {
+	c, err := ParseKey("agent, ifindex,srcip/24/48,dstip/16,proto")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := Config{
+		Fields:        []Field{FieldAgent, FieldInput, FieldSrcIP, FieldDstIP, FieldProtocol},
+		SrcPrefixLen:  24,
+		SrcPrefixLen6: 48,
+		DstPrefixLen:  16,
+	}
+
+	if !reflect.DeepEqual(c, expected) {
+		t.Errorf("expected %+v, got %+v", expected, c)
+	}
+
+	for _, s := range []string{"srcmac", "srcip/33", "srcip/24/129", "dstport/8", "srcip/1/2/3"} {
+		if _, err := ParseKey(s); err == nil {
+			t.Errorf("expected an error for %q", s)
+		}
+	}
+}
+
+func TestScaling(t *testing.T) {
+	c, err := ParseKey("srcip,proto")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	g := traffic.New(traffic.Config{Seed: 1, Hosts: 50, SamplingRate: 100})
+	a := New(c)
+
+	dgram := &sflow.Datagram{IpAddress: net.IPv4(192, 0, 2, 1)}
+	for i := 0; i < 200; i++ {
+		dgram.Samples = g.Samples(100)
+		if done := a.Add(dgram, time.Unix(int64(i), 0)); done != nil {
+			t.Fatalf("unexpected window at %d", i)
+		}
+	}
+
+	windows := a.Flush()
+	if len(windows) != 1 {
+		t.Fatalf("expected 1 window, got %d", len(windows))
+	}
+
+	w := windows[0]
+	if w.Total.Samples != 20000 || w.Skipped != 0 {
+		t.Errorf("expected 20000 samples, got %d and %d skipped", w.Total.Samples, w.Skipped)
+	}
+
+	if !w.Start.Equal(time.Unix(0, 0)) || !w.End.Equal(time.Unix(199, 0)) {
+		t.Errorf("unexpected window %v - %v", w.Start, w.End)
+	}
+
+	packets, octets := g.Population()
+	for _, v := range [][2]uint64{{w.Total.Packets, packets}, {w.Total.Octets, octets}} {
+		if ratio := float64(v[0]) / float64(v[1]); ratio < 0.95 || ratio > 1.05 {
+			t.Errorf("estimate %d is off from %d by %.3f", v[0], v[1], ratio)
+		}
+	}
+
+	top := w.Top(1)[0]
+	if !top.SrcIP.Equal(traffic.HostIP(0, false)) || top.Protocol != records.IPProtocolTCP {
+		t.Errorf("unexpected top entry %+v", top)
+	}
+
+	if top.Agent != nil || top.DstIP != nil {
+		t.Errorf("expected fields outside the key to be zero, got %+v", top)
+	}
+
+	for i := 1; i < len(w.Entries); i++ {
+		if w.Entries[i].Octets > w.Entries[i-1].Octets {
+			t.Fatalf("entry %d is larger than entry %d", i, i-1)
+		}
+	}
+}
+
+func TestWindows(t *testing.T) {
+	a := New(Config{Fields: []Field{FieldAgent}, Window: time.Minute})
+
+	g := traffic.New(traffic.Config{Seed: 2})
+	start := time.Date(2020, 1, 1, 10, 0, 0, 0, time.UTC)
+
+	agents := []net.IP{net.IPv4(192, 0, 2, 1), net.IPv4(192, 0, 2, 2)}
+
+	var windows []*Window
+	for i := 0; i < 180; i += 15 {
+		for _, agent := range agents {
+			dgram := &sflow.Datagram{IpAddress: agent, Samples: g.Samples(2)}
+			windows = append(windows, a.Add(dgram, start.Add(time.Duration(i)*time.Second+time.Millisecond))...)
+		}
+	}
+
+	if len(windows) != 2 {
+		t.Fatalf("expected 2 finished windows, got %d", len(windows))
+	}
+
+	windows = append(windows, a.Flush()...)
+
+	for i, w := range windows {
+		if !w.Start.Equal(start.Add(time.Duration(i)*time.Minute)) || w.End.Sub(w.Start) != time.Minute {
+			t.Errorf("window %d: unexpected window %v - %v", i, w.Start, w.End)
+		}
+
+		if len(w.Entries) != 2 || w.Total.Samples != 16 {
+			t.Errorf("window %d: expected 2 agents with 16 samples, got %d with %d",
+				i, len(w.Entries), w.Total.Samples)
+		}
+
+		if !w.Entries[0].Agent.Equal(agents[0]) && !w.Entries[0].Agent.Equal(agents[1]) {
+			t.Errorf("window %d: unexpected agent %v", i, w.Entries[0].Agent)
+		}
+	}
+
+	if a.Flush() != nil {
+		t.Error("expected no window after flush")
+	}
+}
+
+func TestPrefixAndAS(t *testing.T) {
+	c, err := ParseKey("srcip/8/32,srcas,dstas")
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.Order = ByPackets
+
+	a := New(c)
+
+	g := traffic.New(traffic.Config{Seed: 3, IPv6Fraction: 0.5})
+
+	// the destination AS is the last of the ordered AS path
+	gateway := records.ExtendedGatewayFlow{
+		NextHopType:          1,
+		NextHop:              net.IPv4(192, 0, 2, 254).To4(),
+		SrcAs:                64496,
+		DstAsPathSegmentsLen: 1,
+		DstAsPathSegments: []records.ExtendedGatewayFlowASPathSegment{{
+			SegType: records.AsPathSegmentTypeOrdered,
+			SegLen:  2,
+			Seg:     []uint32{64500, 64511},
+		}},
+	}
+
+	var samples []sflow.Sample
+	for i := 0; i < 100; i++ {
+		s := g.Sample()
+		s.Records = append(s.Records, gateway)
+		samples = append(samples, s)
+	}
+
+	// the AS numbers are aggregated from decoded datagrams
+	datagrams, err := sflow.NewEncoder(net.IPv4(192, 0, 2, 1), 0, 1).EncodeBatch(samples)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, b := range datagrams {
+		dgram, err := sflow.NewPacketDecoder().Decode(b)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(dgram.Errors) != 0 {
+			t.Fatalf("unexpected decode errors %v", dgram.Errors)
+		}
+
+		a.Add(dgram, time.Unix(0, 0))
+	}
+
+	w := a.Flush()[0]
+
+	if len(w.Entries) != 2 {
+		t.Fatalf("expected 2 prefixes, got %d", len(w.Entries))
+	}
+
+	prefixes := map[string]bool{}
+	for _, e := range w.Entries {
+		prefixes[e.Value(FieldSrcIP)] = true
+
+		if e.Value(FieldSrcAS) != "64496" || e.DstAS != 64511 {
+			t.Errorf("unexpected AS numbers %d and %d", e.SrcAS, e.DstAS)
+		}
+	}
+
+	if !prefixes["10.0.0.0"] || !prefixes["2001:db8::"] {
+		t.Errorf("unexpected prefixes %v", prefixes)
+	}
+
+	if w.Entries[0].Packets < w.Entries[1].Packets {
+		t.Error("expected entries ranked by packets")
+	}
+}
@@ -3,7 +3,10 @@ package sflow
 import (
 	"encoding/binary"
 	"errors"
+	"io"
 	"math"
+
+	"github.com/kanocz/sflow/records"
 )
 
 var (
@@ -13,7 +16,10 @@ var (
 
 // readFields reads big-endian encoded numbers from b into
 // elements of fields, which should be pointers to numbers,
-// e.g. *uint32 or *float32.
+// e.g. *uint32 or *float32. A *string field is read as an XDR string:
+// a 32-bit length followed by the bytes padded to a multiple of 4.
+// A *records.HardwareAddr field is read as a MAC address padded to 8 bytes.
+// *[]int32 and *[]uint32 fields are read as XDR variable-length arrays.
 func readFields(b []byte, fields []interface{}) error {
 	for len(b) > 0 && len(fields) > 0 {
 		field := fields[0]
@@ -86,6 +92,57 @@ func readFields(b []byte, fields []interface{}) error {
 				*field = n
 			}
 
+		case *string:
+			if len(b) < 4 {
+				return ErrInvalidSliceLength
+			}
+
+			n := binary.BigEndian.Uint32(b[:4])
+			if uint64(n) > uint64(len(b)-4) {
+				return ErrInvalidSliceLength
+			}
+			size = 4 + int(n) + (4-int(n)%4)%4
+			if len(b) < size {
+				return ErrInvalidSliceLength
+			}
+
+			*field.(*string) = string(b[4 : 4+n])
+
+		case *records.HardwareAddr:
+			// 6 bytes, padded to 8
+			if len(b) < 8 {
+				return ErrInvalidSliceLength
+			}
+			size = 8
+
+			*field.(*records.HardwareAddr) = records.HardwareAddr(append([]byte(nil), b[:6]...))
+
+		case *[]int32, *[]uint32:
+			if len(b) < 4 {
+				return ErrInvalidSliceLength
+			}
+
+			n := binary.BigEndian.Uint32(b[:4])
+			if uint64(n)*4 > uint64(len(b)-4) {
+				return ErrInvalidSliceLength
+			}
+			size = 4 + int(n)*4
+
+			elems := make([]uint32, n)
+			for i := range elems {
+				elems[i] = binary.BigEndian.Uint32(b[4+i*4:])
+			}
+
+			switch field := field.(type) {
+			case *[]int32:
+				*field = make([]int32, n)
+				for i, e := range elems {
+					(*field)[i] = int32(e)
+				}
+			case *[]uint32:
+				*field = elems
+			}
+
 		default:
 			return ErrInvalidFieldType
 		}
@@ -96,3 +153,71 @@ func readFields(b []byte, fields []interface{}) error {
 
 	return nil
 }
+
+// encodedStringSize returns the number of bytes s occupies
+// when encoded as an XDR string.
+func encodedStringSize(s string) uint32 {
+	return uint32(4 + len(s) + (4-len(s)%4)%4)
+}
+
+// writeString writes s to w as an XDR string.
+func writeString(w io.Writer, s string) error {
+	err := binary.Write(w, binary.BigEndian, uint32(len(s)))
+	if err != nil {
+		return err
+	}
+
+	_, err = w.Write(append([]byte(s), make([]byte, (4-len(s)%4)%4)...))
+	return err
+}
+
+// writeFields writes the values in fields to w in the same encoding
+// readFields expects.
+func writeFields(w io.Writer, fields []interface{}) error {
+	var err error
+
+	for _, field := range fields {
+		switch field := field.(type) {
+		case string:
+			err = writeString(w, field)
+		case records.HardwareAddr:
+			var mac [8]byte
+			copy(mac[:6], field)
+			_, err = w.Write(mac[:])
+		case []int32, []uint32:
+			err = binary.Write(w, binary.BigEndian, uint32(binary.Size(field)/4))
+			if err == nil {
+				err = binary.Write(w, binary.BigEndian, field)
+			}
+		default:
+			err = binary.Write(w, binary.BigEndian, field)
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// encodedFieldsSize returns the number of bytes writeFields
+// produces for fields.
+func encodedFieldsSize(fields []interface{}) uint32 {
+	size := uint32(0)
+
+	for _, field := range fields {
+		switch field := field.(type) {
+		case string:
+			size += encodedStringSize(field)
+		case records.HardwareAddr:
+			size += 8
+		case []int32, []uint32:
+			size += 4 + uint32(binary.Size(field))
+		default:
+			size += uint32(binary.Size(field))
+		}
+	}
+
+	return size
+}
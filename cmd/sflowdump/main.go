@@ -0,0 +1,560 @@
+// Command sflowdump prints sFlow datagrams received on a UDP socket or
+// read from files.
+//
+// Usage:
+//
+//	sflowdump [flags] [file ...]
+//
+// Without files, datagrams are read from the UDP address given by
+// -listen. Files may be pcap or pcapng captures of sFlow traffic or raw
+// datagram dumps such as the ones in _test/.
+//
+// Datagrams are printed in sflowtool's key/value format (-format human),
+// as one JSON object per line (-format json) or as sflowtool's FLOW/CNTR
+// CSV lines (-format csv). -agent, -sample and -record select what is
+// printed. With -summary, per-agent counts of datagrams, samples,
+// records and decode errors are printed instead, at the end of the
+// input or when interrupted.
+//
+// With -aggregate, flow samples are grouped by the given key, such as
+// srcip/24,dstport,proto (see aggregate.ParseKey), and the -top largest
+// entries of every -window are printed with traffic scaled by the
+// sampling rate.
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"net"
+	"os"
+	"os/signal"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/kanocz/sflow"
+	"github.com/kanocz/sflow/aggregate"
+	"github.com/kanocz/sflow/pcap"
+	"github.com/kanocz/sflow/records"
+	"github.com/kanocz/sflow/sflowtool"
+)
+
+func main() {
+	listen := flag.String("listen", ":6343", "UDP `address` to listen on when no files are given")
+	format := flag.String("format", "human", "output `format`: human, json or csv")
+	agents := flag.String("agent", "", "comma separated agent `addresses` to print")
+	sampleType := flag.String("sample", "", "sample `type` to print: flow or counter")
+	recordTypes := flag.String("record", "", "comma separated record `types` to print, e.g. 1,1001 or 0:2003")
+	summary := flag.Bool("summary", false, "print per-agent counts instead of datagrams")
+	key := flag.String("aggregate", "", "comma separated `fields` to aggregate flows by, e.g. srcip/24,dstport,proto")
+	window := flag.Duration("window", 0, "aggregation window `length`; 0 aggregates the whole input")
+	top := flag.Int("top", 10, "`number` of aggregated entries to print per window")
+	order := flag.String("order", "octets", "rank aggregated entries by octets or packets")
+	flag.Parse()
+
+	out := bufio.NewWriter(os.Stdout)
+	defer out.Flush()
+
+	d, err := newDumper(out, *format, *agents, *sampleType, *recordTypes)
+	if err != nil {
+		log.Fatal(err)
+	}
+	d.summary = *summary
+
+	if *key != "" {
+		err = d.setAggregate(*key, *window, *top, *order)
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	if flag.NArg() > 0 {
+		for _, name := range flag.Args() {
+			err = d.readFile(name)
+			if err != nil {
+				log.Printf("%s: %v", name, err)
+			}
+		}
+	} else {
+		err = d.listen(*listen)
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	if d.aggregator != nil {
+		d.writeWindows(d.aggregator.Flush())
+	}
+
+	if d.summary {
+		d.writeSummary()
+	}
+}
+
+// agentStats counts what was received from an agent.
+type agentStats struct {
+	datagrams      int
+	flowSamples    int
+	counterSamples int
+	records        int
+	errors         int
+}
+
+type dumper struct {
+	out     *bufio.Writer
+	format  string
+	summary bool
+
+	agents      map[string]bool
+	sampleType  int
+	recordTypes map[int]bool
+
+	stats map[string]*agentStats
+
+	aggregator *aggregate.Aggregator
+	fields     []aggregate.Field
+	top        int
+}
+
+func newDumper(out *bufio.Writer, format, agents, sampleType, recordTypes string) (*dumper, error) {
+	d := &dumper{
+		out:   out,
+		stats: make(map[string]*agentStats),
+	}
+
+	switch format {
+	case "human", "json", "csv":
+		d.format = format
+	default:
+		return nil, fmt.Errorf("unknown format %q", format)
+	}
+
+	if agents != "" {
+		d.agents = make(map[string]bool)
+		for _, a := range strings.Split(agents, ",") {
+			ip := net.ParseIP(strings.TrimSpace(a))
+			if ip == nil {
+				return nil, fmt.Errorf("invalid agent address %q", a)
+			}
+			d.agents[ip.String()] = true
+		}
+	}
+
+	switch sampleType {
+	case "":
+	case "flow":
+		d.sampleType = sflow.TypeFlowSample
+	case "counter":
+		d.sampleType = sflow.TypeCounterSample
+	default:
+		return nil, fmt.Errorf("unknown sample type %q", sampleType)
+	}
+
+	if recordTypes != "" {
+		d.recordTypes = make(map[int]bool)
+		for _, r := range strings.Split(recordTypes, ",") {
+			typ, err := parseRecordType(strings.TrimSpace(r))
+			if err != nil {
+				return nil, err
+			}
+			d.recordTypes[typ] = true
+		}
+	}
+
+	return d, nil
+}
+
+// setAggregate makes d aggregate flow samples by key
+// instead of printing datagrams.
+func (d *dumper) setAggregate(key string, window time.Duration, top int, order string) error {
+	c, err := aggregate.ParseKey(key)
+	if err != nil {
+		return err
+	}
+
+	c.Window = window
+
+	switch order {
+	case "octets":
+		c.Order = aggregate.ByOctets
+	case "packets":
+		c.Order = aggregate.ByPackets
+	default:
+		return fmt.Errorf("unknown order %q", order)
+	}
+
+	d.aggregator = aggregate.New(c)
+	d.fields = c.Fields
+	d.top = top
+
+	return nil
+}
+
+// parseRecordType parses a record type given as a data format number
+// or as sflowtool's enterprise:format.
+func parseRecordType(s string) (int, error) {
+	enterprise, format := 0, s
+	if i := strings.Index(s, ":"); i >= 0 {
+		e, err := strconv.Atoi(s[:i])
+		if err != nil {
+			return 0, fmt.Errorf("invalid record type %q", s)
+		}
+		enterprise, format = e, s[i+1:]
+	}
+
+	f, err := strconv.Atoi(format)
+	if err != nil {
+		return 0, fmt.Errorf("invalid record type %q", s)
+	}
+
+	return enterprise<<12 | f, nil
+}
+
+// listen prints datagrams received on addr until interrupted.
+func (d *dumper) listen(addr string) error {
+	conn, err := net.ListenPacket("udp", addr)
+	if err != nil {
+		return err
+	}
+
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt)
+	go func() {
+		<-interrupt
+		conn.Close()
+	}()
+
+	decoder := sflow.NewPacketDecoder()
+	buf := make([]byte, 65535)
+
+	for {
+		n, src, err := conn.ReadFrom(buf)
+		if err != nil {
+			// closed on interrupt
+			return nil
+		}
+
+		var srcIP net.IP
+		if udp, ok := src.(*net.UDPAddr); ok {
+			srcIP = udp.IP
+		}
+
+		dgram, err := decoder.Decode(buf[:n])
+		if err != nil {
+			d.decodeError(srcIP, err)
+			continue
+		}
+
+		d.datagram(dgram, srcIP, n, time.Now())
+		dgram.Release()
+
+		if !d.summary && d.aggregator == nil {
+			d.out.Flush()
+		}
+	}
+}
+
+// readFile prints the datagrams in a capture or raw dump file.
+func (d *dumper) readFile(name string) error {
+	f, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	r := bufio.NewReader(f)
+
+	magic, err := r.Peek(4)
+	if err != nil {
+		return err
+	}
+
+	if isCapture(magic) {
+		return d.readCapture(r)
+	}
+
+	// raw dumps hold one or more datagrams back to back
+	b, err := ioutil.ReadAll(r)
+	if err != nil {
+		return err
+	}
+
+	br := bytes.NewReader(b)
+	decoder := sflow.NewDecoder(br)
+
+	for br.Len() > 0 {
+		size := br.Len()
+
+		dgram, err := decoder.Decode()
+		if err != nil {
+			// the position of the next datagram is unknown
+			d.decodeError(nil, err)
+			return nil
+		}
+
+		d.datagram(dgram, nil, size-br.Len(), time.Now())
+	}
+
+	return nil
+}
+
+func isCapture(magic []byte) bool {
+	for _, m := range [][]byte{
+		{0xd4, 0xc3, 0xb2, 0xa1}, {0xa1, 0xb2, 0xc3, 0xd4},
+		{0x4d, 0x3c, 0xb2, 0xa1}, {0xa1, 0xb2, 0x3c, 0x4d},
+		{0x0a, 0x0d, 0x0d, 0x0a},
+	} {
+		if bytes.Equal(magic, m) {
+			return true
+		}
+	}
+	return false
+}
+
+func (d *dumper) readCapture(r io.Reader) error {
+	cr, err := pcap.NewDatagramReader(r)
+	if err != nil {
+		return err
+	}
+
+	for {
+		dgram, err := cr.Next()
+		if err == io.EOF {
+			return nil
+		}
+
+		if dgram == nil {
+			return err
+		}
+
+		if err != nil {
+			d.decodeError(dgram.Source.IP, err)
+			continue
+		}
+
+		d.datagram(dgram.Datagram, dgram.Source.IP, len(dgram.Payload), dgram.Time)
+	}
+}
+
+func (d *dumper) agentStats(agent net.IP) *agentStats {
+	key := "-"
+	if agent != nil {
+		key = agent.String()
+	}
+
+	s, ok := d.stats[key]
+	if !ok {
+		s = &agentStats{}
+		d.stats[key] = s
+	}
+
+	return s
+}
+
+// decodeError counts err under the agent of the datagram, like the
+// datagrams of the agent, or under "-" if the agent is unknown.
+func (d *dumper) decodeError(src net.IP, err error) {
+	var agent net.IP
+
+	var e *sflow.DecodeError
+	if errors.As(err, &e) {
+		agent = e.Agent
+	}
+
+	if agent != nil && d.agents != nil && !d.agents[agent.String()] {
+		return
+	}
+
+	d.agentStats(agent).errors++
+
+	if !d.summary {
+		log.Printf("decode error from %s: %v", src, err)
+	}
+}
+
+// datagram filters dgram and prints or counts what is left.
+func (d *dumper) datagram(dgram *sflow.Datagram, src net.IP, size int, t time.Time) {
+	if d.agents != nil && !d.agents[dgram.IpAddress.String()] {
+		return
+	}
+
+	// datagrams and their errors are counted even if no sample
+	// or record is selected
+	var s *agentStats
+	if d.summary {
+		s = d.agentStats(dgram.IpAddress)
+		s.datagrams++
+		s.errors += len(dgram.Errors)
+	}
+
+	dgram = d.filter(dgram)
+	if dgram == nil {
+		return
+	}
+
+	if d.aggregator != nil {
+		d.writeWindows(d.aggregator.Add(dgram, t))
+		if !d.summary {
+			return
+		}
+	}
+
+	if d.summary {
+		for _, sample := range dgram.Samples {
+			switch sample.SampleType() {
+			case sflow.TypeFlowSample:
+				s.flowSamples++
+			case sflow.TypeCounterSample:
+				s.counterSamples++
+			}
+			s.records += len(sample.GetRecords())
+		}
+
+		return
+	}
+
+	var err error
+
+	switch d.format {
+	case "human":
+		err = sflowtool.WriteText(d.out, dgram, sflowtool.Origin{Addr: src, Size: size, Time: t})
+	case "csv":
+		err = sflowtool.WriteCSV(d.out, dgram)
+	case "json":
+		var b []byte
+		b, err = json.Marshal(dgram)
+		if err == nil {
+			_, err = fmt.Fprintf(d.out, "%s\n", b)
+		}
+	}
+
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+// filter returns a copy of dgram holding only the selected samples and
+// records, or nil if nothing is selected.
+func (d *dumper) filter(dgram *sflow.Datagram) *sflow.Datagram {
+	if d.sampleType == 0 && d.recordTypes == nil {
+		return dgram
+	}
+
+	filtered := *dgram
+	filtered.Samples = nil
+
+	for _, sample := range dgram.Samples {
+		if d.sampleType != 0 && sample.SampleType() != d.sampleType {
+			continue
+		}
+
+		if d.recordTypes == nil {
+			filtered.Samples = append(filtered.Samples, sample)
+			continue
+		}
+
+		var recs []records.Record
+		for _, rec := range sample.GetRecords() {
+			if d.recordTypes[rec.RecordType()] {
+				recs = append(recs, rec)
+			}
+		}
+
+		if len(recs) == 0 {
+			continue
+		}
+
+		switch s := sample.(type) {
+		case *sflow.FlowSample:
+			c := *s
+			c.Records = recs
+			filtered.Samples = append(filtered.Samples, &c)
+		case *sflow.CounterSample:
+			c := *s
+			c.Records = recs
+			filtered.Samples = append(filtered.Samples, &c)
+		}
+	}
+
+	if len(filtered.Samples) == 0 {
+		return nil
+	}
+
+	filtered.NumSamples = uint32(len(filtered.Samples))
+
+	return &filtered
+}
+
+func (d *dumper) writeSummary() {
+	agents := make([]string, 0, len(d.stats))
+	for agent := range d.stats {
+		agents = append(agents, agent)
+	}
+	sort.Strings(agents)
+
+	fmt.Fprintf(d.out, "%-40s %10s %10s %10s %10s %10s\n",
+		"agent", "datagrams", "flows", "counters", "records", "errors")
+
+	total := agentStats{}
+	for _, agent := range agents {
+		s := d.stats[agent]
+		fmt.Fprintf(d.out, "%-40s %10d %10d %10d %10d %10d\n",
+			agent, s.datagrams, s.flowSamples, s.counterSamples, s.records, s.errors)
+
+		total.datagrams += s.datagrams
+		total.flowSamples += s.flowSamples
+		total.counterSamples += s.counterSamples
+		total.records += s.records
+		total.errors += s.errors
+	}
+
+	fmt.Fprintf(d.out, "%-40s %10d %10d %10d %10d %10d\n",
+		"total", total.datagrams, total.flowSamples, total.counterSamples, total.records, total.errors)
+}
+
+// writeWindows prints the top entries of aggregation windows.
+func (d *dumper) writeWindows(windows []*aggregate.Window) {
+	for _, w := range windows {
+		fmt.Fprintf(d.out, "window %s - %s\n",
+			w.Start.Format(time.RFC3339), w.End.Format(time.RFC3339))
+
+		tw := tabwriter.NewWriter(d.out, 0, 8, 2, ' ', tabwriter.AlignRight)
+
+		for _, f := range d.fields {
+			fmt.Fprintf(tw, "%s\t", f)
+		}
+		fmt.Fprintf(tw, "samples\tpackets\toctets\t\n")
+
+		for _, e := range w.Top(d.top) {
+			for _, f := range d.fields {
+				fmt.Fprintf(tw, "%s\t", e.Value(f))
+			}
+			fmt.Fprintf(tw, "%d\t%d\t%d\t\n", e.Samples, e.Packets, e.Octets)
+		}
+
+		for i := range d.fields {
+			if i == 0 {
+				fmt.Fprintf(tw, "total")
+			}
+			fmt.Fprintf(tw, "\t")
+		}
+		fmt.Fprintf(tw, "%d\t%d\t%d\t\n", w.Total.Samples, w.Total.Packets, w.Total.Octets)
+
+		tw.Flush()
+
+		if w.Skipped > 0 {
+			fmt.Fprintf(d.out, "%d flow samples without an IP header\n", w.Skipped)
+		}
+	}
+
+	d.out.Flush()
+}
@@ -0,0 +1,186 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/kanocz/sflow"
+)
+
+func runDumper(t *testing.T, summary bool, format, agents, sampleType, recordTypes string, files ...string) string {
+	buf := &bytes.Buffer{}
+	out := bufio.NewWriter(buf)
+
+	d, err := newDumper(out, format, agents, sampleType, recordTypes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	d.summary = summary
+
+	for _, name := range files {
+		err = d.readFile(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if summary {
+		d.writeSummary()
+	}
+
+	out.Flush()
+	return buf.String()
+}
+
+func TestDumpJSONFilters(t *testing.T) {
+	files := []string{"../../_test/flow_sample.dump", "../../_test/host_sample.dump"}
+
+	out := runDumper(t, false, "json", "", "", "", files...)
+	if lines := strings.Split(strings.TrimSpace(out), "\n"); len(lines) != 2 {
+		t.Fatalf("expected 2 datagrams, got %d", len(lines))
+	}
+
+	out = runDumper(t, false, "json", "", "counter", "0:2003", files...)
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	if len(lines) != 1 {
+		t.Fatalf("expected 1 datagram, got %d", len(lines))
+	}
+
+	var v struct {
+		Samples []struct {
+			Records []struct {
+				RecordType int `json:"recordType"`
+			} `json:"records"`
+		} `json:"samples"`
+	}
+
+	err := json.Unmarshal([]byte(lines[0]), &v)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(v.Samples) != 1 || len(v.Samples[0].Records) != 1 || v.Samples[0].Records[0].RecordType != 2003 {
+		t.Errorf("unexpected filtered datagram %s", lines[0])
+	}
+
+	out = runDumper(t, false, "csv", "192.0.2.1", "", "", files...)
+	if out != "" {
+		t.Errorf("expected no output for an unknown agent, got %q", out)
+	}
+}
+
+func TestDumpSummary(t *testing.T) {
+	out := runDumper(t, true, "human", "", "", "",
+		"../../_test/flow_sample.dump", "../../_test/flow_sample.dump", "../../_test/host_sample.dump")
+
+	for _, line := range []string{
+		"192.168.1.7                                       1          0          1          4          0",
+		"208.85.240.52                                     2          2          0          4          0",
+		"total                                             3          2          1          8          0",
+	} {
+		if !strings.Contains(out, line) {
+			t.Errorf("expected summary to contain %q, got\n%s", line, out)
+		}
+	}
+}
+
+func TestDumpSummaryErrors(t *testing.T) {
+	words := func(w ...uint32) []byte {
+		b := make([]byte, 4*len(w))
+		for i, v := range w {
+			binary.BigEndian.PutUint32(b[4*i:], v)
+		}
+		return b
+	}
+
+	// a counter sample with a host CPU record four bytes too long
+	size := uint32(binary.Size(sflow.HostCPUCounters{})) + 4
+
+	b := words(5, 1, 0xc0000201, 0, 1, 0, 1, sflow.TypeCounterSample, 5*4+size, 1, 1, 1, sflow.TypeHostCPUCountersRecord, size)
+	b = append(b, make([]byte, size)...)
+
+	// a datagram of the same agent ending after its header
+	b = append(b, words(5, 1, 0xc0000201, 0, 2, 0, 1)...)
+
+	name := filepath.Join(t.TempDir(), "errors.dump")
+	if err := ioutil.WriteFile(name, b, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	out := runDumper(t, true, "human", "", "", "", name)
+
+	for _, line := range []string{
+		"192.0.2.1                                         1          0          1          1          2",
+		"total                                             1          0          1          1          2",
+	} {
+		if !strings.Contains(out, line) {
+			t.Errorf("expected summary to contain %q, got\n%s", line, out)
+		}
+	}
+
+	// datagrams without selected samples are still counted
+	out = runDumper(t, true, "human", "", "flow", "", name)
+
+	line := "192.0.2.1                                         1          0          0          0          2"
+	if !strings.Contains(out, line) {
+		t.Errorf("expected summary to contain %q, got\n%s", line, out)
+	}
+}
+
+func TestParseRecordType(t *testing.T) {
+	for s, expected := range map[string]int{
+		"1":      1,
+		"2003":   2003,
+		"0:1001": 1001,
+		"4413:1": 4413<<12 | 1,
+	} {
+		typ, err := parseRecordType(s)
+		if err != nil || typ != expected {
+			t.Errorf("%s: expected %d, got %d (%v)", s, expected, typ, err)
+		}
+	}
+}
+
+func TestDumpAggregate(t *testing.T) {
+	buf := &bytes.Buffer{}
+	out := bufio.NewWriter(buf)
+
+	d, err := newDumper(out, "human", "", "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = d.setAggregate("srcip/24,dstport,proto", 0, 10, "octets")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 2; i++ {
+		err = d.readFile("../../_test/flow_sample.dump")
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	d.writeWindows(d.aggregator.Flush())
+
+	for _, line := range []string{
+		"         srcip  dstport  proto  samples  packets   octets",
+		"  199.58.161.0     9728     17        2     8192  2457600",
+		"         total                        2     8192  2457600",
+	} {
+		if !strings.Contains(buf.String(), line+"\n") {
+			t.Errorf("expected line %q in\n%s", line, buf.String())
+		}
+	}
+
+	if d.setAggregate("srcip", 0, 10, "bytes") == nil {
+		t.Error("expected an error for an unknown order")
+	}
+}
@@ -0,0 +1,314 @@
+// Command sflowreplay sends captured sFlow datagrams to a collector,
+// for example to load-test it.
+//
+// Usage:
+//
+//	sflowreplay -target host:port [flags] file ...
+//
+// Files may be pcap or pcapng captures of sFlow traffic or raw datagram
+// dumps such as the ones in _test/. Datagrams are sent with their
+// original timing (-mode original, optionally sped up with -speed), at a
+// fixed rate (-mode rate -rate N) or as fast as possible (-mode max).
+// Raw dumps carry no timestamps, so their datagrams are sent back to back
+// in original mode.
+//
+// -agent rewrites the agent address of every datagram, and -renumber
+// numbers the datagrams of each agent and sub-agent from 1. Both only
+// rewrite the datagram header, samples and uptimes are sent as captured.
+// Otherwise datagrams are sent unchanged.
+//
+// The achieved throughput is reported every -report interval and when
+// the replay ends.
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"net"
+	"os"
+	"time"
+
+	"github.com/kanocz/sflow"
+	"github.com/kanocz/sflow/pcap"
+)
+
+func main() {
+	target := flag.String("target", "", "UDP `address` of the collector")
+	mode := flag.String("mode", "original", "send timing: original, rate or max")
+	rate := flag.Float64("rate", 1000, "datagrams per second in rate mode")
+	speed := flag.Float64("speed", 1, "speed-up `factor` in original mode")
+	loops := flag.Int("loop", 1, "number of times to send the input, 0 for ever")
+	agent := flag.String("agent", "", "rewrite the agent `address` of every datagram")
+	renumber := flag.Bool("renumber", false, "renumber datagrams per agent")
+	report := flag.Duration("report", time.Second, "throughput report `interval`, 0 to report only at the end")
+	flag.Parse()
+
+	if *target == "" || flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	var datagrams []datagram
+	for _, name := range flag.Args() {
+		d, err := readFile(name)
+		if err != nil {
+			log.Fatalf("%s: %v", name, err)
+		}
+		datagrams = append(datagrams, d...)
+	}
+
+	conn, err := net.Dial("udp", *target)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer conn.Close()
+
+	r := &replayer{
+		w:        conn,
+		mode:     *mode,
+		rate:     *rate,
+		speed:    *speed,
+		report:   *report,
+		log:      os.Stderr,
+		renumber: *renumber,
+	}
+
+	if *agent != "" {
+		r.agent = net.ParseIP(*agent)
+		if r.agent == nil {
+			log.Fatalf("invalid agent address %q", *agent)
+		}
+	}
+
+	err = r.run(datagrams, *loops)
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+// datagram is a captured datagram. Time is zero if the input
+// had no timestamps.
+type datagram struct {
+	payload []byte
+	time    time.Time
+}
+
+// readFile reads the datagrams of a capture or raw dump file.
+func readFile(name string) ([]datagram, error) {
+	b, err := ioutil.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+
+	cr, err := pcap.NewDatagramReader(bytes.NewReader(b))
+	if err == pcap.ErrUnknownFormat {
+		return readDump(b)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var datagrams []datagram
+
+	for {
+		d, err := cr.Next()
+		if err == io.EOF {
+			return datagrams, nil
+		}
+		if d == nil {
+			return nil, err
+		}
+
+		// datagrams that fail to decode are replayed as well,
+		// a collector has to cope with them
+		datagrams = append(datagrams, datagram{payload: d.Payload, time: d.Time})
+	}
+}
+
+// readDump splits a raw dump of datagrams written back to back.
+func readDump(b []byte) ([]datagram, error) {
+	var datagrams []datagram
+
+	r := bytes.NewReader(b)
+	d := sflow.NewDecoder(r)
+
+	for r.Len() > 0 {
+		start := len(b) - r.Len()
+
+		_, err := d.Decode()
+		if err != nil {
+			return nil, err
+		}
+
+		datagrams = append(datagrams, datagram{payload: b[start : len(b)-r.Len()]})
+	}
+
+	return datagrams, nil
+}
+
+// errInvalidHeader is returned for datagrams whose header
+// cannot be rewritten.
+var errInvalidHeader = errors.New("invalid sFlow datagram header")
+
+type sequenceKey struct {
+	agent      string
+	subAgentID uint32
+}
+
+type replayer struct {
+	w      io.Writer
+	mode   string
+	rate   float64
+	speed  float64
+	report time.Duration
+	log    io.Writer
+
+	agent     net.IP
+	renumber  bool
+	sequences map[sequenceKey]uint32
+	buf       []byte
+
+	sent   int
+	bytes  int
+	errors int
+}
+
+// run sends datagrams loops times, or for ever if loops is 0.
+func (r *replayer) run(datagrams []datagram, loops int) error {
+	switch r.mode {
+	case "original", "max":
+	case "rate":
+		if r.rate <= 0 {
+			return fmt.Errorf("invalid rate %v", r.rate)
+		}
+	default:
+		return fmt.Errorf("unknown mode %q", r.mode)
+	}
+
+	if r.speed <= 0 {
+		return fmt.Errorf("invalid speed %v", r.speed)
+	}
+
+	start := time.Now()
+	lastReport, lastSent, lastBytes := start, 0, 0
+	// when the current loop started, in original mode
+	loopStart := start
+
+	for loop := 0; loops == 0 || loop < loops; loop++ {
+		for i, d := range datagrams {
+			switch r.mode {
+			case "original":
+				if !d.time.IsZero() && !datagrams[0].time.IsZero() {
+					offset := float64(d.time.Sub(datagrams[0].time)) / r.speed
+					sleepUntil(loopStart.Add(time.Duration(offset)))
+				}
+			case "rate":
+				n := loop*len(datagrams) + i
+				sleepUntil(start.Add(time.Duration(float64(n) * float64(time.Second) / r.rate)))
+			}
+
+			payload, err := r.payload(d.payload)
+			if err != nil {
+				r.errors++
+				continue
+			}
+
+			_, err = r.w.Write(payload)
+			if err != nil {
+				r.errors++
+				continue
+			}
+
+			r.sent++
+			r.bytes += len(payload)
+
+			if now := time.Now(); r.report > 0 && now.Sub(lastReport) >= r.report {
+				r.printRate(now.Sub(lastReport), r.sent-lastSent, r.bytes-lastBytes)
+				lastReport, lastSent, lastBytes = now, r.sent, r.bytes
+			}
+		}
+
+		loopStart = time.Now()
+	}
+
+	elapsed := time.Since(start)
+	fmt.Fprintf(r.log, "total: ")
+	r.printRate(elapsed, r.sent, r.bytes)
+
+	return nil
+}
+
+func (r *replayer) printRate(elapsed time.Duration, sent, bytes int) {
+	seconds := elapsed.Seconds()
+	if seconds <= 0 {
+		seconds = 1e-9
+	}
+
+	fmt.Fprintf(r.log, "%d datagrams, %d bytes in %v: %.1f datagrams/s, %.3f Mbit/s, %d errors\n",
+		sent, bytes, elapsed.Round(time.Millisecond),
+		float64(sent)/seconds, float64(bytes)*8/seconds/1e6, r.errors)
+}
+
+// payload returns the datagram to send for b, with the header
+// rewritten if agents or sequence numbers are.
+func (r *replayer) payload(b []byte) ([]byte, error) {
+	if r.agent == nil && !r.renumber {
+		return b, nil
+	}
+
+	if len(b) < 8 || binary.BigEndian.Uint32(b) != 5 {
+		return nil, errInvalidHeader
+	}
+
+	ipLen := net.IPv4len
+	if binary.BigEndian.Uint32(b[4:]) == 2 {
+		ipLen = net.IPv6len
+	}
+
+	// the address is followed by the sub-agent ID, sequence
+	// number, uptime and number of samples
+	if len(b) < 8+ipLen+16 {
+		return nil, errInvalidHeader
+	}
+
+	agent, rest := net.IP(b[8:8+ipLen]), b[8+ipLen:]
+	if r.agent != nil {
+		agent = r.agent
+	}
+
+	ipVersion, ip := uint32(1), agent.To4()
+	if ip == nil {
+		ipVersion, ip = 2, agent.To16()
+	}
+
+	r.buf = append(r.buf[:0], b[:4]...)
+	r.buf = append(r.buf, 0, 0, 0, 0)
+	binary.BigEndian.PutUint32(r.buf[4:], ipVersion)
+	r.buf = append(r.buf, ip...)
+	r.buf = append(r.buf, rest...)
+
+	if r.renumber {
+		if r.sequences == nil {
+			r.sequences = make(map[sequenceKey]uint32)
+		}
+
+		key := sequenceKey{agent: agent.String(), subAgentID: binary.BigEndian.Uint32(rest)}
+		r.sequences[key]++
+
+		binary.BigEndian.PutUint32(r.buf[8+len(ip)+4:], r.sequences[key])
+	}
+
+	return r.buf, nil
+}
+
+func sleepUntil(t time.Time) {
+	if d := time.Until(t); d > 0 {
+		time.Sleep(d)
+	}
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net"
+	"testing"
+
+	"github.com/kanocz/sflow"
+)
+
+type packets [][]byte
+
+func (p *packets) Write(b []byte) (int, error) {
+	*p = append(*p, append([]byte(nil), b...))
+	return len(b), nil
+}
+
+func TestReadDump(t *testing.T) {
+	a, err := ioutil.ReadFile("../../_test/flow_sample.dump")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := ioutil.ReadFile("../../_test/host_sample.dump")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	datagrams, err := readDump(append(append([]byte(nil), a...), b...))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(datagrams) != 2 || !bytes.Equal(datagrams[0].payload, a) || !bytes.Equal(datagrams[1].payload, b) {
+		t.Fatalf("unexpected datagrams %v", datagrams)
+	}
+}
+
+func TestReplayRewrite(t *testing.T) {
+	// an IPv4 agent with a flow sample
+	payload, err := ioutil.ReadFile("../../_test/flow_sample.dump")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	original, err := sflow.NewPacketDecoder().Decode(payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the sub-agent ID, sequence number, uptime and samples
+	rest := payload[8+net.IPv4len:]
+
+	for _, c := range []struct {
+		agent    string
+		renumber bool
+	}{
+		{agent: "192.0.2.1"},
+		{agent: "2001:db8::1"},
+		{renumber: true},
+		{agent: "2001:db8::1", renumber: true},
+	} {
+		var out packets
+		r := &replayer{
+			w:        &out,
+			mode:     "max",
+			speed:    1,
+			log:      ioutil.Discard,
+			agent:    net.ParseIP(c.agent),
+			renumber: c.renumber,
+		}
+
+		err = r.run([]datagram{{payload: payload}}, 3)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(out) != 3 || r.sent != 3 || r.errors != 0 {
+			t.Fatalf("%+v: expected 3 datagrams, sent %d with %d errors", c, len(out), r.errors)
+		}
+
+		for i, b := range out {
+			dgram, err := sflow.NewPacketDecoder().Decode(b)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			agent, sequenceNumber := original.IpAddress, original.SequenceNumber
+			if c.agent != "" {
+				agent = net.ParseIP(c.agent)
+			}
+			if c.renumber {
+				sequenceNumber = uint32(i + 1)
+			}
+
+			if !dgram.IpAddress.Equal(agent) || dgram.SequenceNumber != sequenceNumber {
+				t.Errorf("%+v: datagram %d: unexpected agent %v or sequence number %d",
+					c, i, dgram.IpAddress, dgram.SequenceNumber)
+			}
+
+			if dgram.Uptime != original.Uptime || dgram.SubAgentId != original.SubAgentId {
+				t.Errorf("%+v: datagram %d: unexpected uptime %d or sub-agent %d",
+					c, i, dgram.Uptime, dgram.SubAgentId)
+			}
+
+			// the samples are sent as captured
+			if !bytes.Equal(b[len(b)-len(rest)+8:], rest[8:]) {
+				t.Errorf("%+v: datagram %d: samples differ", c, i)
+			}
+		}
+	}
+}
+
+func TestReplayInvalidHeader(t *testing.T) {
+	var out packets
+	r := &replayer{w: &out, mode: "max", speed: 1, log: ioutil.Discard, renumber: true}
+
+	err := r.run([]datagram{{payload: []byte{0, 0, 0, 5, 0, 0, 0, 1, 192, 0, 2}}}, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(out) != 0 || r.errors != 1 {
+		t.Errorf("expected the datagram to be dropped, sent %d with %d errors", len(out), r.errors)
+	}
+}
@@ -4,32 +4,31 @@ import (
 	"encoding/binary"
 	"fmt"
 	"io"
-	"unsafe"
 
 	"github.com/kanocz/sflow/records"
 )
 
 // GenericInterfaceCounters is a generic switch counters record.
 type GenericInterfaceCounters struct {
-	Index               uint32
-	Type                uint32
-	Speed               uint64
-	Direction           uint32
-	Status              uint32
-	InOctets            uint64
-	InUnicastPackets    uint32
-	InMulticastPackets  uint32
-	InBroadcastPackets  uint32
-	InDiscards          uint32
-	InErrors            uint32
-	InUnknownProtocols  uint32
-	OutOctets           uint64
-	OutUnicastPackets   uint32
-	OutMulticastPackets uint32
-	OutBroadcastPackets uint32
-	OutDiscards         uint32
-	OutErrors           uint32
-	PromiscuousMode     uint32
+	Index               uint32 `json:"index"`
+	Type                uint32 `json:"type"`
+	Speed               uint64 `json:"speed"`
+	Direction           uint32 `json:"direction"`
+	Status              uint32 `json:"status"`
+	InOctets            uint64 `json:"inOctets"`
+	InUnicastPackets    uint32 `json:"inUnicastPackets"`
+	InMulticastPackets  uint32 `json:"inMulticastPackets"`
+	InBroadcastPackets  uint32 `json:"inBroadcastPackets"`
+	InDiscards          uint32 `json:"inDiscards"`
+	InErrors            uint32 `json:"inErrors"`
+	InUnknownProtocols  uint32 `json:"inUnknownProtocols"`
+	OutOctets           uint64 `json:"outOctets"`
+	OutUnicastPackets   uint32 `json:"outUnicastPackets"`
+	OutMulticastPackets uint32 `json:"outMulticastPackets"`
+	OutBroadcastPackets uint32 `json:"outBroadcastPackets"`
+	OutDiscards         uint32 `json:"outDiscards"`
+	OutErrors           uint32 `json:"outErrors"`
+	PromiscuousMode     uint32 `json:"promiscuousMode"`
 }
 
 func (c GenericInterfaceCounters) String() string {
@@ -45,19 +44,19 @@ func (c GenericInterfaceCounters) RecordName() string {
 
 // EthernetCounters is an Ethernet interface counters record.
 type EthernetCounters struct {
-	AlignmentErrors           uint32
-	FCSErrors                 uint32
-	SingleCollisionFrames     uint32
-	MultipleCollisionFrames   uint32
-	SQETestErrors             uint32
-	DeferredTransmissions     uint32
-	LateCollisions            uint32
-	ExcessiveCollisions       uint32
-	InternalMACTransmitErrors uint32
-	CarrierSenseErrors        uint32
-	FrameTooLongs             uint32
-	InternalMACReceiveErrors  uint32
-	SymbolErrors              uint32
+	AlignmentErrors           uint32 `json:"alignmentErrors"`
+	FCSErrors                 uint32 `json:"fcsErrors"`
+	SingleCollisionFrames     uint32 `json:"singleCollisionFrames"`
+	MultipleCollisionFrames   uint32 `json:"multipleCollisionFrames"`
+	SQETestErrors             uint32 `json:"sqeTestErrors"`
+	DeferredTransmissions     uint32 `json:"deferredTransmissions"`
+	LateCollisions            uint32 `json:"lateCollisions"`
+	ExcessiveCollisions       uint32 `json:"excessiveCollisions"`
+	InternalMACTransmitErrors uint32 `json:"internalMACTransmitErrors"`
+	CarrierSenseErrors        uint32 `json:"carrierSenseErrors"`
+	FrameTooLongs             uint32 `json:"frameTooLongs"`
+	InternalMACReceiveErrors  uint32 `json:"internalMACReceiveErrors"`
+	SymbolErrors              uint32 `json:"symbolErrors"`
 }
 
 func (c EthernetCounters) String() string {
@@ -73,24 +72,24 @@ func (c EthernetCounters) RecordName() string {
 
 // TokenRingCounters is a token ring interface counters record.
 type TokenRingCounters struct {
-	LineErrors         uint32
-	BurstErrors        uint32
-	ACErrors           uint32
-	AbortTransErrors   uint32
-	InternalErrors     uint32
-	LostFrameErrors    uint32
-	ReceiveCongestions uint32
-	FrameCopiedErrors  uint32
-	TokenErrors        uint32
-	SoftErrors         uint32
-	HardErrors         uint32
-	SignalLoss         uint32
-	TransmitBeacons    uint32
-	Recoverys          uint32
-	LobeWires          uint32
-	Removes            uint32
-	Singles            uint32
-	FreqErrors         uint32
+	LineErrors         uint32 `json:"lineErrors"`
+	BurstErrors        uint32 `json:"burstErrors"`
+	ACErrors           uint32 `json:"acErrors"`
+	AbortTransErrors   uint32 `json:"abortTransErrors"`
+	InternalErrors     uint32 `json:"internalErrors"`
+	LostFrameErrors    uint32 `json:"lostFrameErrors"`
+	ReceiveCongestions uint32 `json:"receiveCongestions"`
+	FrameCopiedErrors  uint32 `json:"frameCopiedErrors"`
+	TokenErrors        uint32 `json:"tokenErrors"`
+	SoftErrors         uint32 `json:"softErrors"`
+	HardErrors         uint32 `json:"hardErrors"`
+	SignalLoss         uint32 `json:"signalLoss"`
+	TransmitBeacons    uint32 `json:"transmitBeacons"`
+	Recoverys          uint32 `json:"recoverys"`
+	LobeWires          uint32 `json:"lobeWires"`
+	Removes            uint32 `json:"removes"`
+	Singles            uint32 `json:"singles"`
+	FreqErrors         uint32 `json:"freqErrors"`
 }
 
 func (c TokenRingCounters) String() string {
@@ -106,20 +105,20 @@ func (c TokenRingCounters) RecordName() string {
 
 // VgCounters is a BaseVG interface counters record.
 type VgCounters struct {
-	InHighPriorityFrames    uint32
-	InHighPriorityOctets    uint64
-	InNormPriorityFrames    uint32
-	InNormPriorityOctets    uint64
-	InIPMErrors             uint32
-	InOversizeFrameErrors   uint32
-	InDataErrors            uint32
-	InNullAddressedFrames   uint32
-	OutHighPriorityFrames   uint32
-	OutHighPriorityOctets   uint64
-	TransitionIntoTrainings uint32
-	HCInHighPriorityOctets  uint64
-	HCInNormPriorityOctets  uint64
-	HCOutHighPriorityOctets uint64
+	InHighPriorityFrames    uint32 `json:"inHighPriorityFrames"`
+	InHighPriorityOctets    uint64 `json:"inHighPriorityOctets"`
+	InNormPriorityFrames    uint32 `json:"inNormPriorityFrames"`
+	InNormPriorityOctets    uint64 `json:"inNormPriorityOctets"`
+	InIPMErrors             uint32 `json:"inIPMErrors"`
+	InOversizeFrameErrors   uint32 `json:"inOversizeFrameErrors"`
+	InDataErrors            uint32 `json:"inDataErrors"`
+	InNullAddressedFrames   uint32 `json:"inNullAddressedFrames"`
+	OutHighPriorityFrames   uint32 `json:"outHighPriorityFrames"`
+	OutHighPriorityOctets   uint64 `json:"outHighPriorityOctets"`
+	TransitionIntoTrainings uint32 `json:"transitionIntoTrainings"`
+	HCInHighPriorityOctets  uint64 `json:"hcInHighPriorityOctets"`
+	HCInNormPriorityOctets  uint64 `json:"hcInNormPriorityOctets"`
+	HCOutHighPriorityOctets uint64 `json:"hcOutHighPriorityOctets"`
 }
 
 func (c VgCounters) String() string {
@@ -135,12 +134,12 @@ func (c VgCounters) RecordName() string {
 
 // VlanCounters is a VLAN counters record.
 type VlanCounters struct {
-	ID               uint32
-	Octets           uint64
-	UnicastPackets   uint32
-	MulticastPackets uint32
-	BroadcastPackets uint32
-	Discards         uint32
+	ID               uint32 `json:"id"`
+	Octets           uint64 `json:"octets"`
+	UnicastPackets   uint32 `json:"unicastPackets"`
+	MulticastPackets uint32 `json:"multicastPackets"`
+	BroadcastPackets uint32 `json:"broadcastPackets"`
+	Discards         uint32 `json:"discards"`
 }
 
 func (c VlanCounters) String() string {
@@ -154,13 +153,130 @@ func (c VlanCounters) RecordName() string {
 	return "VlanCounters"
 }
 
+// IEEE80211Counters is an 802.11 interface counters record.
+type IEEE80211Counters struct {
+	TransmittedFragmentCount       uint32 `json:"transmittedFragmentCount"`
+	MulticastTransmittedFrameCount uint32 `json:"multicastTransmittedFrameCount"`
+	FailedCount                    uint32 `json:"failedCount"`
+	RetryCount                     uint32 `json:"retryCount"`
+	MultipleRetryCount             uint32 `json:"multipleRetryCount"`
+	FrameDuplicateCount            uint32 `json:"frameDuplicateCount"`
+	RTSSuccessCount                uint32 `json:"rtsSuccessCount"`
+	RTSFailureCount                uint32 `json:"rtsFailureCount"`
+	ACKFailureCount                uint32 `json:"ackFailureCount"`
+	ReceivedFragmentCount          uint32 `json:"receivedFragmentCount"`
+	MulticastReceivedFrameCount    uint32 `json:"multicastReceivedFrameCount"`
+	FCSErrorCount                  uint32 `json:"fcsErrorCount"`
+	TransmittedFrameCount          uint32 `json:"transmittedFrameCount"`
+	WEPUndecryptableCount          uint32 `json:"wepUndecryptableCount"`
+	QoSDiscardedFragmentCount      uint32 `json:"qosDiscardedFragmentCount"`
+	AssociatedStationCount         uint32 `json:"associatedStationCount"`
+	QoSCFPollsReceivedCount        uint32 `json:"qosCFPollsReceivedCount"`
+	QoSCFPollsUnusedCount          uint32 `json:"qosCFPollsUnusedCount"`
+	QoSCFPollsUnusableCount        uint32 `json:"qosCFPollsUnusableCount"`
+	QoSCFPollsLostCount            uint32 `json:"qosCFPollsLostCount"`
+}
+
+func (c IEEE80211Counters) String() string {
+	type X IEEE80211Counters
+	x := X(c)
+	return fmt.Sprintf("IEEE80211Counters: %+v", x)
+}
+
+// RecordName returns the Name of this counter record
+func (c IEEE80211Counters) RecordName() string {
+	return "IEEE80211Counters"
+}
+
+// LAGPortCounters is an IEEE 802.3ad link aggregation port counters record.
+// The system IDs are MAC addresses of type records.HardwareAddr, like the
+// addresses of flow records, so that they marshal to JSON as hex strings.
+type LAGPortCounters struct {
+	ActorSystemID        records.HardwareAddr `json:"actorSystemID"`
+	PartnerOperSystemID  records.HardwareAddr `json:"partnerOperSystemID"`
+	AttachedAggID        uint32               `json:"attachedAggID"`
+	ActorAdminState      uint8                `json:"actorAdminState"`
+	ActorOperState       uint8                `json:"actorOperState"`
+	PartnerAdminState    uint8                `json:"partnerAdminState"`
+	PartnerOperState     uint8                `json:"partnerOperState"`
+	LACPDUsRx            uint32               `json:"lacpdusRx"`
+	MarkerPDUsRx         uint32               `json:"markerPDUsRx"`
+	MarkerResponsePDUsRx uint32               `json:"markerResponsePDUsRx"`
+	UnknownRx            uint32               `json:"unknownRx"`
+	IllegalRx            uint32               `json:"illegalRx"`
+	LACPDUsTx            uint32               `json:"lacpdusTx"`
+	MarkerPDUsTx         uint32               `json:"markerPDUsTx"`
+	MarkerResponsePDUsTx uint32               `json:"markerResponsePDUsTx"`
+}
+
+func (c LAGPortCounters) String() string {
+	type X LAGPortCounters
+	x := X(c)
+	return fmt.Sprintf("LAGPortCounters: %+v", x)
+}
+
+// RecordName returns the Name of this counter record
+func (c LAGPortCounters) RecordName() string {
+	return "LAGPortCounters"
+}
+
+// SlowPathCounters is a fast path / slow path counters record.
+type SlowPathCounters struct {
+	Unknown     uint32 `json:"unknown"`
+	Other       uint32 `json:"other"`
+	CAMMiss     uint32 `json:"camMiss"`
+	CAMFull     uint32 `json:"camFull"`
+	NoHWSupport uint32 `json:"noHWSupport"`
+	Control     uint32 `json:"control"`
+}
+
+func (c SlowPathCounters) String() string {
+	type X SlowPathCounters
+	x := X(c)
+	return fmt.Sprintf("SlowPathCounters: %+v", x)
+}
+
+// RecordName returns the Name of this counter record
+func (c SlowPathCounters) RecordName() string {
+	return "SlowPathCounters"
+}
+
+// InfiniBandCounters is an InfiniBand port counters record.
+type InfiniBandCounters struct {
+	PortXmitPkts                 uint32 `json:"portXmitPkts"`
+	PortRcvPkts                  uint32 `json:"portRcvPkts"`
+	SymbolErrorCounter           uint32 `json:"symbolErrorCounter"`
+	LinkErrorRecoveryCounter     uint32 `json:"linkErrorRecoveryCounter"`
+	LinkDownedCounter            uint32 `json:"linkDownedCounter"`
+	PortRcvErrors                uint32 `json:"portRcvErrors"`
+	PortRcvRemotePhysicalErrors  uint32 `json:"portRcvRemotePhysicalErrors"`
+	PortRcvSwitchRelayErrors     uint32 `json:"portRcvSwitchRelayErrors"`
+	PortXmitDiscards             uint32 `json:"portXmitDiscards"`
+	PortXmitConstraintErrors     uint32 `json:"portXmitConstraintErrors"`
+	PortRcvConstraintErrors      uint32 `json:"portRcvConstraintErrors"`
+	LocalLinkIntegrityErrors     uint32 `json:"localLinkIntegrityErrors"`
+	ExcessiveBufferOverrunErrors uint32 `json:"excessiveBufferOverrunErrors"`
+	VL15Dropped                  uint32 `json:"vl15Dropped"`
+}
+
+func (c InfiniBandCounters) String() string {
+	type X InfiniBandCounters
+	x := X(c)
+	return fmt.Sprintf("InfiniBandCounters: %+v", x)
+}
+
+// RecordName returns the Name of this counter record
+func (c InfiniBandCounters) RecordName() string {
+	return "InfiniBandCounters"
+}
+
 // ProcessorCounters is a switch processor counters record.
 type ProcessorCounters struct {
-	CPU5s       uint32
-	CPU1m       uint32
-	CPU5m       uint32
-	TotalMemory uint64
-	FreeMemory  uint64
+	CPU5s       uint32 `json:"cpu5s"`
+	CPU1m       uint32 `json:"cpu1m"`
+	CPU5m       uint32 `json:"cpu5m"`
+	TotalMemory uint64 `json:"totalMemory"`
+	FreeMemory  uint64 `json:"freeMemory"`
 }
 
 func (c ProcessorCounters) String() string {
@@ -174,30 +290,109 @@ func (c ProcessorCounters) RecordName() string {
 	return "ProcessorCounters"
 }
 
+// RadioUtilizationCounters is an 802.11 radio utilization record.
+type RadioUtilizationCounters struct {
+	ElapsedTime       uint32 `json:"elapsedTime"`       // ms
+	OnChannelTime     uint32 `json:"onChannelTime"`     // ms
+	OnChannelBusyTime uint32 `json:"onChannelBusyTime"` // ms
+}
+
+func (c RadioUtilizationCounters) String() string {
+	type X RadioUtilizationCounters
+	x := X(c)
+	return fmt.Sprintf("RadioUtilizationCounters: %+v", x)
+}
+
+// RecordName returns the Name of this counter record
+func (c RadioUtilizationCounters) RecordName() string {
+	return "RadioUtilizationCounters"
+}
+
+// QueueLengthCounters is a queue length histogram record.
+type QueueLengthCounters struct {
+	QueueIndex      uint32 `json:"queueIndex"`
+	SegmentSize     uint32 `json:"segmentSize"`
+	QueueSegments   uint32 `json:"queueSegments"`
+	QueueLength0    uint32 `json:"queueLength0"`
+	QueueLength1    uint32 `json:"queueLength1"`
+	QueueLength2    uint32 `json:"queueLength2"`
+	QueueLength4    uint32 `json:"queueLength4"`
+	QueueLength8    uint32 `json:"queueLength8"`
+	QueueLength32   uint32 `json:"queueLength32"`
+	QueueLength128  uint32 `json:"queueLength128"`
+	QueueLength1024 uint32 `json:"queueLength1024"`
+	QueueLengthMore uint32 `json:"queueLengthMore"`
+	Dropped         uint32 `json:"dropped"`
+}
+
+func (c QueueLengthCounters) String() string {
+	type X QueueLengthCounters
+	x := X(c)
+	return fmt.Sprintf("QueueLengthCounters: %+v", x)
+}
+
+// RecordName returns the Name of this counter record
+func (c QueueLengthCounters) RecordName() string {
+	return "QueueLengthCounters"
+}
+
+// OpenFlowPortCounters maps a data source to an OpenFlow datapath port.
+type OpenFlowPortCounters struct {
+	DatapathID uint64 `json:"datapathID"`
+	PortNumber uint32 `json:"portNumber"`
+}
+
+func (c OpenFlowPortCounters) String() string {
+	type X OpenFlowPortCounters
+	x := X(c)
+	return fmt.Sprintf("OpenFlowPortCounters: %+v", x)
+}
+
+// RecordName returns the Name of this counter record
+func (c OpenFlowPortCounters) RecordName() string {
+	return "OpenFlowPortCounters"
+}
+
+// PortNameCounters carries the name of a data source port.
+type PortNameCounters struct {
+	Name string `json:"name"`
+}
+
+func (c PortNameCounters) String() string {
+	type X PortNameCounters
+	x := X(c)
+	return fmt.Sprintf("PortNameCounters: %+v", x)
+}
+
+// RecordName returns the Name of this counter record
+func (c PortNameCounters) RecordName() string {
+	return "PortNameCounters"
+}
+
 // HostCPUCounters is a host CPU counters record.
 type HostCPUCounters struct {
-	Load1m           float32
-	Load5m           float32
-	Load15m          float32
-	ProcessesRunning uint32
-	ProcessesTotal   uint32
-	NumCPU           uint32
-	SpeedCPU         uint32
-	Uptime           uint32
-
-	CPUUser         uint32
-	CPUNice         uint32
-	CPUSys          uint32
-	CPUIdle         uint32
-	CPUWio          uint32
-	CPUIntr         uint32
-	CPUSoftIntr     uint32
-	Interrupts      uint32
-	ContextSwitches uint32
-
-	CPUSteal     uint32
-	CPUGuest     uint32
-	CPUGuestNice uint32
+	Load1m           float32 `json:"load1m"`
+	Load5m           float32 `json:"load5m"`
+	Load15m          float32 `json:"load15m"`
+	ProcessesRunning uint32  `json:"processesRunning"`
+	ProcessesTotal   uint32  `json:"processesTotal"`
+	NumCPU           uint32  `json:"numCPU"`
+	SpeedCPU         uint32  `json:"speedCPU"`
+	Uptime           uint32  `json:"uptime"`
+
+	CPUUser         uint32 `json:"cpuUser"`
+	CPUNice         uint32 `json:"cpuNice"`
+	CPUSys          uint32 `json:"cpuSys"`
+	CPUIdle         uint32 `json:"cpuIdle"`
+	CPUWio          uint32 `json:"cpuWio"`
+	CPUIntr         uint32 `json:"cpuIntr"`
+	CPUSoftIntr     uint32 `json:"cpuSoftIntr"`
+	Interrupts      uint32 `json:"interrupts"`
+	ContextSwitches uint32 `json:"contextSwitches"`
+
+	CPUSteal     uint32 `json:"cpuSteal"`
+	CPUGuest     uint32 `json:"cpuGuest"`
+	CPUGuestNice uint32 `json:"cpuGuestNice"`
 }
 
 func (c HostCPUCounters) String() string {
@@ -213,18 +408,18 @@ func (c HostCPUCounters) RecordName() string {
 
 // HostMemoryCounters is a host memory counters record.
 type HostMemoryCounters struct {
-	Total     uint64
-	Free      uint64
-	Shared    uint64
-	Buffers   uint64
-	Cached    uint64
-	SwapTotal uint64
-	SwapFree  uint64
-
-	PageIn  uint32
-	PageOut uint32
-	SwapIn  uint32
-	SwapOut uint32
+	Total     uint64 `json:"total"`
+	Free      uint64 `json:"free"`
+	Shared    uint64 `json:"shared"`
+	Buffers   uint64 `json:"buffers"`
+	Cached    uint64 `json:"cached"`
+	SwapTotal uint64 `json:"swapTotal"`
+	SwapFree  uint64 `json:"swapFree"`
+
+	PageIn  uint32 `json:"pageIn"`
+	PageOut uint32 `json:"pageOut"`
+	SwapIn  uint32 `json:"swapIn"`
+	SwapOut uint32 `json:"swapOut"`
 }
 
 func (c HostMemoryCounters) String() string {
@@ -240,15 +435,15 @@ func (c HostMemoryCounters) RecordName() string {
 
 // HostDiskCounters is a host disk counters record.
 type HostDiskCounters struct {
-	Total          uint64
-	Free           uint64
-	MaxUsedPercent float32
-	Reads          uint32
-	BytesRead      uint64
-	ReadTime       uint32
-	Writes         uint32
-	BytesWritten   uint64
-	WriteTime      uint32
+	Total          uint64  `json:"total"`
+	Free           uint64  `json:"free"`
+	MaxUsedPercent float32 `json:"maxUsedPercent"`
+	Reads          uint32  `json:"reads"`
+	BytesRead      uint64  `json:"bytesRead"`
+	ReadTime       uint32  `json:"readTime"`
+	Writes         uint32  `json:"writes"`
+	BytesWritten   uint64  `json:"bytesWritten"`
+	WriteTime      uint32  `json:"writeTime"`
 }
 
 func (c HostDiskCounters) String() string {
@@ -264,15 +459,15 @@ func (c HostDiskCounters) RecordName() string {
 
 // HostNetCounters is a host network counters record.
 type HostNetCounters struct {
-	BytesIn   uint64
-	PacketsIn uint32
-	ErrorsIn  uint32
-	DropsIn   uint32
-
-	BytesOut   uint64
-	PacketsOut uint32
-	ErrorsOut  uint32
-	DropsOut   uint32
+	BytesIn   uint64 `json:"bytesIn"`
+	PacketsIn uint32 `json:"packetsIn"`
+	ErrorsIn  uint32 `json:"errorsIn"`
+	DropsIn   uint32 `json:"dropsIn"`
+
+	BytesOut   uint64 `json:"bytesOut"`
+	PacketsOut uint32 `json:"packetsOut"`
+	ErrorsOut  uint32 `json:"errorsOut"`
+	DropsOut   uint32 `json:"dropsOut"`
 }
 
 func (c HostNetCounters) String() string {
@@ -286,19 +481,464 @@ func (c HostNetCounters) RecordName() string {
 	return "HostNetCounters"
 }
 
+// MIB2IPGroupCounters is a host IP counters record (RFC 2013 ip group).
+type MIB2IPGroupCounters struct {
+	Forwarding      uint32 `json:"forwarding"`
+	DefaultTTL      uint32 `json:"defaultTTL"`
+	InReceives      uint32 `json:"inReceives"`
+	InHdrErrors     uint32 `json:"inHdrErrors"`
+	InAddrErrors    uint32 `json:"inAddrErrors"`
+	ForwDatagrams   uint32 `json:"forwDatagrams"`
+	InUnknownProtos uint32 `json:"inUnknownProtos"`
+	InDiscards      uint32 `json:"inDiscards"`
+	InDelivers      uint32 `json:"inDelivers"`
+	OutRequests     uint32 `json:"outRequests"`
+	OutDiscards     uint32 `json:"outDiscards"`
+	OutNoRoutes     uint32 `json:"outNoRoutes"`
+	ReasmTimeout    uint32 `json:"reasmTimeout"`
+	ReasmReqds      uint32 `json:"reasmReqds"`
+	ReasmOKs        uint32 `json:"reasmOKs"`
+	ReasmFails      uint32 `json:"reasmFails"`
+	FragOKs         uint32 `json:"fragOKs"`
+	FragFails       uint32 `json:"fragFails"`
+	FragCreates     uint32 `json:"fragCreates"`
+}
+
+func (c MIB2IPGroupCounters) String() string {
+	type X MIB2IPGroupCounters
+	x := X(c)
+	return fmt.Sprintf("MIB2IPGroupCounters: %+v", x)
+}
+
+// RecordName returns the Name of this counter record
+func (c MIB2IPGroupCounters) RecordName() string {
+	return "MIB2IPGroupCounters"
+}
+
+// MIB2ICMPGroupCounters is a host ICMP counters record (RFC 2013 icmp group).
+type MIB2ICMPGroupCounters struct {
+	InMsgs           uint32 `json:"inMsgs"`
+	InErrors         uint32 `json:"inErrors"`
+	InDestUnreachs   uint32 `json:"inDestUnreachs"`
+	InTimeExcds      uint32 `json:"inTimeExcds"`
+	InParamProbs     uint32 `json:"inParamProbs"`
+	InSrcQuenchs     uint32 `json:"inSrcQuenchs"`
+	InRedirects      uint32 `json:"inRedirects"`
+	InEchos          uint32 `json:"inEchos"`
+	InEchoReps       uint32 `json:"inEchoReps"`
+	InTimestamps     uint32 `json:"inTimestamps"`
+	InAddrMasks      uint32 `json:"inAddrMasks"`
+	InAddrMaskReps   uint32 `json:"inAddrMaskReps"`
+	OutMsgs          uint32 `json:"outMsgs"`
+	OutErrors        uint32 `json:"outErrors"`
+	OutDestUnreachs  uint32 `json:"outDestUnreachs"`
+	OutTimeExcds     uint32 `json:"outTimeExcds"`
+	OutParamProbs    uint32 `json:"outParamProbs"`
+	OutSrcQuenchs    uint32 `json:"outSrcQuenchs"`
+	OutRedirects     uint32 `json:"outRedirects"`
+	OutEchos         uint32 `json:"outEchos"`
+	OutEchoReps      uint32 `json:"outEchoReps"`
+	OutTimestamps    uint32 `json:"outTimestamps"`
+	OutTimestampReps uint32 `json:"outTimestampReps"`
+	OutAddrMasks     uint32 `json:"outAddrMasks"`
+	OutAddrMaskReps  uint32 `json:"outAddrMaskReps"`
+}
+
+func (c MIB2ICMPGroupCounters) String() string {
+	type X MIB2ICMPGroupCounters
+	x := X(c)
+	return fmt.Sprintf("MIB2ICMPGroupCounters: %+v", x)
+}
+
+// RecordName returns the Name of this counter record
+func (c MIB2ICMPGroupCounters) RecordName() string {
+	return "MIB2ICMPGroupCounters"
+}
+
+// MIB2TCPGroupCounters is a host TCP counters record (RFC 2012 tcp group).
+type MIB2TCPGroupCounters struct {
+	RtoAlgorithm uint32 `json:"rtoAlgorithm"`
+	RtoMin       uint32 `json:"rtoMin"`
+	RtoMax       uint32 `json:"rtoMax"`
+	MaxConn      uint32 `json:"maxConn"`
+	ActiveOpens  uint32 `json:"activeOpens"`
+	PassiveOpens uint32 `json:"passiveOpens"`
+	AttemptFails uint32 `json:"attemptFails"`
+	EstabResets  uint32 `json:"estabResets"`
+	CurrEstab    uint32 `json:"currEstab"`
+	InSegs       uint32 `json:"inSegs"`
+	OutSegs      uint32 `json:"outSegs"`
+	RetransSegs  uint32 `json:"retransSegs"`
+	InErrs       uint32 `json:"inErrs"`
+	OutRsts      uint32 `json:"outRsts"`
+	InCsumErrors uint32 `json:"inCsumErrors"`
+}
+
+func (c MIB2TCPGroupCounters) String() string {
+	type X MIB2TCPGroupCounters
+	x := X(c)
+	return fmt.Sprintf("MIB2TCPGroupCounters: %+v", x)
+}
+
+// RecordName returns the Name of this counter record
+func (c MIB2TCPGroupCounters) RecordName() string {
+	return "MIB2TCPGroupCounters"
+}
+
+// MIB2UDPGroupCounters is a host UDP counters record (RFC 2013 udp group).
+type MIB2UDPGroupCounters struct {
+	InDatagrams  uint32 `json:"inDatagrams"`
+	NoPorts      uint32 `json:"noPorts"`
+	InErrors     uint32 `json:"inErrors"`
+	OutDatagrams uint32 `json:"outDatagrams"`
+	RcvbufErrors uint32 `json:"rcvbufErrors"`
+	SndbufErrors uint32 `json:"sndbufErrors"`
+	InCsumErrors uint32 `json:"inCsumErrors"`
+}
+
+func (c MIB2UDPGroupCounters) String() string {
+	type X MIB2UDPGroupCounters
+	x := X(c)
+	return fmt.Sprintf("MIB2UDPGroupCounters: %+v", x)
+}
+
+// RecordName returns the Name of this counter record
+func (c MIB2UDPGroupCounters) RecordName() string {
+	return "MIB2UDPGroupCounters"
+}
+
+// JMXRuntimeCounters is a Java virtual machine runtime record.
+type JMXRuntimeCounters struct {
+	VMName    string `json:"vmName"`
+	VMVendor  string `json:"vmVendor"`
+	VMVersion string `json:"vmVersion"`
+}
+
+func (c JMXRuntimeCounters) String() string {
+	type X JMXRuntimeCounters
+	x := X(c)
+	return fmt.Sprintf("JMXRuntimeCounters: %+v", x)
+}
+
+// RecordName returns the Name of this counter record
+func (c JMXRuntimeCounters) RecordName() string {
+	return "JMXRuntimeCounters"
+}
+
+// JMXStatisticsCounters is a Java virtual machine statistics record.
+type JMXStatisticsCounters struct {
+	HeapInitial         uint64 `json:"heapInitial"`
+	HeapUsed            uint64 `json:"heapUsed"`
+	HeapCommitted       uint64 `json:"heapCommitted"`
+	HeapMax             uint64 `json:"heapMax"`
+	NonHeapInitial      uint64 `json:"nonHeapInitial"`
+	NonHeapUsed         uint64 `json:"nonHeapUsed"`
+	NonHeapCommitted    uint64 `json:"nonHeapCommitted"`
+	NonHeapMax          uint64 `json:"nonHeapMax"`
+	GCCount             uint32 `json:"gcCount"`
+	GCTime              uint32 `json:"gcTime"`
+	ClassesLoaded       uint32 `json:"classesLoaded"`
+	ClassesTotal        uint32 `json:"classesTotal"`
+	ClassesUnloaded     uint32 `json:"classesUnloaded"`
+	CompilationTime     uint32 `json:"compilationTime"`
+	ThreadsLive         uint32 `json:"threadsLive"`
+	ThreadsDaemon       uint32 `json:"threadsDaemon"`
+	ThreadsStarted      uint32 `json:"threadsStarted"`
+	OpenFileDescriptors uint32 `json:"openFileDescriptors"`
+	MaxFileDescriptors  uint32 `json:"maxFileDescriptors"`
+}
+
+func (c JMXStatisticsCounters) String() string {
+	type X JMXStatisticsCounters
+	x := X(c)
+	return fmt.Sprintf("JMXStatisticsCounters: %+v", x)
+}
+
+// RecordName returns the Name of this counter record
+func (c JMXStatisticsCounters) RecordName() string {
+	return "JMXStatisticsCounters"
+}
+
+// OVSDPStatsCounters is an Open vSwitch datapath performance record.
+type OVSDPStatsCounters struct {
+	Hits     uint32 `json:"hits"`
+	Misses   uint32 `json:"misses"`
+	Lost     uint32 `json:"lost"`
+	MaskHits uint32 `json:"maskHits"`
+	Flows    uint32 `json:"flows"`
+	Masks    uint32 `json:"masks"`
+}
+
+func (c OVSDPStatsCounters) String() string {
+	type X OVSDPStatsCounters
+	x := X(c)
+	return fmt.Sprintf("OVSDPStatsCounters: %+v", x)
+}
+
+// RecordName returns the Name of this counter record
+func (c OVSDPStatsCounters) RecordName() string {
+	return "OVSDPStatsCounters"
+}
+
+// EnergyCounters is an energy consumption record.
+type EnergyCounters struct {
+	Voltage     uint32 `json:"voltage"`     // mV
+	Current     uint32 `json:"current"`     // mA
+	RealPower   uint32 `json:"realPower"`   // mW
+	PowerFactor int32  `json:"powerFactor"` // hundredths, -1 if unknown
+	Energy      uint32 `json:"energy"`      // mJ
+	Errors      uint32 `json:"errors"`
+}
+
+func (c EnergyCounters) String() string {
+	type X EnergyCounters
+	x := X(c)
+	return fmt.Sprintf("EnergyCounters: %+v", x)
+}
+
+// RecordName returns the Name of this counter record
+func (c EnergyCounters) RecordName() string {
+	return "EnergyCounters"
+}
+
+// TemperatureCounters is a temperature record.
+type TemperatureCounters struct {
+	Minimum int32  `json:"minimum"` // degrees Celsius
+	Maximum int32  `json:"maximum"` // degrees Celsius
+	Errors  uint32 `json:"errors"`
+}
+
+func (c TemperatureCounters) String() string {
+	type X TemperatureCounters
+	x := X(c)
+	return fmt.Sprintf("TemperatureCounters: %+v", x)
+}
+
+// RecordName returns the Name of this counter record
+func (c TemperatureCounters) RecordName() string {
+	return "TemperatureCounters"
+}
+
+// HumidityCounters is a relative humidity record.
+type HumidityCounters struct {
+	Relative int32 `json:"relative"` // percent
+}
+
+func (c HumidityCounters) String() string {
+	type X HumidityCounters
+	x := X(c)
+	return fmt.Sprintf("HumidityCounters: %+v", x)
+}
+
+// RecordName returns the Name of this counter record
+func (c HumidityCounters) RecordName() string {
+	return "HumidityCounters"
+}
+
+// FansCounters is a cooling fans record.
+type FansCounters struct {
+	Total  uint32 `json:"total"`
+	Failed uint32 `json:"failed"`
+	Speed  uint32 `json:"speed"` // percent
+}
+
+func (c FansCounters) String() string {
+	type X FansCounters
+	x := X(c)
+	return fmt.Sprintf("FansCounters: %+v", x)
+}
+
+// RecordName returns the Name of this counter record
+func (c FansCounters) RecordName() string {
+	return "FansCounters"
+}
+
+// BroadcomDeviceBuffersCounters is a Broadcom device level peak buffer
+// utilization record. Utilization is given in hundredths of a percent,
+// -1 if unknown.
+type BroadcomDeviceBuffersCounters struct {
+	UnicastPercent   int32 `json:"unicastPercent"`
+	MulticastPercent int32 `json:"multicastPercent"`
+}
+
+func (c BroadcomDeviceBuffersCounters) String() string {
+	type X BroadcomDeviceBuffersCounters
+	x := X(c)
+	return fmt.Sprintf("BroadcomDeviceBuffersCounters: %+v", x)
+}
+
+// RecordName returns the Name of this counter record
+func (c BroadcomDeviceBuffersCounters) RecordName() string {
+	return "BroadcomDeviceBuffersCounters"
+}
+
+// BroadcomPortBuffersCounters is a Broadcom port level peak buffer
+// utilization record with per egress queue utilization. Utilization is
+// given in hundredths of a percent, -1 if unknown.
+type BroadcomPortBuffersCounters struct {
+	IngressUnicastPercent       int32   `json:"ingressUnicastPercent"`
+	IngressMulticastPercent     int32   `json:"ingressMulticastPercent"`
+	EgressUnicastPercent        int32   `json:"egressUnicastPercent"`
+	EgressMulticastPercent      int32   `json:"egressMulticastPercent"`
+	EgressQueueUnicastPercent   []int32 `json:"egressQueueUnicastPercent"`
+	EgressQueueMulticastPercent []int32 `json:"egressQueueMulticastPercent"`
+}
+
+func (c BroadcomPortBuffersCounters) String() string {
+	type X BroadcomPortBuffersCounters
+	x := X(c)
+	return fmt.Sprintf("BroadcomPortBuffersCounters: %+v", x)
+}
+
+// RecordName returns the Name of this counter record
+func (c BroadcomPortBuffersCounters) RecordName() string {
+	return "BroadcomPortBuffersCounters"
+}
+
+// BroadcomHardwareTablesCounters is a Broadcom switch ASIC table
+// utilization record.
+type BroadcomHardwareTablesCounters struct {
+	HostEntries           uint32 `json:"hostEntries"`
+	HostEntriesMax        uint32 `json:"hostEntriesMax"`
+	IPv4Entries           uint32 `json:"ipv4Entries"`
+	IPv4EntriesMax        uint32 `json:"ipv4EntriesMax"`
+	IPv6Entries           uint32 `json:"ipv6Entries"`
+	IPv6EntriesMax        uint32 `json:"ipv6EntriesMax"`
+	IPv4IPv6Entries       uint32 `json:"ipv4IPv6Entries"`
+	IPv4IPv6EntriesMax    uint32 `json:"ipv4IPv6EntriesMax"`
+	LongIPv6Entries       uint32 `json:"longIPv6Entries"`
+	LongIPv6EntriesMax    uint32 `json:"longIPv6EntriesMax"`
+	TotalRoutes           uint32 `json:"totalRoutes"`
+	TotalRoutesMax        uint32 `json:"totalRoutesMax"`
+	ECMPNexthops          uint32 `json:"ecmpNexthops"`
+	ECMPNexthopsMax       uint32 `json:"ecmpNexthopsMax"`
+	MACEntries            uint32 `json:"macEntries"`
+	MACEntriesMax         uint32 `json:"macEntriesMax"`
+	IPv4Neighbors         uint32 `json:"ipv4Neighbors"`
+	IPv6Neighbors         uint32 `json:"ipv6Neighbors"`
+	IPv4Routes            uint32 `json:"ipv4Routes"`
+	IPv6Routes            uint32 `json:"ipv6Routes"`
+	ACLIngressEntries     uint32 `json:"aclIngressEntries"`
+	ACLIngressEntriesMax  uint32 `json:"aclIngressEntriesMax"`
+	ACLIngressCounters    uint32 `json:"aclIngressCounters"`
+	ACLIngressCountersMax uint32 `json:"aclIngressCountersMax"`
+	ACLIngressMeters      uint32 `json:"aclIngressMeters"`
+	ACLIngressMetersMax   uint32 `json:"aclIngressMetersMax"`
+	ACLIngressSlices      uint32 `json:"aclIngressSlices"`
+	ACLIngressSlicesMax   uint32 `json:"aclIngressSlicesMax"`
+	ACLEgressEntries      uint32 `json:"aclEgressEntries"`
+	ACLEgressEntriesMax   uint32 `json:"aclEgressEntriesMax"`
+	ACLEgressCounters     uint32 `json:"aclEgressCounters"`
+	ACLEgressCountersMax  uint32 `json:"aclEgressCountersMax"`
+	ACLEgressMeters       uint32 `json:"aclEgressMeters"`
+	ACLEgressMetersMax    uint32 `json:"aclEgressMetersMax"`
+	ACLEgressSlices       uint32 `json:"aclEgressSlices"`
+	ACLEgressSlicesMax    uint32 `json:"aclEgressSlicesMax"`
+}
+
+func (c BroadcomHardwareTablesCounters) String() string {
+	type X BroadcomHardwareTablesCounters
+	x := X(c)
+	return fmt.Sprintf("BroadcomHardwareTablesCounters: %+v", x)
+}
+
+// RecordName returns the Name of this counter record
+func (c BroadcomHardwareTablesCounters) RecordName() string {
+	return "BroadcomHardwareTablesCounters"
+}
+
+// NvidiaGPUCounters is an NVIDIA GPU (NVML) counters record.
+type NvidiaGPUCounters struct {
+	DeviceCount uint32 `json:"deviceCount"`
+	Processes   uint32 `json:"processes"`
+	GPUTime     uint32 `json:"gpuTime"` // ms, summed across devices
+	MemTime     uint32 `json:"memTime"` // ms, summed across devices
+	MemTotal    uint64 `json:"memTotal"`
+	MemFree     uint64 `json:"memFree"`
+	ECCErrors   uint32 `json:"eccErrors"`
+	Energy      uint32 `json:"energy"`      // mJ, summed across devices
+	Temperature uint32 `json:"temperature"` // degrees Celsius, maximum across devices
+	FanSpeed    uint32 `json:"fanSpeed"`    // percent, maximum across devices
+}
+
+func (c NvidiaGPUCounters) String() string {
+	type X NvidiaGPUCounters
+	x := X(c)
+	return fmt.Sprintf("NvidiaGPUCounters: %+v", x)
+}
+
+// RecordName returns the Name of this counter record
+func (c NvidiaGPUCounters) RecordName() string {
+	return "NvidiaGPUCounters"
+}
+
 var (
-	genericInterfaceCountersSize = uint32(unsafe.Sizeof(GenericInterfaceCounters{}))
-	ethernetCountersSize         = uint32(unsafe.Sizeof(EthernetCounters{}))
-	tokenRingCountersSize        = uint32(unsafe.Sizeof(TokenRingCounters{}))
-	vgCountersSize               = uint32(unsafe.Sizeof(VgCounters{}))
-	vlanCountersSize             = uint32(unsafe.Sizeof(VlanCounters{}))
-	processorCountersSize        = uint32(unsafe.Sizeof(ProcessorCounters{}))
-	hostCPUCountersSize          = uint32(unsafe.Sizeof(HostCPUCounters{}))
-	hostMemoryCountersSize       = uint32(unsafe.Sizeof(HostMemoryCounters{}))
-	hostDiskCountersSize         = uint32(unsafe.Sizeof(HostDiskCounters{}))
-	hostNetCountersSize          = uint32(unsafe.Sizeof(HostNetCounters{}))
+	genericInterfaceCountersSize       = uint32(binary.Size(GenericInterfaceCounters{}))
+	ethernetCountersSize               = uint32(binary.Size(EthernetCounters{}))
+	tokenRingCountersSize              = uint32(binary.Size(TokenRingCounters{}))
+	vgCountersSize                     = uint32(binary.Size(VgCounters{}))
+	vlanCountersSize                   = uint32(binary.Size(VlanCounters{}))
+	ieee80211CountersSize              = uint32(binary.Size(IEEE80211Counters{}))
+	slowPathCountersSize               = uint32(binary.Size(SlowPathCounters{}))
+	infiniBandCountersSize             = uint32(binary.Size(InfiniBandCounters{}))
+	processorCountersSize              = uint32(binary.Size(ProcessorCounters{}))
+	radioUtilizationCountersSize       = uint32(binary.Size(RadioUtilizationCounters{}))
+	queueLengthCountersSize            = uint32(binary.Size(QueueLengthCounters{}))
+	openFlowPortCountersSize           = uint32(binary.Size(OpenFlowPortCounters{}))
+	hostCPUCountersSize                = uint32(binary.Size(HostCPUCounters{}))
+	hostMemoryCountersSize             = uint32(binary.Size(HostMemoryCounters{}))
+	hostDiskCountersSize               = uint32(binary.Size(HostDiskCounters{}))
+	hostNetCountersSize                = uint32(binary.Size(HostNetCounters{}))
+	mib2IPGroupCountersSize            = uint32(binary.Size(MIB2IPGroupCounters{}))
+	mib2ICMPGroupCountersSize          = uint32(binary.Size(MIB2ICMPGroupCounters{}))
+	mib2TCPGroupCountersSize           = uint32(binary.Size(MIB2TCPGroupCounters{}))
+	mib2UDPGroupCountersSize           = uint32(binary.Size(MIB2UDPGroupCounters{}))
+	jmxStatisticsCountersSize          = uint32(binary.Size(JMXStatisticsCounters{}))
+	ovsDPStatsCountersSize             = uint32(binary.Size(OVSDPStatsCounters{}))
+	energyCountersSize                 = uint32(binary.Size(EnergyCounters{}))
+	temperatureCountersSize            = uint32(binary.Size(TemperatureCounters{}))
+	humidityCountersSize               = uint32(binary.Size(HumidityCounters{}))
+	fansCountersSize                   = uint32(binary.Size(FansCounters{}))
+	broadcomDeviceBuffersCountersSize  = uint32(binary.Size(BroadcomDeviceBuffersCounters{}))
+	broadcomHardwareTablesCountersSize = uint32(binary.Size(BroadcomHardwareTablesCounters{}))
+	nvidiaGPUCountersSize              = uint32(binary.Size(NvidiaGPUCounters{}))
 )
 
+// counterRecordSizes holds the sizes of the fixed size counter records.
+// Longer records come from newer agents. Their known fields are decoded,
+// and their extra bytes are skipped and reported as a length mismatch.
+var counterRecordSizes = map[uint32]uint32{
+	TypeGenericInterfaceCountersRecord:       genericInterfaceCountersSize,
+	TypeEthernetCountersRecord:               ethernetCountersSize,
+	TypeTokenRingCountersRecord:              tokenRingCountersSize,
+	TypeVgCountersRecord:                     vgCountersSize,
+	TypeVlanCountersRecord:                   vlanCountersSize,
+	TypeIEEE80211CountersRecord:              ieee80211CountersSize,
+	TypeSlowPathCountersRecord:               slowPathCountersSize,
+	TypeInfiniBandCountersRecord:             infiniBandCountersSize,
+	TypeProcessorCountersRecord:              processorCountersSize,
+	TypeRadioUtilizationCountersRecord:       radioUtilizationCountersSize,
+	TypeQueueLengthCountersRecord:            queueLengthCountersSize,
+	TypeOpenFlowPortCountersRecord:           openFlowPortCountersSize,
+	TypeHostCPUCountersRecord:                hostCPUCountersSize,
+	TypeHostMemoryCountersRecord:             hostMemoryCountersSize,
+	TypeHostDiskCountersRecord:               hostDiskCountersSize,
+	TypeHostNetCountersRecord:                hostNetCountersSize,
+	TypeMIB2IPGroupCountersRecord:            mib2IPGroupCountersSize,
+	TypeMIB2ICMPGroupCountersRecord:          mib2ICMPGroupCountersSize,
+	TypeMIB2TCPGroupCountersRecord:           mib2TCPGroupCountersSize,
+	TypeMIB2UDPGroupCountersRecord:           mib2UDPGroupCountersSize,
+	TypeJMXStatisticsCountersRecord:          jmxStatisticsCountersSize,
+	TypeOVSDPStatsCountersRecord:             ovsDPStatsCountersSize,
+	TypeEnergyCountersRecord:                 energyCountersSize,
+	TypeTemperatureCountersRecord:            temperatureCountersSize,
+	TypeHumidityCountersRecord:               humidityCountersSize,
+	TypeFansCountersRecord:                   fansCountersSize,
+	TypeBroadcomDeviceBuffersCountersRecord:  broadcomDeviceBuffersCountersSize,
+	TypeBroadcomHardwareTablesCountersRecord: broadcomHardwareTablesCountersSize,
+	TypeNvidiaGPUCountersRecord:              nvidiaGPUCountersSize,
+}
+
 // RecordType returns the type of counter record.
 func (c GenericInterfaceCounters) RecordType() int {
 	return TypeGenericInterfaceCountersRecord
@@ -306,7 +946,9 @@ func (c GenericInterfaceCounters) RecordType() int {
 
 func decodeGenericInterfaceCountersRecord(r io.Reader, length uint32) (GenericInterfaceCounters, error) {
 	c := GenericInterfaceCounters{}
-	b := make([]byte, int(length))
+	buf := getBuffer(int(length))
+	defer putBuffer(buf)
+	b := *buf
 	n, _ := r.Read(b)
 	if n != int(length) {
 		return c, records.ErrDecodingRecord
@@ -361,7 +1003,9 @@ func (c EthernetCounters) RecordType() int {
 
 func decodeEthernetCountersRecord(r io.Reader, length uint32) (EthernetCounters, error) {
 	c := EthernetCounters{}
-	b := make([]byte, int(length))
+	buf := getBuffer(int(length))
+	defer putBuffer(buf)
+	b := *buf
 	n, _ := r.Read(b)
 	if n != int(length) {
 		return c, records.ErrDecodingRecord
@@ -410,7 +1054,9 @@ func (c TokenRingCounters) RecordType() int {
 
 func decodeTokenRingCountersRecord(r io.Reader, length uint32) (TokenRingCounters, error) {
 	c := TokenRingCounters{}
-	b := make([]byte, int(length))
+	buf := getBuffer(int(length))
+	defer putBuffer(buf)
+	b := *buf
 	n, _ := r.Read(b)
 	if n != int(length) {
 		return c, records.ErrDecodingRecord
@@ -464,7 +1110,9 @@ func (c VgCounters) RecordType() int {
 
 func decodeVgCountersRecord(r io.Reader, length uint32) (VgCounters, error) {
 	c := VgCounters{}
-	b := make([]byte, int(length))
+	buf := getBuffer(int(length))
+	defer putBuffer(buf)
+	b := *buf
 	n, _ := r.Read(b)
 	if n != int(length) {
 		return c, records.ErrDecodingRecord
@@ -514,7 +1162,9 @@ func (c VlanCounters) RecordType() int {
 
 func decodeVlanCountersRecord(r io.Reader, length uint32) (VlanCounters, error) {
 	c := VlanCounters{}
-	b := make([]byte, int(length))
+	buf := getBuffer(int(length))
+	defer putBuffer(buf)
+	b := *buf
 	n, _ := r.Read(b)
 	if n != int(length) {
 		return c, records.ErrDecodingRecord
@@ -550,30 +1200,47 @@ func (c VlanCounters) Encode(w io.Writer) error {
 }
 
 // RecordType returns the type of counter record.
-func (c ProcessorCounters) RecordType() int {
-	return TypeProcessorCountersRecord
+func (c IEEE80211Counters) RecordType() int {
+	return TypeIEEE80211CountersRecord
 }
 
-func decodeProcessorCountersRecord(r io.Reader, length uint32) (ProcessorCounters, error) {
-	c := ProcessorCounters{}
-	b := make([]byte, int(length))
+func decodeIEEE80211CountersRecord(r io.Reader, length uint32) (IEEE80211Counters, error) {
+	c := IEEE80211Counters{}
+	buf := getBuffer(int(length))
+	defer putBuffer(buf)
+	b := *buf
 	n, _ := r.Read(b)
 	if n != int(length) {
 		return c, records.ErrDecodingRecord
 	}
 
 	fields := []interface{}{
-		&c.CPU5s,
-		&c.CPU1m,
-		&c.CPU5m,
-		&c.TotalMemory,
-		&c.FreeMemory,
+		&c.TransmittedFragmentCount,
+		&c.MulticastTransmittedFrameCount,
+		&c.FailedCount,
+		&c.RetryCount,
+		&c.MultipleRetryCount,
+		&c.FrameDuplicateCount,
+		&c.RTSSuccessCount,
+		&c.RTSFailureCount,
+		&c.ACKFailureCount,
+		&c.ReceivedFragmentCount,
+		&c.MulticastReceivedFrameCount,
+		&c.FCSErrorCount,
+		&c.TransmittedFrameCount,
+		&c.WEPUndecryptableCount,
+		&c.QoSDiscardedFragmentCount,
+		&c.AssociatedStationCount,
+		&c.QoSCFPollsReceivedCount,
+		&c.QoSCFPollsUnusedCount,
+		&c.QoSCFPollsUnusableCount,
+		&c.QoSCFPollsLostCount,
 	}
 
 	return c, readFields(b, fields)
 }
 
-func (c ProcessorCounters) Encode(w io.Writer) error {
+func (c IEEE80211Counters) Encode(w io.Writer) error {
 	var err error
 
 	err = binary.Write(w, binary.BigEndian, uint32(c.RecordType()))
@@ -581,7 +1248,7 @@ func (c ProcessorCounters) Encode(w io.Writer) error {
 		return err
 	}
 
-	err = binary.Write(w, binary.BigEndian, processorCountersSize)
+	err = binary.Write(w, binary.BigEndian, ieee80211CountersSize)
 	if err != nil {
 		return err
 	}
@@ -591,92 +1258,103 @@ func (c ProcessorCounters) Encode(w io.Writer) error {
 }
 
 // RecordType returns the type of counter record.
-func (c HostCPUCounters) RecordType() int {
-	return TypeHostCPUCountersRecord
+func (c LAGPortCounters) RecordType() int {
+	return TypeLAGPortCountersRecord
 }
 
-func decodeHostCPUCountersRecord(r io.Reader, length uint32) (HostCPUCounters, error) {
-	c := HostCPUCounters{}
-	b := make([]byte, int(length))
+func decodeLAGPortCountersRecord(r io.Reader, length uint32) (LAGPortCounters, error) {
+	c := LAGPortCounters{}
+	buf := getBuffer(int(length))
+	defer putBuffer(buf)
+	b := *buf
 	n, _ := r.Read(b)
 	if n != int(length) {
 		return c, records.ErrDecodingRecord
 	}
 
 	fields := []interface{}{
-		&c.Load1m,
-		&c.Load5m,
-		&c.Load15m,
-		&c.ProcessesRunning,
-		&c.ProcessesTotal,
-		&c.NumCPU,
-		&c.SpeedCPU,
-		&c.Uptime,
-		&c.CPUUser,
-		&c.CPUNice,
-		&c.CPUSys,
-		&c.CPUIdle,
-		&c.CPUWio,
-		&c.CPUIntr,
-		&c.CPUSoftIntr,
-		&c.Interrupts,
-		&c.ContextSwitches,
-		&c.CPUSteal,
-		&c.CPUGuest,
-		&c.CPUGuestNice,
+		&c.ActorSystemID,
+		&c.PartnerOperSystemID,
+		&c.AttachedAggID,
+		&c.ActorAdminState,
+		&c.ActorOperState,
+		&c.PartnerAdminState,
+		&c.PartnerOperState,
+		&c.LACPDUsRx,
+		&c.MarkerPDUsRx,
+		&c.MarkerResponsePDUsRx,
+		&c.UnknownRx,
+		&c.IllegalRx,
+		&c.LACPDUsTx,
+		&c.MarkerPDUsTx,
+		&c.MarkerResponsePDUsTx,
 	}
 
 	return c, readFields(b, fields)
 }
 
-func (c HostCPUCounters) Encode(w io.Writer) error {
+func (c LAGPortCounters) Encode(w io.Writer) error {
 	var err error
 
+	fields := []interface{}{
+		c.ActorSystemID,
+		c.PartnerOperSystemID,
+		c.AttachedAggID,
+		c.ActorAdminState,
+		c.ActorOperState,
+		c.PartnerAdminState,
+		c.PartnerOperState,
+		c.LACPDUsRx,
+		c.MarkerPDUsRx,
+		c.MarkerResponsePDUsRx,
+		c.UnknownRx,
+		c.IllegalRx,
+		c.LACPDUsTx,
+		c.MarkerPDUsTx,
+		c.MarkerResponsePDUsTx,
+	}
+
 	err = binary.Write(w, binary.BigEndian, uint32(c.RecordType()))
 	if err != nil {
 		return err
 	}
 
-	err = binary.Write(w, binary.BigEndian, hostCPUCountersSize)
+	err = binary.Write(w, binary.BigEndian, encodedFieldsSize(fields))
 	if err != nil {
 		return err
 	}
 
-	err = binary.Write(w, binary.BigEndian, c)
-	return err
+	return writeFields(w, fields)
 }
 
 // RecordType returns the type of counter record.
-func (c HostMemoryCounters) RecordType() int {
-	return TypeHostMemoryCountersRecord
+func (c SlowPathCounters) RecordType() int {
+	return TypeSlowPathCountersRecord
 }
 
-func decodeHostMemoryCountersRecord(r io.Reader, length uint32) (HostMemoryCounters, error) {
-	c := HostMemoryCounters{}
-	b := make([]byte, int(length))
+func decodeSlowPathCountersRecord(r io.Reader, length uint32) (SlowPathCounters, error) {
+	c := SlowPathCounters{}
+	buf := getBuffer(int(length))
+	defer putBuffer(buf)
+	b := *buf
 	n, _ := r.Read(b)
 	if n != int(length) {
 		return c, records.ErrDecodingRecord
 	}
 
 	fields := []interface{}{
-		&c.Total,
-		&c.Free,
-		&c.Shared,
-		&c.Buffers,
-		&c.Cached,
-		&c.SwapTotal,
-		&c.SwapFree,
-		&c.PageIn,
-		&c.PageOut,
-		&c.SwapIn,
-		&c.SwapOut,
+		&c.Unknown,
+		&c.Other,
+		&c.CAMMiss,
+		&c.CAMFull,
+		&c.NoHWSupport,
+		&c.Control,
 	}
 
 	return c, readFields(b, fields)
 }
 
-func (c HostMemoryCounters) Encode(w io.Writer) error {
+func (c SlowPathCounters) Encode(w io.Writer) error {
 	var err error
 
 	err = binary.Write(w, binary.BigEndian, uint32(c.RecordType()))
@@ -684,7 +1362,7 @@ func (c HostMemoryCounters) Encode(w io.Writer) error {
 		return err
 	}
 
-	err = binary.Write(w, binary.BigEndian, hostMemoryCountersSize)
+	err = binary.Write(w, binary.BigEndian, slowPathCountersSize)
 	if err != nil {
 		return err
 	}
@@ -694,24 +1372,402 @@ func (c HostMemoryCounters) Encode(w io.Writer) error {
 }
 
 // RecordType returns the type of counter record.
-func (c HostDiskCounters) RecordType() int {
-	return TypeHostDiskCountersRecord
+func (c InfiniBandCounters) RecordType() int {
+	return TypeInfiniBandCountersRecord
 }
 
-func decodeHostDiskCountersRecord(r io.Reader, length uint32) (HostDiskCounters, error) {
-	c := HostDiskCounters{}
-	b := make([]byte, int(length))
+func decodeInfiniBandCountersRecord(r io.Reader, length uint32) (InfiniBandCounters, error) {
+	c := InfiniBandCounters{}
+	buf := getBuffer(int(length))
+	defer putBuffer(buf)
+	b := *buf
 	n, _ := r.Read(b)
 	if n != int(length) {
 		return c, records.ErrDecodingRecord
 	}
 
 	fields := []interface{}{
-		&c.Total,
-		&c.Free,
-		&c.MaxUsedPercent,
-		&c.Reads,
-		&c.BytesRead,
+		&c.PortXmitPkts,
+		&c.PortRcvPkts,
+		&c.SymbolErrorCounter,
+		&c.LinkErrorRecoveryCounter,
+		&c.LinkDownedCounter,
+		&c.PortRcvErrors,
+		&c.PortRcvRemotePhysicalErrors,
+		&c.PortRcvSwitchRelayErrors,
+		&c.PortXmitDiscards,
+		&c.PortXmitConstraintErrors,
+		&c.PortRcvConstraintErrors,
+		&c.LocalLinkIntegrityErrors,
+		&c.ExcessiveBufferOverrunErrors,
+		&c.VL15Dropped,
+	}
+
+	return c, readFields(b, fields)
+}
+
+func (c InfiniBandCounters) Encode(w io.Writer) error {
+	var err error
+
+	err = binary.Write(w, binary.BigEndian, uint32(c.RecordType()))
+	if err != nil {
+		return err
+	}
+
+	err = binary.Write(w, binary.BigEndian, infiniBandCountersSize)
+	if err != nil {
+		return err
+	}
+
+	err = binary.Write(w, binary.BigEndian, c)
+	return err
+}
+
+// RecordType returns the type of counter record.
+func (c ProcessorCounters) RecordType() int {
+	return TypeProcessorCountersRecord
+}
+
+func decodeProcessorCountersRecord(r io.Reader, length uint32) (ProcessorCounters, error) {
+	c := ProcessorCounters{}
+	buf := getBuffer(int(length))
+	defer putBuffer(buf)
+	b := *buf
+	n, _ := r.Read(b)
+	if n != int(length) {
+		return c, records.ErrDecodingRecord
+	}
+
+	fields := []interface{}{
+		&c.CPU5s,
+		&c.CPU1m,
+		&c.CPU5m,
+		&c.TotalMemory,
+		&c.FreeMemory,
+	}
+
+	return c, readFields(b, fields)
+}
+
+func (c ProcessorCounters) Encode(w io.Writer) error {
+	var err error
+
+	err = binary.Write(w, binary.BigEndian, uint32(c.RecordType()))
+	if err != nil {
+		return err
+	}
+
+	err = binary.Write(w, binary.BigEndian, processorCountersSize)
+	if err != nil {
+		return err
+	}
+
+	err = binary.Write(w, binary.BigEndian, c)
+	return err
+}
+
+// RecordType returns the type of counter record.
+func (c RadioUtilizationCounters) RecordType() int {
+	return TypeRadioUtilizationCountersRecord
+}
+
+func decodeRadioUtilizationCountersRecord(r io.Reader, length uint32) (RadioUtilizationCounters, error) {
+	c := RadioUtilizationCounters{}
+	buf := getBuffer(int(length))
+	defer putBuffer(buf)
+	b := *buf
+	n, _ := r.Read(b)
+	if n != int(length) {
+		return c, records.ErrDecodingRecord
+	}
+
+	fields := []interface{}{
+		&c.ElapsedTime,
+		&c.OnChannelTime,
+		&c.OnChannelBusyTime,
+	}
+
+	return c, readFields(b, fields)
+}
+
+func (c RadioUtilizationCounters) Encode(w io.Writer) error {
+	var err error
+
+	err = binary.Write(w, binary.BigEndian, uint32(c.RecordType()))
+	if err != nil {
+		return err
+	}
+
+	err = binary.Write(w, binary.BigEndian, radioUtilizationCountersSize)
+	if err != nil {
+		return err
+	}
+
+	err = binary.Write(w, binary.BigEndian, c)
+	return err
+}
+
+// RecordType returns the type of counter record.
+func (c QueueLengthCounters) RecordType() int {
+	return TypeQueueLengthCountersRecord
+}
+
+func decodeQueueLengthCountersRecord(r io.Reader, length uint32) (QueueLengthCounters, error) {
+	c := QueueLengthCounters{}
+	buf := getBuffer(int(length))
+	defer putBuffer(buf)
+	b := *buf
+	n, _ := r.Read(b)
+	if n != int(length) {
+		return c, records.ErrDecodingRecord
+	}
+
+	fields := []interface{}{
+		&c.QueueIndex,
+		&c.SegmentSize,
+		&c.QueueSegments,
+		&c.QueueLength0,
+		&c.QueueLength1,
+		&c.QueueLength2,
+		&c.QueueLength4,
+		&c.QueueLength8,
+		&c.QueueLength32,
+		&c.QueueLength128,
+		&c.QueueLength1024,
+		&c.QueueLengthMore,
+		&c.Dropped,
+	}
+
+	return c, readFields(b, fields)
+}
+
+func (c QueueLengthCounters) Encode(w io.Writer) error {
+	var err error
+
+	err = binary.Write(w, binary.BigEndian, uint32(c.RecordType()))
+	if err != nil {
+		return err
+	}
+
+	err = binary.Write(w, binary.BigEndian, queueLengthCountersSize)
+	if err != nil {
+		return err
+	}
+
+	err = binary.Write(w, binary.BigEndian, c)
+	return err
+}
+
+// RecordType returns the type of counter record.
+func (c OpenFlowPortCounters) RecordType() int {
+	return TypeOpenFlowPortCountersRecord
+}
+
+func decodeOpenFlowPortCountersRecord(r io.Reader, length uint32) (OpenFlowPortCounters, error) {
+	c := OpenFlowPortCounters{}
+	buf := getBuffer(int(length))
+	defer putBuffer(buf)
+	b := *buf
+	n, _ := r.Read(b)
+	if n != int(length) {
+		return c, records.ErrDecodingRecord
+	}
+
+	fields := []interface{}{
+		&c.DatapathID,
+		&c.PortNumber,
+	}
+
+	return c, readFields(b, fields)
+}
+
+func (c OpenFlowPortCounters) Encode(w io.Writer) error {
+	var err error
+
+	err = binary.Write(w, binary.BigEndian, uint32(c.RecordType()))
+	if err != nil {
+		return err
+	}
+
+	err = binary.Write(w, binary.BigEndian, openFlowPortCountersSize)
+	if err != nil {
+		return err
+	}
+
+	err = binary.Write(w, binary.BigEndian, c)
+	return err
+}
+
+// RecordType returns the type of counter record.
+func (c PortNameCounters) RecordType() int {
+	return TypePortNameCountersRecord
+}
+
+func decodePortNameCountersRecord(r io.Reader, length uint32) (PortNameCounters, error) {
+	c := PortNameCounters{}
+	buf := getBuffer(int(length))
+	defer putBuffer(buf)
+	b := *buf
+	n, _ := r.Read(b)
+	if n != int(length) {
+		return c, records.ErrDecodingRecord
+	}
+
+	fields := []interface{}{
+		&c.Name,
+	}
+
+	return c, readFields(b, fields)
+}
+
+func (c PortNameCounters) Encode(w io.Writer) error {
+	var err error
+
+	fields := []interface{}{
+		c.Name,
+	}
+
+	err = binary.Write(w, binary.BigEndian, uint32(c.RecordType()))
+	if err != nil {
+		return err
+	}
+
+	err = binary.Write(w, binary.BigEndian, encodedFieldsSize(fields))
+	if err != nil {
+		return err
+	}
+
+	return writeFields(w, fields)
+}
+
+// RecordType returns the type of counter record.
+func (c HostCPUCounters) RecordType() int {
+	return TypeHostCPUCountersRecord
+}
+
+func decodeHostCPUCountersRecord(r io.Reader, length uint32) (HostCPUCounters, error) {
+	c := HostCPUCounters{}
+	buf := getBuffer(int(length))
+	defer putBuffer(buf)
+	b := *buf
+	n, _ := r.Read(b)
+	if n != int(length) {
+		return c, records.ErrDecodingRecord
+	}
+
+	fields := []interface{}{
+		&c.Load1m,
+		&c.Load5m,
+		&c.Load15m,
+		&c.ProcessesRunning,
+		&c.ProcessesTotal,
+		&c.NumCPU,
+		&c.SpeedCPU,
+		&c.Uptime,
+		&c.CPUUser,
+		&c.CPUNice,
+		&c.CPUSys,
+		&c.CPUIdle,
+		&c.CPUWio,
+		&c.CPUIntr,
+		&c.CPUSoftIntr,
+		&c.Interrupts,
+		&c.ContextSwitches,
+		&c.CPUSteal,
+		&c.CPUGuest,
+		&c.CPUGuestNice,
+	}
+
+	return c, readFields(b, fields)
+}
+
+func (c HostCPUCounters) Encode(w io.Writer) error {
+	var err error
+
+	err = binary.Write(w, binary.BigEndian, uint32(c.RecordType()))
+	if err != nil {
+		return err
+	}
+
+	err = binary.Write(w, binary.BigEndian, hostCPUCountersSize)
+	if err != nil {
+		return err
+	}
+
+	err = binary.Write(w, binary.BigEndian, c)
+	return err
+}
+
+// RecordType returns the type of counter record.
+func (c HostMemoryCounters) RecordType() int {
+	return TypeHostMemoryCountersRecord
+}
+
+func decodeHostMemoryCountersRecord(r io.Reader, length uint32) (HostMemoryCounters, error) {
+	c := HostMemoryCounters{}
+	buf := getBuffer(int(length))
+	defer putBuffer(buf)
+	b := *buf
+	n, _ := r.Read(b)
+	if n != int(length) {
+		return c, records.ErrDecodingRecord
+	}
+
+	fields := []interface{}{
+		&c.Total,
+		&c.Free,
+		&c.Shared,
+		&c.Buffers,
+		&c.Cached,
+		&c.SwapTotal,
+		&c.SwapFree,
+		&c.PageIn,
+		&c.PageOut,
+		&c.SwapIn,
+		&c.SwapOut,
+	}
+
+	return c, readFields(b, fields)
+}
+
+func (c HostMemoryCounters) Encode(w io.Writer) error {
+	var err error
+
+	err = binary.Write(w, binary.BigEndian, uint32(c.RecordType()))
+	if err != nil {
+		return err
+	}
+
+	err = binary.Write(w, binary.BigEndian, hostMemoryCountersSize)
+	if err != nil {
+		return err
+	}
+
+	err = binary.Write(w, binary.BigEndian, c)
+	return err
+}
+
+// RecordType returns the type of counter record.
+func (c HostDiskCounters) RecordType() int {
+	return TypeHostDiskCountersRecord
+}
+
+func decodeHostDiskCountersRecord(r io.Reader, length uint32) (HostDiskCounters, error) {
+	c := HostDiskCounters{}
+	buf := getBuffer(int(length))
+	defer putBuffer(buf)
+	b := *buf
+	n, _ := r.Read(b)
+	if n != int(length) {
+		return c, records.ErrDecodingRecord
+	}
+
+	fields := []interface{}{
+		&c.Total,
+		&c.Free,
+		&c.MaxUsedPercent,
+		&c.Reads,
+		&c.BytesRead,
 		&c.ReadTime,
 		&c.Writes,
 		&c.BytesWritten,
@@ -721,7 +1777,374 @@ func decodeHostDiskCountersRecord(r io.Reader, length uint32) (HostDiskCounters,
 	return c, readFields(b, fields)
 }
 
-func (c HostDiskCounters) Encode(w io.Writer) error {
+func (c HostDiskCounters) Encode(w io.Writer) error {
+	var err error
+
+	err = binary.Write(w, binary.BigEndian, uint32(c.RecordType()))
+	if err != nil {
+		return err
+	}
+
+	err = binary.Write(w, binary.BigEndian, hostDiskCountersSize)
+	if err != nil {
+		return err
+	}
+
+	err = binary.Write(w, binary.BigEndian, c)
+	return err
+}
+
+// RecordType returns the type of counter record.
+func (c HostNetCounters) RecordType() int {
+	return TypeHostNetCountersRecord
+}
+
+func decodeHostNetCountersRecord(r io.Reader, length uint32) (HostNetCounters, error) {
+	c := HostNetCounters{}
+	buf := getBuffer(int(length))
+	defer putBuffer(buf)
+	b := *buf
+	n, _ := r.Read(b)
+	if n != int(length) {
+		return c, records.ErrDecodingRecord
+	}
+
+	fields := []interface{}{
+		&c.BytesIn,
+		&c.PacketsIn,
+		&c.ErrorsIn,
+		&c.DropsIn,
+		&c.BytesOut,
+		&c.PacketsOut,
+		&c.ErrorsOut,
+		&c.DropsOut,
+	}
+
+	return c, readFields(b, fields)
+}
+
+func (c HostNetCounters) Encode(w io.Writer) error {
+	var err error
+
+	err = binary.Write(w, binary.BigEndian, uint32(c.RecordType()))
+	if err != nil {
+		return err
+	}
+
+	err = binary.Write(w, binary.BigEndian, hostNetCountersSize)
+	if err != nil {
+		return err
+	}
+
+	err = binary.Write(w, binary.BigEndian, c)
+	return err
+}
+
+// RecordType returns the type of counter record.
+func (c MIB2IPGroupCounters) RecordType() int {
+	return TypeMIB2IPGroupCountersRecord
+}
+
+func decodeMIB2IPGroupCountersRecord(r io.Reader, length uint32) (MIB2IPGroupCounters, error) {
+	c := MIB2IPGroupCounters{}
+	buf := getBuffer(int(length))
+	defer putBuffer(buf)
+	b := *buf
+	n, _ := r.Read(b)
+	if n != int(length) {
+		return c, records.ErrDecodingRecord
+	}
+
+	fields := []interface{}{
+		&c.Forwarding,
+		&c.DefaultTTL,
+		&c.InReceives,
+		&c.InHdrErrors,
+		&c.InAddrErrors,
+		&c.ForwDatagrams,
+		&c.InUnknownProtos,
+		&c.InDiscards,
+		&c.InDelivers,
+		&c.OutRequests,
+		&c.OutDiscards,
+		&c.OutNoRoutes,
+		&c.ReasmTimeout,
+		&c.ReasmReqds,
+		&c.ReasmOKs,
+		&c.ReasmFails,
+		&c.FragOKs,
+		&c.FragFails,
+		&c.FragCreates,
+	}
+
+	return c, readFields(b, fields)
+}
+
+func (c MIB2IPGroupCounters) Encode(w io.Writer) error {
+	var err error
+
+	err = binary.Write(w, binary.BigEndian, uint32(c.RecordType()))
+	if err != nil {
+		return err
+	}
+
+	err = binary.Write(w, binary.BigEndian, mib2IPGroupCountersSize)
+	if err != nil {
+		return err
+	}
+
+	err = binary.Write(w, binary.BigEndian, c)
+	return err
+}
+
+// RecordType returns the type of counter record.
+func (c MIB2ICMPGroupCounters) RecordType() int {
+	return TypeMIB2ICMPGroupCountersRecord
+}
+
+func decodeMIB2ICMPGroupCountersRecord(r io.Reader, length uint32) (MIB2ICMPGroupCounters, error) {
+	c := MIB2ICMPGroupCounters{}
+	buf := getBuffer(int(length))
+	defer putBuffer(buf)
+	b := *buf
+	n, _ := r.Read(b)
+	if n != int(length) {
+		return c, records.ErrDecodingRecord
+	}
+
+	fields := []interface{}{
+		&c.InMsgs,
+		&c.InErrors,
+		&c.InDestUnreachs,
+		&c.InTimeExcds,
+		&c.InParamProbs,
+		&c.InSrcQuenchs,
+		&c.InRedirects,
+		&c.InEchos,
+		&c.InEchoReps,
+		&c.InTimestamps,
+		&c.InAddrMasks,
+		&c.InAddrMaskReps,
+		&c.OutMsgs,
+		&c.OutErrors,
+		&c.OutDestUnreachs,
+		&c.OutTimeExcds,
+		&c.OutParamProbs,
+		&c.OutSrcQuenchs,
+		&c.OutRedirects,
+		&c.OutEchos,
+		&c.OutEchoReps,
+		&c.OutTimestamps,
+		&c.OutTimestampReps,
+		&c.OutAddrMasks,
+		&c.OutAddrMaskReps,
+	}
+
+	return c, readFields(b, fields)
+}
+
+func (c MIB2ICMPGroupCounters) Encode(w io.Writer) error {
+	var err error
+
+	err = binary.Write(w, binary.BigEndian, uint32(c.RecordType()))
+	if err != nil {
+		return err
+	}
+
+	err = binary.Write(w, binary.BigEndian, mib2ICMPGroupCountersSize)
+	if err != nil {
+		return err
+	}
+
+	err = binary.Write(w, binary.BigEndian, c)
+	return err
+}
+
+// RecordType returns the type of counter record.
+func (c MIB2TCPGroupCounters) RecordType() int {
+	return TypeMIB2TCPGroupCountersRecord
+}
+
+func decodeMIB2TCPGroupCountersRecord(r io.Reader, length uint32) (MIB2TCPGroupCounters, error) {
+	c := MIB2TCPGroupCounters{}
+	buf := getBuffer(int(length))
+	defer putBuffer(buf)
+	b := *buf
+	n, _ := r.Read(b)
+	if n != int(length) {
+		return c, records.ErrDecodingRecord
+	}
+
+	fields := []interface{}{
+		&c.RtoAlgorithm,
+		&c.RtoMin,
+		&c.RtoMax,
+		&c.MaxConn,
+		&c.ActiveOpens,
+		&c.PassiveOpens,
+		&c.AttemptFails,
+		&c.EstabResets,
+		&c.CurrEstab,
+		&c.InSegs,
+		&c.OutSegs,
+		&c.RetransSegs,
+		&c.InErrs,
+		&c.OutRsts,
+		&c.InCsumErrors,
+	}
+
+	return c, readFields(b, fields)
+}
+
+func (c MIB2TCPGroupCounters) Encode(w io.Writer) error {
+	var err error
+
+	err = binary.Write(w, binary.BigEndian, uint32(c.RecordType()))
+	if err != nil {
+		return err
+	}
+
+	err = binary.Write(w, binary.BigEndian, mib2TCPGroupCountersSize)
+	if err != nil {
+		return err
+	}
+
+	err = binary.Write(w, binary.BigEndian, c)
+	return err
+}
+
+// RecordType returns the type of counter record.
+func (c MIB2UDPGroupCounters) RecordType() int {
+	return TypeMIB2UDPGroupCountersRecord
+}
+
+func decodeMIB2UDPGroupCountersRecord(r io.Reader, length uint32) (MIB2UDPGroupCounters, error) {
+	c := MIB2UDPGroupCounters{}
+	buf := getBuffer(int(length))
+	defer putBuffer(buf)
+	b := *buf
+	n, _ := r.Read(b)
+	if n != int(length) {
+		return c, records.ErrDecodingRecord
+	}
+
+	fields := []interface{}{
+		&c.InDatagrams,
+		&c.NoPorts,
+		&c.InErrors,
+		&c.OutDatagrams,
+		&c.RcvbufErrors,
+		&c.SndbufErrors,
+		&c.InCsumErrors,
+	}
+
+	return c, readFields(b, fields)
+}
+
+func (c MIB2UDPGroupCounters) Encode(w io.Writer) error {
+	var err error
+
+	err = binary.Write(w, binary.BigEndian, uint32(c.RecordType()))
+	if err != nil {
+		return err
+	}
+
+	err = binary.Write(w, binary.BigEndian, mib2UDPGroupCountersSize)
+	if err != nil {
+		return err
+	}
+
+	err = binary.Write(w, binary.BigEndian, c)
+	return err
+}
+
+// RecordType returns the type of counter record.
+func (c JMXRuntimeCounters) RecordType() int {
+	return TypeJMXRuntimeCountersRecord
+}
+
+func decodeJMXRuntimeCountersRecord(r io.Reader, length uint32) (JMXRuntimeCounters, error) {
+	c := JMXRuntimeCounters{}
+	buf := getBuffer(int(length))
+	defer putBuffer(buf)
+	b := *buf
+	n, _ := r.Read(b)
+	if n != int(length) {
+		return c, records.ErrDecodingRecord
+	}
+
+	fields := []interface{}{
+		&c.VMName,
+		&c.VMVendor,
+		&c.VMVersion,
+	}
+
+	return c, readFields(b, fields)
+}
+
+func (c JMXRuntimeCounters) Encode(w io.Writer) error {
+	var err error
+
+	fields := []interface{}{
+		c.VMName,
+		c.VMVendor,
+		c.VMVersion,
+	}
+
+	err = binary.Write(w, binary.BigEndian, uint32(c.RecordType()))
+	if err != nil {
+		return err
+	}
+
+	err = binary.Write(w, binary.BigEndian, encodedFieldsSize(fields))
+	if err != nil {
+		return err
+	}
+
+	return writeFields(w, fields)
+}
+
+// RecordType returns the type of counter record.
+func (c JMXStatisticsCounters) RecordType() int {
+	return TypeJMXStatisticsCountersRecord
+}
+
+func decodeJMXStatisticsCountersRecord(r io.Reader, length uint32) (JMXStatisticsCounters, error) {
+	c := JMXStatisticsCounters{}
+	buf := getBuffer(int(length))
+	defer putBuffer(buf)
+	b := *buf
+	n, _ := r.Read(b)
+	if n != int(length) {
+		return c, records.ErrDecodingRecord
+	}
+
+	fields := []interface{}{
+		&c.HeapInitial,
+		&c.HeapUsed,
+		&c.HeapCommitted,
+		&c.HeapMax,
+		&c.NonHeapInitial,
+		&c.NonHeapUsed,
+		&c.NonHeapCommitted,
+		&c.NonHeapMax,
+		&c.GCCount,
+		&c.GCTime,
+		&c.ClassesLoaded,
+		&c.ClassesTotal,
+		&c.ClassesUnloaded,
+		&c.CompilationTime,
+		&c.ThreadsLive,
+		&c.ThreadsDaemon,
+		&c.ThreadsStarted,
+		&c.OpenFileDescriptors,
+		&c.MaxFileDescriptors,
+	}
+
+	return c, readFields(b, fields)
+}
+
+func (c JMXStatisticsCounters) Encode(w io.Writer) error {
 	var err error
 
 	err = binary.Write(w, binary.BigEndian, uint32(c.RecordType()))
@@ -729,7 +2152,7 @@ func (c HostDiskCounters) Encode(w io.Writer) error {
 		return err
 	}
 
-	err = binary.Write(w, binary.BigEndian, hostDiskCountersSize)
+	err = binary.Write(w, binary.BigEndian, jmxStatisticsCountersSize)
 	if err != nil {
 		return err
 	}
@@ -739,33 +2162,33 @@ func (c HostDiskCounters) Encode(w io.Writer) error {
 }
 
 // RecordType returns the type of counter record.
-func (c HostNetCounters) RecordType() int {
-	return TypeHostNetCountersRecord
+func (c OVSDPStatsCounters) RecordType() int {
+	return TypeOVSDPStatsCountersRecord
 }
 
-func decodeHostNetCountersRecord(r io.Reader, length uint32) (HostNetCounters, error) {
-	c := HostNetCounters{}
-	b := make([]byte, int(length))
+func decodeOVSDPStatsCountersRecord(r io.Reader, length uint32) (OVSDPStatsCounters, error) {
+	c := OVSDPStatsCounters{}
+	buf := getBuffer(int(length))
+	defer putBuffer(buf)
+	b := *buf
 	n, _ := r.Read(b)
 	if n != int(length) {
 		return c, records.ErrDecodingRecord
 	}
 
 	fields := []interface{}{
-		&c.BytesIn,
-		&c.PacketsIn,
-		&c.ErrorsIn,
-		&c.DropsIn,
-		&c.BytesOut,
-		&c.PacketsOut,
-		&c.ErrorsOut,
-		&c.DropsOut,
+		&c.Hits,
+		&c.Misses,
+		&c.Lost,
+		&c.MaskHits,
+		&c.Flows,
+		&c.Masks,
 	}
 
 	return c, readFields(b, fields)
 }
 
-func (c HostNetCounters) Encode(w io.Writer) error {
+func (c OVSDPStatsCounters) Encode(w io.Writer) error {
 	var err error
 
 	err = binary.Write(w, binary.BigEndian, uint32(c.RecordType()))
@@ -773,7 +2196,386 @@ func (c HostNetCounters) Encode(w io.Writer) error {
 		return err
 	}
 
-	err = binary.Write(w, binary.BigEndian, hostNetCountersSize)
+	err = binary.Write(w, binary.BigEndian, ovsDPStatsCountersSize)
+	if err != nil {
+		return err
+	}
+
+	err = binary.Write(w, binary.BigEndian, c)
+	return err
+}
+
+// RecordType returns the type of counter record.
+func (c EnergyCounters) RecordType() int {
+	return TypeEnergyCountersRecord
+}
+
+func decodeEnergyCountersRecord(r io.Reader, length uint32) (EnergyCounters, error) {
+	c := EnergyCounters{}
+	buf := getBuffer(int(length))
+	defer putBuffer(buf)
+	b := *buf
+	n, _ := r.Read(b)
+	if n != int(length) {
+		return c, records.ErrDecodingRecord
+	}
+
+	fields := []interface{}{
+		&c.Voltage,
+		&c.Current,
+		&c.RealPower,
+		&c.PowerFactor,
+		&c.Energy,
+		&c.Errors,
+	}
+
+	return c, readFields(b, fields)
+}
+
+func (c EnergyCounters) Encode(w io.Writer) error {
+	var err error
+
+	err = binary.Write(w, binary.BigEndian, uint32(c.RecordType()))
+	if err != nil {
+		return err
+	}
+
+	err = binary.Write(w, binary.BigEndian, energyCountersSize)
+	if err != nil {
+		return err
+	}
+
+	err = binary.Write(w, binary.BigEndian, c)
+	return err
+}
+
+// RecordType returns the type of counter record.
+func (c TemperatureCounters) RecordType() int {
+	return TypeTemperatureCountersRecord
+}
+
+func decodeTemperatureCountersRecord(r io.Reader, length uint32) (TemperatureCounters, error) {
+	c := TemperatureCounters{}
+	buf := getBuffer(int(length))
+	defer putBuffer(buf)
+	b := *buf
+	n, _ := r.Read(b)
+	if n != int(length) {
+		return c, records.ErrDecodingRecord
+	}
+
+	fields := []interface{}{
+		&c.Minimum,
+		&c.Maximum,
+		&c.Errors,
+	}
+
+	return c, readFields(b, fields)
+}
+
+func (c TemperatureCounters) Encode(w io.Writer) error {
+	var err error
+
+	err = binary.Write(w, binary.BigEndian, uint32(c.RecordType()))
+	if err != nil {
+		return err
+	}
+
+	err = binary.Write(w, binary.BigEndian, temperatureCountersSize)
+	if err != nil {
+		return err
+	}
+
+	err = binary.Write(w, binary.BigEndian, c)
+	return err
+}
+
+// RecordType returns the type of counter record.
+func (c HumidityCounters) RecordType() int {
+	return TypeHumidityCountersRecord
+}
+
+func decodeHumidityCountersRecord(r io.Reader, length uint32) (HumidityCounters, error) {
+	c := HumidityCounters{}
+	buf := getBuffer(int(length))
+	defer putBuffer(buf)
+	b := *buf
+	n, _ := r.Read(b)
+	if n != int(length) {
+		return c, records.ErrDecodingRecord
+	}
+
+	fields := []interface{}{
+		&c.Relative,
+	}
+
+	return c, readFields(b, fields)
+}
+
+func (c HumidityCounters) Encode(w io.Writer) error {
+	var err error
+
+	err = binary.Write(w, binary.BigEndian, uint32(c.RecordType()))
+	if err != nil {
+		return err
+	}
+
+	err = binary.Write(w, binary.BigEndian, humidityCountersSize)
+	if err != nil {
+		return err
+	}
+
+	err = binary.Write(w, binary.BigEndian, c)
+	return err
+}
+
+// RecordType returns the type of counter record.
+func (c FansCounters) RecordType() int {
+	return TypeFansCountersRecord
+}
+
+func decodeFansCountersRecord(r io.Reader, length uint32) (FansCounters, error) {
+	c := FansCounters{}
+	buf := getBuffer(int(length))
+	defer putBuffer(buf)
+	b := *buf
+	n, _ := r.Read(b)
+	if n != int(length) {
+		return c, records.ErrDecodingRecord
+	}
+
+	fields := []interface{}{
+		&c.Total,
+		&c.Failed,
+		&c.Speed,
+	}
+
+	return c, readFields(b, fields)
+}
+
+func (c FansCounters) Encode(w io.Writer) error {
+	var err error
+
+	err = binary.Write(w, binary.BigEndian, uint32(c.RecordType()))
+	if err != nil {
+		return err
+	}
+
+	err = binary.Write(w, binary.BigEndian, fansCountersSize)
+	if err != nil {
+		return err
+	}
+
+	err = binary.Write(w, binary.BigEndian, c)
+	return err
+}
+
+// RecordType returns the type of counter record.
+func (c BroadcomDeviceBuffersCounters) RecordType() int {
+	return TypeBroadcomDeviceBuffersCountersRecord
+}
+
+func decodeBroadcomDeviceBuffersCountersRecord(r io.Reader, length uint32) (BroadcomDeviceBuffersCounters, error) {
+	c := BroadcomDeviceBuffersCounters{}
+	buf := getBuffer(int(length))
+	defer putBuffer(buf)
+	b := *buf
+	n, _ := r.Read(b)
+	if n != int(length) {
+		return c, records.ErrDecodingRecord
+	}
+
+	fields := []interface{}{
+		&c.UnicastPercent,
+		&c.MulticastPercent,
+	}
+
+	return c, readFields(b, fields)
+}
+
+func (c BroadcomDeviceBuffersCounters) Encode(w io.Writer) error {
+	var err error
+
+	err = binary.Write(w, binary.BigEndian, uint32(c.RecordType()))
+	if err != nil {
+		return err
+	}
+
+	err = binary.Write(w, binary.BigEndian, broadcomDeviceBuffersCountersSize)
+	if err != nil {
+		return err
+	}
+
+	err = binary.Write(w, binary.BigEndian, c)
+	return err
+}
+
+// RecordType returns the type of counter record.
+func (c BroadcomPortBuffersCounters) RecordType() int {
+	return TypeBroadcomPortBuffersCountersRecord
+}
+
+func decodeBroadcomPortBuffersCountersRecord(r io.Reader, length uint32) (BroadcomPortBuffersCounters, error) {
+	c := BroadcomPortBuffersCounters{}
+	buf := getBuffer(int(length))
+	defer putBuffer(buf)
+	b := *buf
+	n, _ := r.Read(b)
+	if n != int(length) {
+		return c, records.ErrDecodingRecord
+	}
+
+	fields := []interface{}{
+		&c.IngressUnicastPercent,
+		&c.IngressMulticastPercent,
+		&c.EgressUnicastPercent,
+		&c.EgressMulticastPercent,
+		&c.EgressQueueUnicastPercent,
+		&c.EgressQueueMulticastPercent,
+	}
+
+	return c, readFields(b, fields)
+}
+
+func (c BroadcomPortBuffersCounters) Encode(w io.Writer) error {
+	var err error
+
+	fields := []interface{}{
+		c.IngressUnicastPercent,
+		c.IngressMulticastPercent,
+		c.EgressUnicastPercent,
+		c.EgressMulticastPercent,
+		c.EgressQueueUnicastPercent,
+		c.EgressQueueMulticastPercent,
+	}
+
+	err = binary.Write(w, binary.BigEndian, uint32(c.RecordType()))
+	if err != nil {
+		return err
+	}
+
+	err = binary.Write(w, binary.BigEndian, encodedFieldsSize(fields))
+	if err != nil {
+		return err
+	}
+
+	return writeFields(w, fields)
+}
+
+// RecordType returns the type of counter record.
+func (c BroadcomHardwareTablesCounters) RecordType() int {
+	return TypeBroadcomHardwareTablesCountersRecord
+}
+
+func decodeBroadcomHardwareTablesCountersRecord(r io.Reader, length uint32) (BroadcomHardwareTablesCounters, error) {
+	c := BroadcomHardwareTablesCounters{}
+	buf := getBuffer(int(length))
+	defer putBuffer(buf)
+	b := *buf
+	n, _ := r.Read(b)
+	if n != int(length) {
+		return c, records.ErrDecodingRecord
+	}
+
+	fields := []interface{}{
+		&c.HostEntries,
+		&c.HostEntriesMax,
+		&c.IPv4Entries,
+		&c.IPv4EntriesMax,
+		&c.IPv6Entries,
+		&c.IPv6EntriesMax,
+		&c.IPv4IPv6Entries,
+		&c.IPv4IPv6EntriesMax,
+		&c.LongIPv6Entries,
+		&c.LongIPv6EntriesMax,
+		&c.TotalRoutes,
+		&c.TotalRoutesMax,
+		&c.ECMPNexthops,
+		&c.ECMPNexthopsMax,
+		&c.MACEntries,
+		&c.MACEntriesMax,
+		&c.IPv4Neighbors,
+		&c.IPv6Neighbors,
+		&c.IPv4Routes,
+		&c.IPv6Routes,
+		&c.ACLIngressEntries,
+		&c.ACLIngressEntriesMax,
+		&c.ACLIngressCounters,
+		&c.ACLIngressCountersMax,
+		&c.ACLIngressMeters,
+		&c.ACLIngressMetersMax,
+		&c.ACLIngressSlices,
+		&c.ACLIngressSlicesMax,
+		&c.ACLEgressEntries,
+		&c.ACLEgressEntriesMax,
+		&c.ACLEgressCounters,
+		&c.ACLEgressCountersMax,
+		&c.ACLEgressMeters,
+		&c.ACLEgressMetersMax,
+		&c.ACLEgressSlices,
+		&c.ACLEgressSlicesMax,
+	}
+
+	return c, readFields(b, fields)
+}
+
+func (c BroadcomHardwareTablesCounters) Encode(w io.Writer) error {
+	var err error
+
+	err = binary.Write(w, binary.BigEndian, uint32(c.RecordType()))
+	if err != nil {
+		return err
+	}
+
+	err = binary.Write(w, binary.BigEndian, broadcomHardwareTablesCountersSize)
+	if err != nil {
+		return err
+	}
+
+	err = binary.Write(w, binary.BigEndian, c)
+	return err
+}
+
+// RecordType returns the type of counter record.
+func (c NvidiaGPUCounters) RecordType() int {
+	return TypeNvidiaGPUCountersRecord
+}
+
+func decodeNvidiaGPUCountersRecord(r io.Reader, length uint32) (NvidiaGPUCounters, error) {
+	c := NvidiaGPUCounters{}
+	buf := getBuffer(int(length))
+	defer putBuffer(buf)
+	b := *buf
+	n, _ := r.Read(b)
+	if n != int(length) {
+		return c, records.ErrDecodingRecord
+	}
+
+	fields := []interface{}{
+		&c.DeviceCount,
+		&c.Processes,
+		&c.GPUTime,
+		&c.MemTime,
+		&c.MemTotal,
+		&c.MemFree,
+		&c.ECCErrors,
+		&c.Energy,
+		&c.Temperature,
+		&c.FanSpeed,
+	}
+
+	return c, readFields(b, fields)
+}
+
+func (c NvidiaGPUCounters) Encode(w io.Writer) error {
+	var err error
+
+	err = binary.Write(w, binary.BigEndian, uint32(c.RecordType()))
+	if err != nil {
+		return err
+	}
+
+	err = binary.Write(w, binary.BigEndian, nvidiaGPUCountersSize)
 	if err != nil {
 		return err
 	}
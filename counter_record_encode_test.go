@@ -2,7 +2,11 @@ package sflow
 
 import (
 	"bytes"
+	"encoding/binary"
+	"reflect"
 	"testing"
+
+	"github.com/kanocz/sflow/records"
 )
 
 func TestEncodeDecodeGenericInterfaceCountersRecord(t *testing.T) {
@@ -103,3 +107,293 @@ func TestEncodeDecodeHostCPUCountersRecord(t *testing.T) {
 		t.Errorf("expected\n%+#v\n, got\n%+#v", rec, decoded)
 	}
 }
+
+func TestEncodeDecodeMIB2TCPGroupCountersRecord(t *testing.T) {
+	rec := MIB2TCPGroupCounters{
+		RtoAlgorithm: 1,
+		RtoMin:       200,
+		RtoMax:       120000,
+		MaxConn:      0xffffffff,
+		ActiveOpens:  5,
+		PassiveOpens: 6,
+		AttemptFails: 7,
+		EstabResets:  8,
+		CurrEstab:    9,
+		InSegs:       10,
+		OutSegs:      11,
+		RetransSegs:  12,
+		InErrs:       13,
+		OutRsts:      14,
+		InCsumErrors: 15,
+	}
+
+	b := &bytes.Buffer{}
+
+	err := rec.Encode(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Skip the header section. It's 8 bytes.
+	var headerBytes [8]byte
+
+	_, err = b.Read(headerBytes[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	decoded, err := decodeMIB2TCPGroupCountersRecord(b, uint32(b.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if decoded != rec {
+		t.Errorf("expected\n%+#v\n, got\n%+#v", rec, decoded)
+	}
+}
+
+func TestEncodeDecodeJMXRuntimeCountersRecord(t *testing.T) {
+	rec := JMXRuntimeCounters{
+		VMName:    "OpenJDK 64-Bit Server VM",
+		VMVendor:  "Eclipse Adoptium",
+		VMVersion: "17.0.8+7",
+	}
+
+	b := &bytes.Buffer{}
+
+	err := rec.Encode(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Skip the header section. It's 8 bytes.
+	var headerBytes [8]byte
+
+	_, err = b.Read(headerBytes[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if b.Len()%4 != 0 {
+		t.Fatalf("expected record to be padded to 4 bytes, got length %d", b.Len())
+	}
+
+	decoded, err := decodeJMXRuntimeCountersRecord(b, uint32(b.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if decoded != rec {
+		t.Errorf("expected\n%+#v\n, got\n%+#v", rec, decoded)
+	}
+}
+
+func TestEncodeDecodeJMXStatisticsCountersRecord(t *testing.T) {
+	rec := JMXStatisticsCounters{
+		HeapInitial:         1,
+		HeapUsed:            2,
+		HeapCommitted:       3,
+		HeapMax:             4,
+		NonHeapInitial:      5,
+		NonHeapUsed:         6,
+		NonHeapCommitted:    7,
+		NonHeapMax:          8,
+		GCCount:             9,
+		GCTime:              10,
+		ClassesLoaded:       11,
+		ClassesTotal:        12,
+		ClassesUnloaded:     13,
+		CompilationTime:     14,
+		ThreadsLive:         15,
+		ThreadsDaemon:       16,
+		ThreadsStarted:      17,
+		OpenFileDescriptors: 18,
+		MaxFileDescriptors:  19,
+	}
+
+	b := &bytes.Buffer{}
+
+	err := rec.Encode(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Skip the header section. It's 8 bytes.
+	var headerBytes [8]byte
+
+	_, err = b.Read(headerBytes[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// heap_used follows heap_initial in sflow_jmx
+	if used := binary.BigEndian.Uint64(b.Bytes()[8:]); used != rec.HeapUsed {
+		t.Errorf("expected heap used %d to be encoded second, got %d", rec.HeapUsed, used)
+	}
+
+	decoded, err := decodeJMXStatisticsCountersRecord(b, uint32(b.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if decoded != rec {
+		t.Errorf("expected\n%+#v\n, got\n%+#v", rec, decoded)
+	}
+}
+
+func TestEncodeDecodeOVSDPStatsCountersRecord(t *testing.T) {
+	rec := OVSDPStatsCounters{
+		Hits:     1,
+		Misses:   2,
+		Lost:     3,
+		MaskHits: 4,
+		Flows:    5,
+		Masks:    6,
+	}
+
+	b := &bytes.Buffer{}
+
+	err := rec.Encode(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Skip the header section. It's 8 bytes.
+	var headerBytes [8]byte
+
+	_, err = b.Read(headerBytes[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	decoded, err := decodeOVSDPStatsCountersRecord(b, uint32(b.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if decoded != rec {
+		t.Errorf("expected\n%+#v\n, got\n%+#v", rec, decoded)
+	}
+}
+
+func TestEncodeDecodeEnergyCountersRecord(t *testing.T) {
+	rec := EnergyCounters{
+		Voltage:     230000,
+		Current:     1500,
+		RealPower:   345000,
+		PowerFactor: -1,
+		Energy:      5,
+		Errors:      6,
+	}
+
+	b := &bytes.Buffer{}
+
+	err := rec.Encode(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Skip the header section. It's 8 bytes.
+	var headerBytes [8]byte
+
+	_, err = b.Read(headerBytes[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	decoded, err := decodeEnergyCountersRecord(b, uint32(b.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if decoded != rec {
+		t.Errorf("expected\n%+#v\n, got\n%+#v", rec, decoded)
+	}
+}
+
+func TestEncodeDecodeLAGPortCountersRecord(t *testing.T) {
+	rec := LAGPortCounters{
+		ActorSystemID:        records.HardwareAddr{0x00, 0x1b, 0x21, 0x3c, 0x4d, 0x5e},
+		PartnerOperSystemID:  records.HardwareAddr{0x00, 0x1b, 0x21, 0x6f, 0x70, 0x81},
+		AttachedAggID:        501,
+		ActorAdminState:      0x3d,
+		ActorOperState:       0x3f,
+		PartnerAdminState:    0x01,
+		PartnerOperState:     0x3f,
+		LACPDUsRx:            1,
+		MarkerPDUsRx:         2,
+		MarkerResponsePDUsRx: 3,
+		UnknownRx:            4,
+		IllegalRx:            5,
+		LACPDUsTx:            6,
+		MarkerPDUsTx:         7,
+		MarkerResponsePDUsTx: 8,
+	}
+
+	b := &bytes.Buffer{}
+
+	err := rec.Encode(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Skip the header section. It's 8 bytes.
+	var headerBytes [8]byte
+
+	_, err = b.Read(headerBytes[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if b.Len() != 56 {
+		t.Fatalf("expected record length 56, got %d", b.Len())
+	}
+
+	decoded, err := decodeLAGPortCountersRecord(b, uint32(b.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(decoded, rec) {
+		t.Errorf("expected\n%+#v\n, got\n%+#v", rec, decoded)
+	}
+}
+
+func TestEncodeDecodeNvidiaGPUCountersRecord(t *testing.T) {
+	rec := NvidiaGPUCounters{
+		DeviceCount: 4,
+		Processes:   12,
+		GPUTime:     58000,
+		MemTime:     31000,
+		MemTotal:    4 * 85899345920,
+		MemFree:     17179869184,
+		ECCErrors:   0,
+		Energy:      1200000,
+		Temperature: 71,
+		FanSpeed:    60,
+	}
+
+	b := &bytes.Buffer{}
+
+	err := rec.Encode(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Skip the header section. It's 8 bytes.
+	var headerBytes [8]byte
+
+	_, err = b.Read(headerBytes[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	decoded, err := decodeNvidiaGPUCountersRecord(b, uint32(b.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if decoded != rec {
+		t.Errorf("expected\n%+#v\n, got\n%+#v", rec, decoded)
+	}
+}
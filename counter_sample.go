@@ -3,7 +3,6 @@ package sflow
 import (
 	"bytes"
 	"encoding/binary"
-	"errors"
 	"fmt"
 	"io"
 
@@ -16,23 +15,53 @@ const (
 	TypeTokenRingCountersRecord        = 3
 	TypeVgCountersRecord               = 4
 	TypeVlanCountersRecord             = 5
-
-	TypeProcessorCountersRecord  = 1001
-	TypeHostCPUCountersRecord    = 2003
-	TypeHostMemoryCountersRecord = 2004
-	TypeHostDiskCountersRecord   = 2005
-	TypeHostNetCountersRecord    = 2006
+	TypeIEEE80211CountersRecord        = 6
+	TypeLAGPortCountersRecord          = 7
+	TypeSlowPathCountersRecord         = 8
+	TypeInfiniBandCountersRecord       = 9
+
+	TypeProcessorCountersRecord        = 1001
+	TypeRadioUtilizationCountersRecord = 1002
+	TypeQueueLengthCountersRecord      = 1003
+	TypeOpenFlowPortCountersRecord     = 1004
+	TypePortNameCountersRecord         = 1005
+	TypeHostCPUCountersRecord          = 2003
+	TypeHostMemoryCountersRecord       = 2004
+	TypeHostDiskCountersRecord         = 2005
+	TypeHostNetCountersRecord          = 2006
+
+	TypeMIB2IPGroupCountersRecord   = 2007
+	TypeMIB2ICMPGroupCountersRecord = 2008
+	TypeMIB2TCPGroupCountersRecord  = 2009
+	TypeMIB2UDPGroupCountersRecord  = 2010
+
+	TypeJMXRuntimeCountersRecord    = 2105
+	TypeJMXStatisticsCountersRecord = 2106
+	TypeOVSDPStatsCountersRecord    = 2207
+
+	TypeEnergyCountersRecord      = 3000
+	TypeTemperatureCountersRecord = 3001
+	TypeHumidityCountersRecord    = 3002
+	TypeFansCountersRecord        = 3003
 
 	// Custom (Enterprise) types
 	TypeApplicationCountersRecord = (1)<<12 + 1
+
+	// Broadcom (Enterprise 4413) types
+	TypeBroadcomDeviceBuffersCountersRecord  = (4413)<<12 + 1
+	TypeBroadcomPortBuffersCountersRecord    = (4413)<<12 + 2
+	TypeBroadcomHardwareTablesCountersRecord = (4413)<<12 + 3
+
+	// NVIDIA (Enterprise 5703) types
+	TypeNvidiaGPUCountersRecord = (5703)<<12 + 1
 )
 
 type CounterSample struct {
-	SequenceNum      uint32
-	SourceIdType     byte
-	SourceIdIndexVal uint32 // NOTE: this is 3 bytes in the datagram
+	SequenceNum      uint32 `json:"sequenceNum"`
+	SourceIdType     byte   `json:"sourceIdType"`
+	SourceIdIndexVal uint32 `json:"sourceIdIndexVal"` // NOTE: this is 3 bytes in the datagram
 	numRecords       uint32
-	Records          []records.Record
+	Records          []records.Record `json:"records"`
 }
 
 func (s CounterSample) String() string {
@@ -50,8 +79,8 @@ func (s *CounterSample) GetRecords() []records.Record {
 	return s.Records
 }
 
-func decodeCounterSample(r io.ReadSeeker) (Sample, error) {
-	s := &CounterSample{}
+func decodeCounterSample(r io.ReadSeeker, d *decodeState) (Sample, error) {
+	s := getCounterSample()
 
 	var err error
 
@@ -66,108 +95,107 @@ func decodeCounterSample(r io.ReadSeeker) (Sample, error) {
 	}
 
 	var srcIdIndexVal [3]byte
-	n, err := r.Read(srcIdIndexVal[:])
+	_, err = io.ReadFull(r, srcIdIndexVal[:])
 	if err != nil {
 		return nil, err
 	}
 
-	if n != 3 {
-		return nil, errors.New("sflow: counter sample decoding error")
-	}
-
 	s.SourceIdIndexVal = uint32(srcIdIndexVal[2]) |
-		uint32(srcIdIndexVal[1]<<8) |
-		uint32(srcIdIndexVal[0]<<16)
+		uint32(srcIdIndexVal[1])<<8 |
+		uint32(srcIdIndexVal[0])<<16
 
 	err = binary.Read(r, binary.BigEndian, &s.numRecords)
 	if err != nil {
 		return nil, err
 	}
 
-	for i := uint32(0); i < s.numRecords; i++ {
-		format, length := uint32(0), uint32(0)
-
-		err = binary.Read(r, binary.BigEndian, &format)
-		if err != nil {
-			return nil, err
-		}
+	s.Records, err = decodeRecords(r, s.numRecords, s.Records, d, decodeCounterRecord)
+	if err != nil {
+		return nil, err
+	}
 
-		err = binary.Read(r, binary.BigEndian, &length)
-		if err != nil {
-			return nil, err
-		}
-		if length > MaximumRecordLength {
-			return nil, fmt.Errorf("sflow: record length more than %d: %d",
-				MaximumRecordLength, length)
-		}
+	return s, nil
+}
 
-		var rec records.Record
-
-		switch format {
-		case TypeGenericInterfaceCountersRecord:
-			rec, err = decodeGenericInterfaceCountersRecord(r, length)
-			if err != nil {
-				return nil, err
-			}
-		case TypeEthernetCountersRecord:
-			rec, err = decodeEthernetCountersRecord(r, length)
-			if err != nil {
-				return nil, err
-			}
-		case TypeTokenRingCountersRecord:
-			rec, err = decodeTokenRingCountersRecord(r, length)
-			if err != nil {
-				return nil, err
-			}
-		case TypeVgCountersRecord:
-			rec, err = decodeVgCountersRecord(r, length)
-			if err != nil {
-				return nil, err
-			}
-		case TypeVlanCountersRecord:
-			rec, err = decodeVlanCountersRecord(r, length)
-			if err != nil {
-				return nil, err
-			}
-		case TypeProcessorCountersRecord:
-			rec, err = decodeProcessorCountersRecord(r, length)
-			if err != nil {
-				return nil, err
-			}
-		case TypeHostCPUCountersRecord:
-			rec, err = decodeHostCPUCountersRecord(r, length)
-			if err != nil {
-				return nil, err
-			}
-		case TypeHostMemoryCountersRecord:
-			rec, err = decodeHostMemoryCountersRecord(r, length)
-			if err != nil {
-				return nil, err
-			}
-		case TypeHostDiskCountersRecord:
-			rec, err = decodeHostDiskCountersRecord(r, length)
-			if err != nil {
-				return nil, err
-			}
-		case TypeHostNetCountersRecord:
-			rec, err = decodeHostNetCountersRecord(r, length)
-			if err != nil {
-				return nil, err
-			}
-		default:
-			if rec, err = records.DecodeCounter(r, format); err != nil {
-				//				fmt.Printf("Error: %s\n", err)
-				_, err := r.Seek(int64(length), 1)
-				if err != nil {
-					return nil, err
-				}
-				continue
-			}
-		}
+// decodeCounterRecord decodes a counter record of the given
+// format and length.
+func decodeCounterRecord(r io.Reader, format, length uint32, a *records.Allocation) (records.Record, error) {
+	// Only decode the known fields of fixed size records, so that
+	// extra bytes are reported as a length mismatch.
+	if size, ok := counterRecordSizes[format]; ok && length > size {
+		length = size
+	}
 
-		s.Records = append(s.Records, rec)
+	switch format {
+	case TypeGenericInterfaceCountersRecord:
+		return decodeGenericInterfaceCountersRecord(r, length)
+	case TypeEthernetCountersRecord:
+		return decodeEthernetCountersRecord(r, length)
+	case TypeTokenRingCountersRecord:
+		return decodeTokenRingCountersRecord(r, length)
+	case TypeVgCountersRecord:
+		return decodeVgCountersRecord(r, length)
+	case TypeVlanCountersRecord:
+		return decodeVlanCountersRecord(r, length)
+	case TypeIEEE80211CountersRecord:
+		return decodeIEEE80211CountersRecord(r, length)
+	case TypeLAGPortCountersRecord:
+		return decodeLAGPortCountersRecord(r, length)
+	case TypeSlowPathCountersRecord:
+		return decodeSlowPathCountersRecord(r, length)
+	case TypeInfiniBandCountersRecord:
+		return decodeInfiniBandCountersRecord(r, length)
+	case TypeProcessorCountersRecord:
+		return decodeProcessorCountersRecord(r, length)
+	case TypeRadioUtilizationCountersRecord:
+		return decodeRadioUtilizationCountersRecord(r, length)
+	case TypeQueueLengthCountersRecord:
+		return decodeQueueLengthCountersRecord(r, length)
+	case TypeOpenFlowPortCountersRecord:
+		return decodeOpenFlowPortCountersRecord(r, length)
+	case TypePortNameCountersRecord:
+		return decodePortNameCountersRecord(r, length)
+	case TypeHostCPUCountersRecord:
+		return decodeHostCPUCountersRecord(r, length)
+	case TypeHostMemoryCountersRecord:
+		return decodeHostMemoryCountersRecord(r, length)
+	case TypeHostDiskCountersRecord:
+		return decodeHostDiskCountersRecord(r, length)
+	case TypeHostNetCountersRecord:
+		return decodeHostNetCountersRecord(r, length)
+	case TypeMIB2IPGroupCountersRecord:
+		return decodeMIB2IPGroupCountersRecord(r, length)
+	case TypeMIB2ICMPGroupCountersRecord:
+		return decodeMIB2ICMPGroupCountersRecord(r, length)
+	case TypeMIB2TCPGroupCountersRecord:
+		return decodeMIB2TCPGroupCountersRecord(r, length)
+	case TypeMIB2UDPGroupCountersRecord:
+		return decodeMIB2UDPGroupCountersRecord(r, length)
+	case TypeJMXRuntimeCountersRecord:
+		return decodeJMXRuntimeCountersRecord(r, length)
+	case TypeJMXStatisticsCountersRecord:
+		return decodeJMXStatisticsCountersRecord(r, length)
+	case TypeOVSDPStatsCountersRecord:
+		return decodeOVSDPStatsCountersRecord(r, length)
+	case TypeEnergyCountersRecord:
+		return decodeEnergyCountersRecord(r, length)
+	case TypeTemperatureCountersRecord:
+		return decodeTemperatureCountersRecord(r, length)
+	case TypeHumidityCountersRecord:
+		return decodeHumidityCountersRecord(r, length)
+	case TypeFansCountersRecord:
+		return decodeFansCountersRecord(r, length)
+	case TypeBroadcomDeviceBuffersCountersRecord:
+		return decodeBroadcomDeviceBuffersCountersRecord(r, length)
+	case TypeBroadcomPortBuffersCountersRecord:
+		return decodeBroadcomPortBuffersCountersRecord(r, length)
+	case TypeBroadcomHardwareTablesCountersRecord:
+		return decodeBroadcomHardwareTablesCountersRecord(r, length)
+	case TypeNvidiaGPUCountersRecord:
+		return decodeNvidiaGPUCountersRecord(r, length)
 	}
-	return s, nil
+
+	return records.DecodeCounterLimited(r, format, a)
 }
 
 func (s *CounterSample) encode(w io.Writer) error {
@@ -202,7 +230,7 @@ func (s *CounterSample) encode(w io.Writer) error {
 		return err
 	}
 	err = binary.Write(w, binary.BigEndian,
-		uint32(s.SourceIdType)|s.SourceIdIndexVal<<24)
+		uint32(s.SourceIdType)<<24|s.SourceIdIndexVal&0xffffff)
 	if err != nil {
 		return err
 	}
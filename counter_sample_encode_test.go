@@ -3,7 +3,10 @@ package sflow
 import (
 	"bytes"
 	"os"
+	"reflect"
 	"testing"
+
+	"github.com/kanocz/sflow/records"
 )
 
 func TestDecodeEncodeAndDecodeCounterSample(t *testing.T) {
@@ -48,7 +51,7 @@ func TestDecodeEncodeAndDecodeCounterSample(t *testing.T) {
 	buf.Read(skip[:])
 
 	// bytes.Buffer is not an io.ReadSeeker. bytes.Reader is.
-	decodedSample, err := decodeCounterSample(bytes.NewReader(buf.Bytes()))
+	decodedSample, err := decodeCounterSample(bytes.NewReader(buf.Bytes()), newDecodeState(nil))
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -103,3 +106,73 @@ func TestDecodeEncodeAndDecodeCounterSample(t *testing.T) {
 		t.Errorf("expected\n%#v, got\n%#v", expectedGenericInterfaceCounters, genericInterfaceCounters)
 	}
 }
+
+func TestEncodeDecodeEnterpriseCounterSample(t *testing.T) {
+	rec := BroadcomPortBuffersCounters{
+		IngressUnicastPercent:       150,
+		IngressMulticastPercent:     -1,
+		EgressUnicastPercent:        9800,
+		EgressMulticastPercent:      0,
+		EgressQueueUnicastPercent:   []int32{9800, 12, 0, 0, 0, 0, 0, 3},
+		EgressQueueMulticastPercent: []int32{0, 0, 0, 0, 0, 0, 0, 0},
+	}
+
+	sample := &CounterSample{
+		SequenceNum: 1,
+		Records:     []records.Record{rec},
+	}
+
+	buf := &bytes.Buffer{}
+
+	err := sample.encode(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// We need to skip the first 8 bytes. That's the header.
+	var skip [8]byte
+	buf.Read(skip[:])
+
+	decodedSample, err := decodeCounterSample(bytes.NewReader(buf.Bytes()), newDecodeState(nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	decoded := decodedSample.GetRecords()
+	if len(decoded) != 1 {
+		t.Fatalf("expected 1 record, got %d", len(decoded))
+	}
+
+	if !reflect.DeepEqual(decoded[0], rec) {
+		t.Errorf("expected\n%#v, got\n%#v", rec, decoded[0])
+	}
+}
+
+func TestEncodeDecodeCounterSampleSourceId(t *testing.T) {
+	sample := &CounterSample{
+		SequenceNum:      1,
+		SourceIdType:     2,
+		SourceIdIndexVal: 0x123456,
+	}
+
+	buf := &bytes.Buffer{}
+
+	err := sample.encode(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// We need to skip the first 8 bytes. That's the header.
+	var skip [8]byte
+	buf.Read(skip[:])
+
+	decodedSample, err := decodeCounterSample(bytes.NewReader(buf.Bytes()), newDecodeState(nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	decoded := decodedSample.(*CounterSample)
+	if decoded.SourceIdType != 2 || decoded.SourceIdIndexVal != 0x123456 {
+		t.Errorf("expected source id 2:0x123456, got %d:%#x", decoded.SourceIdType, decoded.SourceIdIndexVal)
+	}
+}
@@ -0,0 +1,435 @@
+package sflow
+
+import (
+	"math"
+	"net"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/kanocz/sflow/records"
+)
+
+// CounterRates are the per-second rates of the counters of a counter
+// record, computed from the record and the previous one of the same
+// agent, source and record type.
+type CounterRates struct {
+	Agent            net.IP
+	SubAgentId       uint32
+	SourceIdType     byte
+	SourceIdIndexVal uint32
+	RecordType       int
+
+	// Interval is the agent uptime between the two records.
+	Interval time.Duration
+
+	// Restarted is set when the agent restarted since the previous
+	// record. Restarted rates have no fields, the record becomes
+	// the base for the next rates.
+	Restarted bool
+
+	// Fields holds the rate of every counter field by field name.
+	// Which fields are counters is told by IsCounter.
+	Fields map[string]float64
+
+	// Wrapped names the 32-bit and smaller counters that
+	// wrapped around.
+	Wrapped []string
+
+	// Reset names the 64-bit counters that went backwards. They
+	// were reset, not wrapped, and have no rate in Fields and a
+	// NaN rate in Rates.
+	Reset []string
+
+	// Rates holds Fields as InterfaceRates, EthernetRates,
+	// HostCPURates, HostMemoryRates, HostDiskRates or HostNetRates,
+	// depending on the record type. It is nil for other records
+	// and for restarts.
+	Rates interface{}
+}
+
+// InterfaceRates are the rates of GenericInterfaceCounters.
+type InterfaceRates struct {
+	InOctets            float64
+	InUnicastPackets    float64
+	InMulticastPackets  float64
+	InBroadcastPackets  float64
+	InDiscards          float64
+	InErrors            float64
+	InUnknownProtocols  float64
+	OutOctets           float64
+	OutUnicastPackets   float64
+	OutMulticastPackets float64
+	OutBroadcastPackets float64
+	OutDiscards         float64
+	OutErrors           float64
+}
+
+// EthernetRates are the rates of EthernetCounters.
+type EthernetRates struct {
+	AlignmentErrors           float64
+	FCSErrors                 float64
+	SingleCollisionFrames     float64
+	MultipleCollisionFrames   float64
+	SQETestErrors             float64
+	DeferredTransmissions     float64
+	LateCollisions            float64
+	ExcessiveCollisions       float64
+	InternalMACTransmitErrors float64
+	CarrierSenseErrors        float64
+	FrameTooLongs             float64
+	InternalMACReceiveErrors  float64
+	SymbolErrors              float64
+}
+
+// HostCPURates are the rates of HostCPUCounters. CPU times are
+// in milliseconds per second.
+type HostCPURates struct {
+	CPUUser         float64
+	CPUNice         float64
+	CPUSys          float64
+	CPUIdle         float64
+	CPUWio          float64
+	CPUIntr         float64
+	CPUSoftIntr     float64
+	Interrupts      float64
+	ContextSwitches float64
+	CPUSteal        float64
+	CPUGuest        float64
+	CPUGuestNice    float64
+}
+
+// HostMemoryRates are the rates of HostMemoryCounters.
+type HostMemoryRates struct {
+	PageIn  float64
+	PageOut float64
+	SwapIn  float64
+	SwapOut float64
+}
+
+// HostDiskRates are the rates of HostDiskCounters. Read and
+// write times are in milliseconds per second.
+type HostDiskRates struct {
+	Reads        float64
+	BytesRead    float64
+	ReadTime     float64
+	Writes       float64
+	BytesWritten float64
+	WriteTime    float64
+}
+
+// HostNetRates are the rates of HostNetCounters.
+type HostNetRates struct {
+	BytesIn    float64
+	PacketsIn  float64
+	ErrorsIn   float64
+	DropsIn    float64
+	BytesOut   float64
+	PacketsOut float64
+	ErrorsOut  float64
+	DropsOut   float64
+}
+
+// rateTypes maps counter record types to their typed rate structs.
+// The fields of a rate struct name the counters of the record, the
+// other fields of the record are gauges.
+var rateTypes = map[int]reflect.Type{
+	TypeGenericInterfaceCountersRecord: reflect.TypeOf(InterfaceRates{}),
+	TypeEthernetCountersRecord:         reflect.TypeOf(EthernetRates{}),
+	TypeHostCPUCountersRecord:          reflect.TypeOf(HostCPURates{}),
+	TypeHostMemoryCountersRecord:       reflect.TypeOf(HostMemoryRates{}),
+	TypeHostDiskCountersRecord:         reflect.TypeOf(HostDiskRates{}),
+	TypeHostNetCountersRecord:          reflect.TypeOf(HostNetRates{}),
+}
+
+// counterNames names the counters of the record types without a typed
+// rate struct. Their other fields, and the fields of record types not
+// listed, are gauges.
+var counterNames = map[int][]string{
+	TypeTokenRingCountersRecord: {
+		"LineErrors", "BurstErrors", "ACErrors", "AbortTransErrors", "InternalErrors",
+		"LostFrameErrors", "ReceiveCongestions", "FrameCopiedErrors", "TokenErrors",
+		"SoftErrors", "HardErrors", "SignalLoss", "TransmitBeacons", "Recoverys",
+		"LobeWires", "Removes", "Singles", "FreqErrors",
+	},
+	TypeVgCountersRecord: {
+		"InHighPriorityFrames", "InHighPriorityOctets", "InNormPriorityFrames",
+		"InNormPriorityOctets", "InIPMErrors", "InOversizeFrameErrors", "InDataErrors",
+		"InNullAddressedFrames", "OutHighPriorityFrames", "OutHighPriorityOctets",
+		"TransitionIntoTrainings", "HCInHighPriorityOctets", "HCInNormPriorityOctets",
+		"HCOutHighPriorityOctets",
+	},
+	TypeVlanCountersRecord: {
+		"Octets", "UnicastPackets", "MulticastPackets", "BroadcastPackets", "Discards",
+	},
+	TypeIEEE80211CountersRecord: {
+		"TransmittedFragmentCount", "MulticastTransmittedFrameCount", "FailedCount",
+		"RetryCount", "MultipleRetryCount", "FrameDuplicateCount", "RTSSuccessCount",
+		"RTSFailureCount", "ACKFailureCount", "ReceivedFragmentCount",
+		"MulticastReceivedFrameCount", "FCSErrorCount", "TransmittedFrameCount",
+		"WEPUndecryptableCount", "QoSDiscardedFragmentCount", "QoSCFPollsReceivedCount",
+		"QoSCFPollsUnusedCount", "QoSCFPollsUnusableCount", "QoSCFPollsLostCount",
+	},
+	TypeLAGPortCountersRecord: {
+		"LACPDUsRx", "MarkerPDUsRx", "MarkerResponsePDUsRx", "UnknownRx", "IllegalRx",
+		"LACPDUsTx", "MarkerPDUsTx", "MarkerResponsePDUsTx",
+	},
+	TypeSlowPathCountersRecord: {
+		"Unknown", "Other", "CAMMiss", "CAMFull", "NoHWSupport", "Control",
+	},
+	TypeInfiniBandCountersRecord: {
+		"PortXmitPkts", "PortRcvPkts", "SymbolErrorCounter", "LinkErrorRecoveryCounter",
+		"LinkDownedCounter", "PortRcvErrors", "PortRcvRemotePhysicalErrors",
+		"PortRcvSwitchRelayErrors", "PortXmitDiscards", "PortXmitConstraintErrors",
+		"PortRcvConstraintErrors", "LocalLinkIntegrityErrors",
+		"ExcessiveBufferOverrunErrors", "VL15Dropped",
+	},
+	TypeRadioUtilizationCountersRecord: {
+		"ElapsedTime", "OnChannelTime", "OnChannelBusyTime",
+	},
+	TypeQueueLengthCountersRecord: {
+		"QueueLength0", "QueueLength1", "QueueLength2", "QueueLength4", "QueueLength8",
+		"QueueLength32", "QueueLength128", "QueueLength1024", "QueueLengthMore", "Dropped",
+	},
+	TypeMIB2IPGroupCountersRecord: {
+		"InReceives", "InHdrErrors", "InAddrErrors", "ForwDatagrams", "InUnknownProtos",
+		"InDiscards", "InDelivers", "OutRequests", "OutDiscards", "OutNoRoutes",
+		"ReasmReqds", "ReasmOKs", "ReasmFails", "FragOKs", "FragFails", "FragCreates",
+	},
+	TypeMIB2ICMPGroupCountersRecord: {
+		"InMsgs", "InErrors", "InDestUnreachs", "InTimeExcds", "InParamProbs",
+		"InSrcQuenchs", "InRedirects", "InEchos", "InEchoReps", "InTimestamps",
+		"InAddrMasks", "InAddrMaskReps", "OutMsgs", "OutErrors", "OutDestUnreachs",
+		"OutTimeExcds", "OutParamProbs", "OutSrcQuenchs", "OutRedirects", "OutEchos",
+		"OutEchoReps", "OutTimestamps", "OutTimestampReps", "OutAddrMasks", "OutAddrMaskReps",
+	},
+	TypeMIB2TCPGroupCountersRecord: {
+		"ActiveOpens", "PassiveOpens", "AttemptFails", "EstabResets", "InSegs",
+		"OutSegs", "RetransSegs", "InErrs", "OutRsts", "InCsumErrors",
+	},
+	TypeMIB2UDPGroupCountersRecord: {
+		"InDatagrams", "NoPorts", "InErrors", "OutDatagrams", "RcvbufErrors",
+		"SndbufErrors", "InCsumErrors",
+	},
+	TypeJMXStatisticsCountersRecord: {
+		"GCCount", "GCTime", "ClassesTotal", "ClassesUnloaded", "CompilationTime",
+		"ThreadsStarted",
+	},
+	TypeOVSDPStatsCountersRecord: {
+		"Hits", "Misses", "Lost", "MaskHits",
+	},
+	TypeEnergyCountersRecord:      {"Energy", "Errors"},
+	TypeTemperatureCountersRecord: {"Errors"},
+	TypeNvidiaGPUCountersRecord: {
+		"GPUTime", "MemTime", "ECCErrors", "Energy",
+	},
+	records.TypeHTTPCounterRecord: {
+		"MethodOptionCount", "MethodGetCount", "MethodHeadCount", "MethodPostCount",
+		"MethodPutCount", "MethodDeleteCount", "MethodTraceCount", "MethodConnectCount",
+		"MethodOtherCount", "Status1XXCount", "Status2XXCount", "Status3XXCount",
+		"Status4XXCount", "Status5XXCount", "StatusOtherCount",
+	},
+}
+
+// IsCounter reports whether the unsigned integer field of counter
+// records of type recordType is a counter rather than a gauge. The
+// counters are the fields of the typed rate struct of the record type,
+// or the fields in counterNames. Fields of unknown records are gauges.
+func IsCounter(recordType int, field string) bool {
+	if typ, ok := rateTypes[recordType]; ok {
+		_, ok = typ.FieldByName(field)
+		return ok
+	}
+
+	for _, name := range counterNames[recordType] {
+		if name == field {
+			return true
+		}
+	}
+
+	return false
+}
+
+// counterField is a counter of a record type.
+type counterField struct {
+	index int
+	name  string
+	bits  int
+}
+
+// counterKey identifies a series of counter records.
+type counterKey struct {
+	agent            string
+	subAgentId       uint32
+	sourceIdType     byte
+	sourceIdIndexVal uint32
+	recordType       int
+}
+
+// counterState is the previous record of a series.
+type counterState struct {
+	uptime      uint32
+	sequenceNum uint32
+	values      []uint64
+}
+
+// CounterTracker keeps the previous counter record per agent, source
+// and record type and computes counter rates from the records that
+// follow. A CounterTracker is safe for concurrent use.
+type CounterTracker struct {
+	mu       sync.Mutex
+	previous map[counterKey]*counterState
+	fields   map[reflect.Type][]counterField
+}
+
+// NewCounterTracker returns a new, empty CounterTracker.
+func NewCounterTracker() *CounterTracker {
+	return &CounterTracker{
+		previous: make(map[counterKey]*counterState),
+		fields:   make(map[reflect.Type][]counterField),
+	}
+}
+
+// Update records the counter samples of d and returns the rates of
+// every counter record that follows an earlier one. Intervals are
+// taken from the datagram uptime. An uptime or sample sequence number
+// going backwards means the agent restarted, and a 32-bit counter
+// going backwards otherwise means it wrapped around.
+func (t *CounterTracker) Update(d *Datagram) []CounterRates {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	var rates []CounterRates
+
+	for _, sample := range d.Samples {
+		s, ok := sample.(*CounterSample)
+		if !ok {
+			continue
+		}
+
+		for _, rec := range s.Records {
+			key := counterKey{
+				agent:            string(d.IpAddress.To16()),
+				subAgentId:       d.SubAgentId,
+				sourceIdType:     s.SourceIdType,
+				sourceIdIndexVal: s.SourceIdIndexVal,
+				recordType:       rec.RecordType(),
+			}
+
+			v := reflect.ValueOf(rec)
+			if v.Kind() != reflect.Struct {
+				continue
+			}
+
+			fields := t.counterFields(key.recordType, v.Type())
+			if len(fields) == 0 {
+				continue
+			}
+
+			cur := &counterState{
+				uptime:      d.Uptime,
+				sequenceNum: s.SequenceNum,
+				values:      make([]uint64, len(fields)),
+			}
+			for i, f := range fields {
+				cur.values[i] = v.Field(f.index).Uint()
+			}
+
+			prev, ok := t.previous[key]
+			if ok && prev.uptime == cur.uptime {
+				// a duplicate, or too close to tell
+				continue
+			}
+
+			t.previous[key] = cur
+
+			if !ok {
+				continue
+			}
+
+			r := CounterRates{
+				Agent:            net.IP(append([]byte(nil), d.IpAddress...)),
+				SubAgentId:       d.SubAgentId,
+				SourceIdType:     s.SourceIdType,
+				SourceIdIndexVal: s.SourceIdIndexVal,
+				RecordType:       key.recordType,
+			}
+
+			if int32(cur.uptime-prev.uptime) < 0 || int32(cur.sequenceNum-prev.sequenceNum) < 0 {
+				r.Restarted = true
+				rates = append(rates, r)
+				continue
+			}
+
+			r.Interval = time.Duration(cur.uptime-prev.uptime) * time.Millisecond
+			r.Fields = make(map[string]float64, len(fields))
+
+			for i, f := range fields {
+				delta := cur.values[i] - prev.values[i]
+
+				if cur.values[i] < prev.values[i] {
+					if f.bits == 64 {
+						r.Reset = append(r.Reset, f.name)
+						continue
+					}
+
+					delta &= 1<<uint(f.bits) - 1
+					r.Wrapped = append(r.Wrapped, f.name)
+				}
+
+				r.Fields[f.name] = float64(delta) / r.Interval.Seconds()
+			}
+
+			if typ, ok := rateTypes[key.recordType]; ok {
+				typed := reflect.New(typ).Elem()
+				for i := 0; i < typ.NumField(); i++ {
+					rate, ok := r.Fields[typ.Field(i).Name]
+					if !ok {
+						rate = math.NaN()
+					}
+					typed.Field(i).SetFloat(rate)
+				}
+				r.Rates = typed.Interface()
+			}
+
+			rates = append(rates, r)
+		}
+	}
+
+	return rates
+}
+
+// counterFields returns the counters of records of type typ.
+func (t *CounterTracker) counterFields(recordType int, typ reflect.Type) []counterField {
+	if fields, ok := t.fields[typ]; ok {
+		return fields
+	}
+
+	var fields []counterField
+	for i := 0; i < typ.NumField(); i++ {
+		f := typ.Field(i)
+		if f.PkgPath != "" {
+			continue
+		}
+
+		switch f.Type.Kind() {
+		case reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		default:
+			continue
+		}
+
+		if !IsCounter(recordType, f.Name) {
+			continue
+		}
+
+		fields = append(fields, counterField{
+			index: i,
+			name:  f.Name,
+			bits:  f.Type.Bits(),
+		})
+	}
+
+	t.fields[typ] = fields
+
+	return fields
+}
@@ -0,0 +1,171 @@
+package sflow
+
+import (
+	"math"
+	"net"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/kanocz/sflow/records"
+)
+
+func counterDatagram(uptime, seq uint32, recs ...records.Record) *Datagram {
+	return &Datagram{
+		IpAddress:  net.IPv4(192, 0, 2, 1).To4(),
+		SubAgentId: 1,
+		Uptime:     uptime,
+		Samples: []Sample{&CounterSample{
+			SequenceNum:      seq,
+			SourceIdIndexVal: 3,
+			Records:          recs,
+		}},
+	}
+}
+
+func TestCounterTrackerRates(t *testing.T) {
+	tracker := NewCounterTracker()
+
+	rates := tracker.Update(counterDatagram(10000, 1,
+		GenericInterfaceCounters{Index: 3, Speed: 1e9, InOctets: 1000, InUnicastPackets: 10},
+		HostNetCounters{BytesIn: 500, PacketsIn: math.MaxUint32 - 9},
+	))
+	if len(rates) != 0 {
+		t.Fatalf("expected no rates for the first sample, got %v", rates)
+	}
+
+	rates = tracker.Update(counterDatagram(20000, 2,
+		GenericInterfaceCounters{Index: 3, Speed: 1e9, InOctets: 11000, InUnicastPackets: 110},
+		HostNetCounters{BytesIn: 100, PacketsIn: 10},
+	))
+	if len(rates) != 2 {
+		t.Fatalf("expected 2 rates, got %d", len(rates))
+	}
+
+	r := rates[0]
+	if !r.Agent.Equal(net.IPv4(192, 0, 2, 1)) || r.SubAgentId != 1 || r.SourceIdIndexVal != 3 ||
+		r.RecordType != TypeGenericInterfaceCountersRecord || r.Interval != 10*time.Second || r.Restarted {
+		t.Errorf("unexpected rates %+v", r)
+	}
+
+	if _, ok := r.Fields["Speed"]; ok {
+		t.Error("expected no rate for the Speed gauge")
+	}
+
+	iface, ok := r.Rates.(InterfaceRates)
+	if !ok || iface.InOctets != 1000 || iface.InUnicastPackets != 10 || iface.OutOctets != 0 {
+		t.Errorf("unexpected interface rates %+v", r.Rates)
+	}
+
+	r = rates[1]
+	if !reflect.DeepEqual(r.Wrapped, []string{"PacketsIn"}) || !reflect.DeepEqual(r.Reset, []string{"BytesIn"}) {
+		t.Errorf("expected PacketsIn to wrap and BytesIn to reset, got %v and %v", r.Wrapped, r.Reset)
+	}
+
+	hostNet, ok := r.Rates.(HostNetRates)
+	if !ok || hostNet.PacketsIn != 2 || !math.IsNaN(hostNet.BytesIn) {
+		t.Errorf("unexpected host net rates %+v", r.Rates)
+	}
+
+	if _, ok := r.Fields["BytesIn"]; ok {
+		t.Error("expected no rate for a reset counter")
+	}
+}
+
+func TestCounterTrackerRestart(t *testing.T) {
+	tracker := NewCounterTracker()
+
+	tracker.Update(counterDatagram(50000, 10, HostNetCounters{PacketsIn: 1000}))
+
+	// duplicates are ignored
+	if rates := tracker.Update(counterDatagram(50000, 10, HostNetCounters{PacketsIn: 1000})); len(rates) != 0 {
+		t.Errorf("expected no rates for a duplicate, got %v", rates)
+	}
+
+	rates := tracker.Update(counterDatagram(2000, 1, HostNetCounters{PacketsIn: 20}))
+	if len(rates) != 1 || !rates[0].Restarted || rates[0].Fields != nil || rates[0].Rates != nil {
+		t.Fatalf("expected a restart, got %+v", rates)
+	}
+
+	rates = tracker.Update(counterDatagram(4000, 2, HostNetCounters{PacketsIn: 60}))
+	if len(rates) != 1 || rates[0].Restarted || rates[0].Fields["PacketsIn"] != 20 || rates[0].Wrapped != nil {
+		t.Fatalf("expected rates after the restart, got %+v", rates)
+	}
+
+	// the uptime wraps after 49.7 days
+	tracker.Update(counterDatagram(math.MaxUint32-999, 3, HostNetCounters{PacketsIn: 60}))
+	rates = tracker.Update(counterDatagram(1000, 4, HostNetCounters{PacketsIn: 260}))
+	if len(rates) != 1 || rates[0].Restarted || rates[0].Interval != 2*time.Second || rates[0].Fields["PacketsIn"] != 100 {
+		t.Fatalf("expected rates across an uptime wrap, got %+v", rates)
+	}
+}
+
+func TestCounterTrackerUntypedRecord(t *testing.T) {
+	tracker := NewCounterTracker()
+
+	tracker.Update(counterDatagram(1000, 1, VlanCounters{ID: 10, Octets: 100, UnicastPackets: 1}))
+	rates := tracker.Update(counterDatagram(3000, 2, VlanCounters{ID: 10, Octets: 300, UnicastPackets: 5}))
+
+	if len(rates) != 1 || rates[0].Rates != nil {
+		t.Fatalf("expected untyped rates, got %+v", rates)
+	}
+
+	if rates[0].Fields["Octets"] != 100 || rates[0].Fields["UnicastPackets"] != 2 {
+		t.Errorf("unexpected rates %v", rates[0].Fields)
+	}
+
+	if _, ok := rates[0].Fields["ID"]; ok {
+		t.Errorf("expected no rate of the VLAN ID, got %v", rates[0].Fields)
+	}
+}
+
+func TestCounterTrackerGauges(t *testing.T) {
+	tracker := NewCounterTracker()
+
+	tracker.Update(counterDatagram(1000, 1, EnergyCounters{Voltage: 230000, Current: 900, RealPower: 200, Energy: 1000}))
+	rates := tracker.Update(counterDatagram(3000, 2, EnergyCounters{Voltage: 229000, Current: 800, RealPower: 180, Energy: 1400}))
+
+	if len(rates) != 1 {
+		t.Fatalf("expected 1 rate, got %+v", rates)
+	}
+
+	if len(rates[0].Fields) != 2 || rates[0].Fields["Energy"] != 200 || rates[0].Fields["Errors"] != 0 {
+		t.Errorf("expected the rates of the energy and error counters only, got %v", rates[0].Fields)
+	}
+
+	if len(rates[0].Wrapped) != 0 {
+		t.Errorf("expected decreasing gauges not to wrap, got %v", rates[0].Wrapped)
+	}
+
+	// records of unknown types only have gauges
+	tracker.Update(counterDatagram(1000, 1, ProcessorCounters{CPU5s: 5000, FreeMemory: 100}))
+	rates = tracker.Update(counterDatagram(3000, 2, ProcessorCounters{CPU5s: 2000, FreeMemory: 50}))
+
+	if len(rates) != 0 {
+		t.Errorf("expected no rates of processor counters, got %+v", rates)
+	}
+}
+
+func TestCounterNames(t *testing.T) {
+	for recordType, names := range counterNames {
+		typ, ok := counterRecordJSONTypes[recordType]
+		if !ok {
+			t.Errorf("unknown record type %d", recordType)
+			continue
+		}
+
+		for _, name := range names {
+			f, ok := typ.FieldByName(name)
+			if !ok {
+				t.Errorf("%s has no field %s", typ.Name(), name)
+				continue
+			}
+
+			switch f.Type.Kind() {
+			case reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			default:
+				t.Errorf("%s.%s is not an unsigned integer", typ.Name(), name)
+			}
+		}
+	}
+}
@@ -14,6 +14,11 @@ type Datagram struct {
 	Uptime         uint32   `json:"uptime"`
 	NumSamples     uint32   `json:"numSamples"`
 	Samples        []Sample `json:"samples"`
+
+	// Errors holds the problems decoding skipped over: samples and
+	// records that did not decode, which are left out, and samples
+	// and records that did not use their full declared length.
+	Errors []*DecodeError `json:"-"`
 }
 
 func (d Datagram) String() string {
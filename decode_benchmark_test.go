@@ -1,6 +1,7 @@
 package sflow
 
 import (
+	"io/ioutil"
 	"os"
 	"testing"
 )
@@ -34,3 +35,21 @@ func BenchmarkCounterSample(b *testing.B) {
 
 	f.Close()
 }
+
+func BenchmarkPacketDecoderCounterSample(b *testing.B) {
+	buf, err := ioutil.ReadFile("_test/counter_sample.dump")
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	d := NewPacketDecoder()
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		dgram, err := d.Decode(buf)
+		if err != nil {
+			b.Fatal(err)
+		}
+		dgram.Release()
+	}
+}
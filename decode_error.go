@@ -0,0 +1,151 @@
+package sflow
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"strings"
+)
+
+// LengthError is the cause of a DecodeError for a sample or record
+// whose contents did not use its full declared length. Decoding
+// continues after the declared length.
+type LengthError struct {
+	// Length is the declared length.
+	Length uint32
+
+	// Used is the number of bytes decoded.
+	Used uint32
+}
+
+func (e *LengthError) Error() string {
+	return fmt.Sprintf("sflow: decoded %d bytes of declared length %d", e.Used, e.Length)
+}
+
+// DecodeError is returned when a datagram cannot be decoded. It tells
+// where decoding failed and wraps the cause, so that errors.Is and
+// errors.As see errors such as ErrUnknownSampleType,
+// records.ErrDecodingRecord or io.ErrUnexpectedEOF.
+type DecodeError struct {
+	// Agent is the agent address, nil if the datagram
+	// ended before it.
+	Agent net.IP
+
+	// Offset is the byte offset within the datagram of the failing
+	// record, of the failing sample if the failure is outside its
+	// records, or of the failing datagram header field.
+	Offset int64
+
+	// Sample is the index of the failing sample, -1 if
+	// the datagram header failed.
+	Sample int
+
+	// SampleType is the format of the failing sample, 0 if unknown.
+	SampleType uint32
+
+	// Record is the index of the failing record within the
+	// sample, -1 if the failure is outside the records.
+	Record int
+
+	// RecordType is the data format of the failing record.
+	RecordType uint32
+
+	// Err is the cause.
+	Err error
+}
+
+func (e *DecodeError) Error() string {
+	b := &strings.Builder{}
+
+	b.WriteString("sflow: decoding datagram")
+	if e.Agent != nil {
+		fmt.Fprintf(b, " from %s", e.Agent)
+	}
+
+	if e.Sample >= 0 {
+		fmt.Fprintf(b, ": sample %d", e.Sample)
+		if e.SampleType != 0 {
+			fmt.Fprintf(b, " (format %d)", e.SampleType)
+		}
+	}
+
+	if e.Record >= 0 {
+		fmt.Fprintf(b, ", record %d (format %d:%d)",
+			e.Record, e.RecordType>>12, e.RecordType&0xfff)
+	}
+
+	fmt.Fprintf(b, " at offset %d: %v", e.Offset, e.Err)
+
+	return b.String()
+}
+
+// Unwrap returns the cause of e.
+func (e *DecodeError) Unwrap() error {
+	return e.Err
+}
+
+// offset returns the current position of r.
+func offset(r io.Seeker) int64 {
+	n, _ := r.Seek(0, io.SeekCurrent)
+	return n
+}
+
+// recordError returns the error of record i of format
+// recordType, which starts at offset.
+func recordError(offset int64, i uint32, recordType uint32, err error) *DecodeError {
+	return &DecodeError{
+		Offset:     offset,
+		Sample:     -1,
+		Record:     int(i),
+		RecordType: recordType,
+		Err:        err,
+	}
+}
+
+// sampleError returns the error of a sample of format sampleType,
+// which starts at offset.
+func sampleError(offset int64, sampleType uint32, err error) *DecodeError {
+	e, ok := err.(*DecodeError)
+	if !ok {
+		e = &DecodeError{
+			Offset: offset,
+			Sample: -1,
+			Record: -1,
+			Err:    err,
+		}
+	}
+
+	e.SampleType = sampleType
+
+	return e
+}
+
+// newDecodeError returns the error of the datagram from agent starting
+// at start. Errors of samples keep the offset of the sample or record,
+// others get the current position of r.
+func newDecodeError(r io.Seeker, start int64, agent net.IP, sample int, err error) error {
+	e, ok := err.(*DecodeError)
+	if !ok {
+		e = &DecodeError{
+			Offset: offset(r),
+			Record: -1,
+			Err:    err,
+		}
+	}
+
+	e.locate(start, agent, sample)
+
+	return e
+}
+
+// locate sets the agent and sample index of e, and makes its offset
+// relative to the datagram starting at start.
+func (e *DecodeError) locate(start int64, agent net.IP, sample int) {
+	e.Offset -= start
+	e.Sample = sample
+
+	if agent != nil {
+		// the datagram is released along with its address
+		e.Agent = net.IP(append([]byte(nil), agent...))
+	}
+}
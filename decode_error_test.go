@@ -0,0 +1,238 @@
+package sflow
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/kanocz/sflow/records"
+)
+
+func TestDecodeErrorRecord(t *testing.T) {
+	buf := &bytes.Buffer{}
+
+	err := NewEncoder(net.IPv4(192, 0, 2, 1), 0, 1).Encode(buf, []Sample{&CounterSample{
+		Records: []records.Record{HostCPUCounters{NumCPU: 4}, HostNetCounters{PacketsIn: 1}},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b := buf.Bytes()
+
+	// the datagram header, sample header and the first record
+	recordOffset := int64(6*4+4) + 8 + 12 + 8 + int64(binary.Size(HostCPUCounters{}))
+
+	// declare a second record longer than its sample
+	binary.BigEndian.PutUint32(b[recordOffset+4:], uint32(binary.Size(HostNetCounters{}))+4)
+
+	dgram, err := NewPacketDecoder().Decode(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(dgram.Errors) != 1 {
+		t.Fatalf("expected 1 error, got %v", dgram.Errors)
+	}
+
+	e := dgram.Errors[0]
+	if !e.Agent.Equal(net.IPv4(192, 0, 2, 1)) || e.Sample != 0 || e.SampleType != TypeCounterSample ||
+		e.Record != 1 || e.RecordType != TypeHostNetCountersRecord || e.Offset != recordOffset {
+		t.Errorf("unexpected error %+v", e)
+	}
+
+	if !errors.Is(e, io.ErrUnexpectedEOF) {
+		t.Errorf("expected the cause to be io.ErrUnexpectedEOF, got %v", e.Err)
+	}
+
+	expected := "sflow: decoding datagram from 192.0.2.1: sample 0 (format 2), record 1 (format 0:2006) at offset 136: unexpected EOF"
+	if e.Error() != expected {
+		t.Errorf("expected %q, got %q", expected, e.Error())
+	}
+
+	s := dgram.Samples[0].(*CounterSample)
+	if len(s.Records) != 1 || s.Records[0].(HostCPUCounters).NumCPU != 4 {
+		t.Errorf("expected the first record to be kept, got %v", s.Records)
+	}
+}
+
+func TestDecodeErrorTruncated(t *testing.T) {
+	buf := &bytes.Buffer{}
+
+	err := NewEncoder(net.IPv4(192, 0, 2, 1), 0, 1).Encode(buf, []Sample{&CounterSample{
+		Records: []records.Record{HostCPUCounters{}},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b := buf.Bytes()
+
+	_, err = NewPacketDecoder().Decode(b[:len(b)-4])
+
+	var e *DecodeError
+	if !errors.As(err, &e) || !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Fatalf("expected a DecodeError for a truncated sample, got %v", err)
+	}
+
+	if e.Sample != 0 || e.SampleType != TypeCounterSample || e.Record != -1 || e.Offset != 6*4+4 {
+		t.Errorf("unexpected error %+v", e)
+	}
+}
+
+func TestDecodeLengthMismatch(t *testing.T) {
+	buf := &bytes.Buffer{}
+
+	err := NewEncoder(net.IPv4(192, 0, 2, 1), 0, 1).Encode(buf, []Sample{&CounterSample{
+		Records: []records.Record{HostCPUCounters{NumCPU: 4}, HostNetCounters{PacketsIn: 1}},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b := buf.Bytes()
+
+	sampleOffset := 6*4 + 4
+	recordOffset := sampleOffset + 8 + 12
+	cpuLength := binary.Size(HostCPUCounters{})
+
+	// pad the first record, and its sample, with 4 bytes
+	padded := append([]byte(nil), b[:recordOffset+8+cpuLength]...)
+	padded = append(padded, 0, 0, 0, 0)
+	padded = append(padded, b[recordOffset+8+cpuLength:]...)
+
+	binary.BigEndian.PutUint32(padded[sampleOffset+4:], binary.BigEndian.Uint32(padded[sampleOffset+4:])+4)
+	binary.BigEndian.PutUint32(padded[recordOffset+4:], uint32(cpuLength)+4)
+
+	dgram, err := NewPacketDecoder().Decode(padded)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := dgram.Samples[0].(*CounterSample)
+	if len(s.Records) != 2 || s.Records[0].(HostCPUCounters).NumCPU != 4 ||
+		s.Records[1].(HostNetCounters).PacketsIn != 1 {
+		t.Fatalf("expected both records, got %v", s.Records)
+	}
+
+	if len(dgram.Errors) != 1 {
+		t.Fatalf("expected 1 error, got %v", dgram.Errors)
+	}
+
+	var lengthErr *LengthError
+	e := dgram.Errors[0]
+	if !errors.As(e, &lengthErr) || lengthErr.Length != uint32(cpuLength)+4 || lengthErr.Used != uint32(cpuLength) {
+		t.Fatalf("expected a LengthError, got %v", e)
+	}
+
+	if e.Record != 0 || e.RecordType != TypeHostCPUCountersRecord || e.Offset != int64(recordOffset) {
+		t.Errorf("unexpected error %+v", e)
+	}
+}
+
+func TestDecodeEncodeHostSample(t *testing.T) {
+	f, err := os.Open("_test/host_sample.dump")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	dgram, err := NewDecoder(f).Decode()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(dgram.Errors) != 0 {
+		t.Fatalf("unexpected errors %v", dgram.Errors)
+	}
+
+	buf := &bytes.Buffer{}
+
+	err = NewEncoder(dgram.IpAddress, dgram.SubAgentId, dgram.SequenceNumber).Encode(buf, dgram.Samples)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	decoded, err := NewPacketDecoder().Decode(buf.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(decoded.Errors) != 0 {
+		t.Fatalf("unexpected errors %v", decoded.Errors)
+	}
+
+	if len(decoded.Samples) != len(dgram.Samples) {
+		t.Fatalf("expected %d samples, got %d", len(dgram.Samples), len(decoded.Samples))
+	}
+
+	for i := range dgram.Samples {
+		expected := dgram.Samples[i].(*CounterSample).Records
+		got := decoded.Samples[i].(*CounterSample).Records
+
+		if !reflect.DeepEqual(got, expected) {
+			t.Errorf("sample %d: expected records %v, got %v", i, expected, got)
+		}
+	}
+}
+
+func TestDecodeErrorHeader(t *testing.T) {
+	b := []byte{0, 0, 0, 5, 0, 0, 0, 1, 192, 0, 2, 1, 0, 0}
+
+	_, err := NewPacketDecoder().Decode(b)
+
+	var e *DecodeError
+	if !errors.As(err, &e) || !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Fatalf("expected a DecodeError for a truncated header, got %v", err)
+	}
+
+	if e.Sample != -1 || e.Record != -1 || !e.Agent.Equal(net.IPv4(192, 0, 2, 1)) {
+		t.Errorf("unexpected error %+v", e)
+	}
+
+	_, err = NewPacketDecoder().Decode([]byte{0, 0, 0, 4})
+	if !errors.Is(err, ErrUnsupportedDatagramVersion) {
+		t.Errorf("expected ErrUnsupportedDatagramVersion, got %v", err)
+	}
+}
+
+func TestDecodeErrorSample(t *testing.T) {
+	buf := &bytes.Buffer{}
+
+	err := NewEncoder(net.IPv4(192, 0, 2, 1), 0, 1).Encode(buf, []Sample{
+		&CounterSample{Records: []records.Record{HostCPUCounters{}}},
+		&CounterSample{Records: []records.Record{HostCPUCounters{}}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b := buf.Bytes()
+
+	// turn the second sample into an unknown type
+	sampleOffset := int64(6*4+4) + 8 + 12 + 8 + int64(binary.Size(HostCPUCounters{}))
+	b[sampleOffset+3] = 9
+
+	dgram, err := NewPacketDecoder().Decode(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(dgram.Samples) != 1 {
+		t.Errorf("expected the unknown sample to be skipped, got %d samples", len(dgram.Samples))
+	}
+
+	if len(dgram.Errors) != 1 || !errors.Is(dgram.Errors[0], ErrUnknownSampleType) {
+		t.Fatalf("expected an unknown sample type, got %v", dgram.Errors)
+	}
+
+	e := dgram.Errors[0]
+	if e.Sample != 1 || e.SampleType != 9 || e.Record != -1 || e.Offset != sampleOffset {
+		t.Errorf("unexpected error %+v", e)
+	}
+}
@@ -1,7 +1,10 @@
 package sflow
 
 import (
+	"fmt"
+	"io/ioutil"
 	"os"
+	"sync"
 	"testing"
 
 	"github.com/kanocz/sflow/records"
@@ -175,3 +178,50 @@ func TestDecodeFlow1(t *testing.T) {
 		t.Errorf("expected FrameLength to be 128, got %d", rec.HeaderSize)
 	}
 }
+
+func TestPacketDecoderConcurrentReuse(t *testing.T) {
+	b, err := ioutil.ReadFile("_test/counter_sample.dump")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	d := NewPacketDecoder()
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 8)
+
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			for j := 0; j < 100; j++ {
+				dgram, err := d.Decode(b)
+				if err != nil {
+					errs <- err
+					return
+				}
+
+				sample, ok := dgram.Samples[0].(*CounterSample)
+				if !ok || len(sample.Records) != 2 {
+					errs <- fmt.Errorf("unexpected sample %v", dgram.Samples[0])
+					return
+				}
+
+				if c, ok := sample.Records[1].(GenericInterfaceCounters); !ok || c.InOctets != 79282473 {
+					errs <- fmt.Errorf("unexpected record %v", sample.Records[1])
+					return
+				}
+
+				dgram.Release()
+			}
+		}()
+	}
+
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		t.Error(err)
+	}
+}
@@ -1,9 +1,12 @@
 package sflow
 
 import (
+	"bytes"
 	"encoding/binary"
 	"errors"
+	"fmt"
 	"io"
+	"sync"
 )
 
 const (
@@ -18,11 +21,20 @@ const (
 	// The value is set to maximum transmission unit (MTU), as the header of a network packet
 	// may not exceed the MTU.
 	MaximumHeaderLength = 1500
+
+	// MaximumSampleLength defines the maximum length acceptable for
+	// decoded samples. No sample can be longer than the largest
+	// datagram, which is limited like MaximumRecordLength.
+	MaximumSampleLength = 65536
 )
 
 var ErrUnsupportedDatagramVersion = errors.New("sflow: unsupported datagram version")
 
 type Decoder struct {
+	// Limits bound the resources decoding a datagram may use.
+	// Nil means DefaultLimits.
+	Limits *Limits
+
 	reader io.ReadSeeker
 }
 
@@ -37,22 +49,64 @@ func (d *Decoder) Use(r io.ReadSeeker) {
 }
 
 func (d *Decoder) Decode() (*Datagram, error) {
+	return decodeDatagram(d.reader, d.Limits)
+}
+
+var readerPool = sync.Pool{
+	New: func() interface{} { return &bytes.Reader{} },
+}
+
+// PacketDecoder decodes datagrams from byte slices, e.g. UDP payloads.
+// Unlike Decoder it holds no per-stream state and is safe for
+// concurrent use. Decoded datagrams may be handed back for reuse
+// with Datagram.Release.
+type PacketDecoder struct {
+	// Limits bound the resources decoding a datagram may use.
+	// Nil means DefaultLimits.
+	Limits *Limits
+}
+
+// NewPacketDecoder returns a new PacketDecoder.
+func NewPacketDecoder() *PacketDecoder {
+	return &PacketDecoder{}
+}
+
+// Decode decodes the datagram in b. The returned datagram does not
+// refer to b, so b may be reused as soon as Decode returns.
+func (d *PacketDecoder) Decode(b []byte) (*Datagram, error) {
+	r := readerPool.Get().(*bytes.Reader)
+	r.Reset(b)
+
+	dgram, err := decodeDatagram(r, d.Limits)
+
+	r.Reset(nil)
+	readerPool.Put(r)
+
+	return dgram, err
+}
+
+func decodeDatagram(r io.ReadSeeker, limits *Limits) (*Datagram, error) {
 	// Decode headers first
-	dgram := &Datagram{}
+	dgram := getDatagram()
 	var err error
 
-	err = binary.Read(d.reader, binary.BigEndian, &dgram.Version)
+	start := offset(r)
+
+	err = binary.Read(r, binary.BigEndian, &dgram.Version)
 	if err != nil {
-		return nil, err
+		dgram.Release()
+		return nil, newDecodeError(r, start, nil, -1, err)
 	}
 
 	if dgram.Version != 5 {
-		return nil, ErrUnsupportedDatagramVersion
+		dgram.Release()
+		return nil, newDecodeError(r, start, nil, -1, ErrUnsupportedDatagramVersion)
 	}
 
-	err = binary.Read(d.reader, binary.BigEndian, &dgram.IpVersion)
+	err = binary.Read(r, binary.BigEndian, &dgram.IpVersion)
 	if err != nil {
-		return nil, err
+		dgram.Release()
+		return nil, newDecodeError(r, start, nil, -1, err)
 	}
 
 	ipLen := 4
@@ -60,42 +114,75 @@ func (d *Decoder) Decode() (*Datagram, error) {
 		ipLen = 16
 	}
 
-	ipBuf := make([]byte, ipLen)
-	_, err = d.reader.Read(ipBuf)
+	// The address is handed out to the caller, so it may only
+	// reuse a pooled datagram's buffer, never a shared one.
+	ipBuf := dgram.IpAddress[:0]
+	if cap(ipBuf) < ipLen {
+		ipBuf = make([]byte, ipLen)
+	}
+	ipBuf = ipBuf[:ipLen]
+
+	_, err = r.Read(ipBuf)
 	if err != nil {
-		return nil, err
+		dgram.Release()
+		return nil, newDecodeError(r, start, nil, -1, err)
 	}
 
 	dgram.IpAddress = ipBuf
 
-	err = binary.Read(d.reader, binary.BigEndian, &dgram.SubAgentId)
+	err = binary.Read(r, binary.BigEndian, &dgram.SubAgentId)
 	if err != nil {
-		return nil, err
+		dgram.Release()
+		return nil, newDecodeError(r, start, dgram.IpAddress, -1, err)
 	}
 
-	err = binary.Read(d.reader, binary.BigEndian, &dgram.SequenceNumber)
+	err = binary.Read(r, binary.BigEndian, &dgram.SequenceNumber)
 	if err != nil {
-		return nil, err
+		dgram.Release()
+		return nil, newDecodeError(r, start, dgram.IpAddress, -1, err)
 	}
 
-	err = binary.Read(d.reader, binary.BigEndian, &dgram.Uptime)
+	err = binary.Read(r, binary.BigEndian, &dgram.Uptime)
 	if err != nil {
-		return nil, err
+		dgram.Release()
+		return nil, newDecodeError(r, start, dgram.IpAddress, -1, err)
 	}
 
-	err = binary.Read(d.reader, binary.BigEndian, &dgram.NumSamples)
+	err = binary.Read(r, binary.BigEndian, &dgram.NumSamples)
 	if err != nil {
+		dgram.Release()
+		return nil, newDecodeError(r, start, dgram.IpAddress, -1, err)
+	}
+
+	d := newDecodeState(limits)
+
+	if max := d.limits.MaxSamples; max > 0 && dgram.NumSamples > max {
+		err = fmt.Errorf("%w: %d samples, maximum %d", ErrLimitExceeded, dgram.NumSamples, max)
+		err = newDecodeError(r, start, dgram.IpAddress, -1, err)
+		dgram.Release()
 		return nil, err
 	}
 
-	for i := dgram.NumSamples; i > 0; i-- {
-		sample, err := decodeSample(d.reader)
+	for i := uint32(0); i < dgram.NumSamples; i++ {
+		skipped := len(d.errs)
+
+		sample, err := decodeSample(r, d)
 		if err != nil {
+			err = newDecodeError(r, start, dgram.IpAddress, int(i), err)
+			dgram.Release()
 			return nil, err
 		}
 
-		dgram.Samples = append(dgram.Samples, sample)
+		for _, e := range d.errs[skipped:] {
+			e.locate(start, dgram.IpAddress, int(i))
+		}
+
+		if sample != nil {
+			dgram.Samples = append(dgram.Samples, sample)
+		}
 	}
 
+	dgram.Errors = d.errs
+
 	return dgram, nil
 }
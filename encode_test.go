@@ -2,8 +2,14 @@ package sflow
 
 import (
 	"bytes"
+	"io/ioutil"
+	"net"
 	"os"
+	"sync"
 	"testing"
+	"time"
+
+	"github.com/kanocz/sflow/records"
 )
 
 func TestDecodeAndEncodeGenericEthernetCounterDatagram(t *testing.T) {
@@ -92,3 +98,173 @@ func TestDecodeAndEncodeGenericEthernetCounterDatagram(t *testing.T) {
 		t.Errorf("expected\n%#v, got\n%#v", expectedGenericInterfaceCounters, genericInterfaceCounters)
 	}
 }
+
+func TestEncodeBatchSplitsDatagrams(t *testing.T) {
+	f, err := os.Open("_test/counter_sample.dump")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	d := NewDecoder(f)
+
+	dgram, err := d.Decode()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var samples []Sample
+	for i := 0; i < 20; i++ {
+		samples = append(samples, dgram.Samples[0])
+	}
+
+	enc := NewEncoder(dgram.IpAddress, dgram.SubAgentId, 100)
+
+	datagrams, err := enc.EncodeBatch(samples)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(datagrams) < 2 {
+		t.Fatalf("expected samples to be split across datagrams, got %d datagram(s)", len(datagrams))
+	}
+
+	total := 0
+	for i, b := range datagrams {
+		if len(b) > DefaultMaxDatagramSize {
+			t.Errorf("datagram %d is %d bytes, more than %d", i, len(b), DefaultMaxDatagramSize)
+		}
+
+		d.Use(bytes.NewReader(b))
+
+		decoded, err := d.Decode()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if decoded.SequenceNumber != uint32(100+i) {
+			t.Errorf("expected sequence number %d, got %d", 100+i, decoded.SequenceNumber)
+		}
+
+		total += len(decoded.Samples)
+	}
+
+	if total != len(samples) {
+		t.Errorf("expected %d samples in total, got %d", len(samples), total)
+	}
+
+	enc.MaxDatagramSize = 64
+	if _, err = enc.EncodeBatch(samples); err != ErrSampleTooLarge {
+		t.Errorf("expected ErrSampleTooLarge, got %v", err)
+	}
+}
+
+func TestEncodeBatchExactFit(t *testing.T) {
+	sample := &CounterSample{Records: []records.Record{HostCPUCounters{}}}
+
+	sampleBuf := &bytes.Buffer{}
+	if err := sample.encode(sampleBuf); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, ip := range []net.IP{net.IPv4(192, 0, 2, 1), net.ParseIP("2001:db8::1")} {
+		enc := NewEncoder(ip, 0, 1)
+
+		single := &bytes.Buffer{}
+		if err := enc.Encode(single, []Sample{sample}); err != nil {
+			t.Fatal(err)
+		}
+
+		// the header plus the sample is exactly the maximum size
+		enc.MaxDatagramSize = single.Len()
+
+		datagrams, err := enc.EncodeBatch([]Sample{sample, sample})
+		if err != nil {
+			t.Fatalf("%v: %v", ip, err)
+		}
+
+		if len(datagrams) != 2 {
+			t.Fatalf("%v: expected 2 datagrams, got %d", ip, len(datagrams))
+		}
+
+		for i, b := range datagrams {
+			if len(b) != enc.MaxDatagramSize {
+				t.Errorf("%v: datagram %d is %d bytes, expected %d", ip, i, len(b), enc.MaxDatagramSize)
+			}
+		}
+
+		if err = enc.CheckSample(sample); err != nil {
+			t.Errorf("%v: expected the sample to fit, got %v", ip, err)
+		}
+
+		enc.MaxDatagramSize--
+		if _, err = enc.EncodeBatch([]Sample{sample}); err != ErrSampleTooLarge {
+			t.Errorf("%v: expected ErrSampleTooLarge, got %v", ip, err)
+		}
+
+		if err = enc.CheckSample(sample); err != ErrSampleTooLarge {
+			t.Errorf("%v: expected CheckSample to return ErrSampleTooLarge, got %v", ip, err)
+		}
+	}
+}
+
+func TestEncoderUptimeAndSequenceNumbers(t *testing.T) {
+	start := time.Date(2016, 1, 1, 0, 0, 0, 0, time.UTC)
+	now := start.Add(90 * time.Second)
+
+	enc := NewEncoder(net.ParseIP("192.0.2.1"), 0, 10)
+	enc.SetStart(start)
+	enc.SetClock(func() time.Time { return now })
+
+	if enc.Uptime() != 90000 {
+		t.Errorf("expected uptime 90000, got %d", enc.Uptime())
+	}
+
+	samples := []Sample{&CounterSample{Records: []records.Record{HostCPUCounters{}}}}
+
+	sub := enc.SubAgent(7)
+	if enc.SubAgent(7) != sub {
+		t.Error("expected SubAgent to return the same encoder for the same id")
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				enc.Encode(ioutil.Discard, samples)
+				sub.Encode(ioutil.Discard, samples)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if enc.SequenceNumber() != 410 {
+		t.Errorf("expected sequence number 410, got %d", enc.SequenceNumber())
+	}
+
+	if sub.SequenceNumber() != 400 {
+		t.Errorf("expected sub-agent sequence number 400, got %d", sub.SequenceNumber())
+	}
+
+	buf := &bytes.Buffer{}
+	if err := sub.Encode(buf, samples); err != nil {
+		t.Fatal(err)
+	}
+
+	dgram, err := NewDecoder(bytes.NewReader(buf.Bytes())).Decode()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if dgram.SubAgentId != 7 || dgram.SequenceNumber != 400 || dgram.Uptime != 90000 {
+		t.Errorf("unexpected datagram header: %v", dgram)
+	}
+
+	enc.Reset()
+
+	if enc.Uptime() != 0 || enc.SequenceNumber() != 0 || sub.SequenceNumber() != 0 {
+		t.Errorf("expected Reset to clear uptime and sequence numbers, got %d, %d and %d",
+			enc.Uptime(), enc.SequenceNumber(), sub.SequenceNumber())
+	}
+}
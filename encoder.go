@@ -1,32 +1,151 @@
 package sflow
 
 import (
+	"bytes"
 	"encoding/binary"
 	"errors"
 	"io"
 	"net"
+	"sync"
+	"time"
 )
 
+// DefaultMaxDatagramSize is the datagram size limit used by EncodeBatch
+// when Encoder.MaxDatagramSize is not set. It leaves room for IP and UDP
+// headers within a 1500 byte Ethernet MTU.
+const DefaultMaxDatagramSize = 1400
+
 var (
 	ErrNoSamplesProvided = errors.New("sflow: no samples provided for encoding")
+	ErrSampleTooLarge    = errors.New("sflow: sample does not fit into a datagram")
 )
 
+// Encoder encodes sFlow v5 datagrams for one agent address. It keeps
+// the datagram sequence number and reports the time since its start
+// as the agent uptime. An Encoder is safe for concurrent use.
 type Encoder struct {
-	ip          net.IP
-	subAgentId  uint32
+	ip         net.IP
+	subAgentId uint32
+	clock      *encoderClock
+
+	// MaxDatagramSize is the maximum size in bytes of a datagram
+	// produced by EncodeBatch. Zero means DefaultMaxDatagramSize.
+	MaxDatagramSize int
+
+	mu          sync.Mutex
 	sequenceNum uint32
-	Uptime      uint32
+	subAgents   map[uint32]*Encoder
+}
+
+// encoderClock is the uptime source shared by an Encoder
+// and its sub-agents.
+type encoderClock struct {
+	mu    sync.Mutex
+	now   func() time.Time
+	start time.Time
+}
+
+func (c *encoderClock) uptime() uint32 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return uint32(c.now().Sub(c.start) / time.Millisecond)
 }
 
-// NewEncoder returns a new sFlow encoder.
+// NewEncoder returns a new sFlow encoder. Its uptime starts now.
 func NewEncoder(source net.IP, subAgentId uint32, initialSequenceNumber uint32) *Encoder {
 	return &Encoder{
-		ip:          source,
-		subAgentId:  subAgentId,
+		ip:         source,
+		subAgentId: subAgentId,
+		clock: &encoderClock{
+			now:   time.Now,
+			start: time.Now(),
+		},
 		sequenceNum: initialSequenceNumber,
 	}
 }
 
+// SetStart sets the time the agent started, from which uptime is measured.
+func (e *Encoder) SetStart(start time.Time) {
+	e.clock.mu.Lock()
+	e.clock.start = start
+	e.clock.mu.Unlock()
+}
+
+// SetClock sets the function used to read the current time.
+// It defaults to time.Now.
+func (e *Encoder) SetClock(now func() time.Time) {
+	e.clock.mu.Lock()
+	e.clock.now = now
+	e.clock.mu.Unlock()
+}
+
+// Uptime returns the agent uptime in milliseconds.
+func (e *Encoder) Uptime() uint32 {
+	return e.clock.uptime()
+}
+
+// SequenceNumber returns the sequence number of the next datagram.
+func (e *Encoder) SequenceNumber() uint32 {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	return e.sequenceNum
+}
+
+// Reset restarts the agent: uptime is measured from now and the sequence
+// numbers of e and its sub-agents start again at 0, which tells
+// collectors that the agent has restarted.
+func (e *Encoder) Reset() {
+	e.clock.mu.Lock()
+	e.clock.start = e.clock.now()
+	e.clock.mu.Unlock()
+
+	e.mu.Lock()
+	e.sequenceNum = 0
+	subAgents := make([]*Encoder, 0, len(e.subAgents))
+	for _, sub := range e.subAgents {
+		subAgents = append(subAgents, sub)
+	}
+	e.mu.Unlock()
+
+	for _, sub := range subAgents {
+		sub.mu.Lock()
+		sub.sequenceNum = 0
+		sub.mu.Unlock()
+	}
+}
+
+// SubAgent returns the encoder for sub-agent id of the same agent. It
+// shares e's address and uptime but has its own sequence numbers.
+// Calling SubAgent again with the same id returns the same encoder.
+func (e *Encoder) SubAgent(id uint32) *Encoder {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if id == e.subAgentId {
+		return e
+	}
+
+	if sub, ok := e.subAgents[id]; ok {
+		return sub
+	}
+
+	sub := &Encoder{
+		ip:              e.ip,
+		subAgentId:      id,
+		clock:           e.clock,
+		MaxDatagramSize: e.MaxDatagramSize,
+	}
+
+	if e.subAgents == nil {
+		e.subAgents = map[uint32]*Encoder{}
+	}
+	e.subAgents[id] = sub
+
+	return sub
+}
+
 // Encode encodes an sFlow v5 datagram with the given samples and
 // writes the packet to w.
 func (e *Encoder) Encode(w io.Writer, samples []Sample) error {
@@ -34,6 +153,126 @@ func (e *Encoder) Encode(w io.Writer, samples []Sample) error {
 		return ErrNoSamplesProvided
 	}
 
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	err := e.writeHeader(w, uint32(len(samples)))
+	if err != nil {
+		return err
+	}
+
+	for _, sample := range samples {
+		err = sample.encode(w)
+		if err != nil {
+			return err
+		}
+	}
+
+	e.sequenceNum++
+
+	return nil
+}
+
+// EncodeBatch encodes the given samples into as many sFlow v5 datagrams
+// as needed to keep each one within MaxDatagramSize bytes. Samples keep
+// their order, and every datagram gets its own sequence number.
+func (e *Encoder) EncodeBatch(samples []Sample) ([][]byte, error) {
+	if len(samples) == 0 {
+		return nil, ErrNoSamplesProvided
+	}
+
+	maxSize := e.maxDatagramSize()
+	headerSize := e.headerSize()
+
+	// Encode every sample up front so we know its size.
+	encoded := make([][]byte, 0, len(samples))
+	for _, sample := range samples {
+		b, err := e.encodeSample(sample)
+		if err != nil {
+			return nil, err
+		}
+
+		encoded = append(encoded, b)
+	}
+
+	var datagrams [][]byte
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	for len(encoded) > 0 {
+		n, size := 0, headerSize
+		for n < len(encoded) && size+len(encoded[n]) <= maxSize {
+			size += len(encoded[n])
+			n++
+		}
+
+		buf := bytes.NewBuffer(make([]byte, 0, size))
+
+		err := e.writeHeader(buf, uint32(n))
+		if err != nil {
+			return nil, err
+		}
+
+		for _, sample := range encoded[:n] {
+			buf.Write(sample)
+		}
+
+		e.sequenceNum++
+
+		datagrams = append(datagrams, buf.Bytes())
+		encoded = encoded[n:]
+	}
+
+	return datagrams, nil
+}
+
+// CheckSample returns the error EncodeBatch fails with because of
+// sample: ErrSampleTooLarge if it does not fit into a datagram on its
+// own, or the error encoding it. It returns nil for samples EncodeBatch
+// can encode.
+func (e *Encoder) CheckSample(sample Sample) error {
+	_, err := e.encodeSample(sample)
+	return err
+}
+
+// encodeSample encodes sample, which must fit into a datagram.
+func (e *Encoder) encodeSample(sample Sample) ([]byte, error) {
+	buf := &bytes.Buffer{}
+
+	err := sample.encode(buf)
+	if err != nil {
+		return nil, err
+	}
+
+	if e.headerSize()+buf.Len() > e.maxDatagramSize() {
+		return nil, ErrSampleTooLarge
+	}
+
+	return buf.Bytes(), nil
+}
+
+// maxDatagramSize returns the size limit of datagrams of EncodeBatch.
+func (e *Encoder) maxDatagramSize() int {
+	if e.MaxDatagramSize <= 0 {
+		return DefaultMaxDatagramSize
+	}
+
+	return e.MaxDatagramSize
+}
+
+// headerSize returns the encoded size of the datagram header.
+func (e *Encoder) headerSize() int {
+	if e.ip.To4() != nil {
+		return 6*4 + net.IPv4len
+	}
+
+	return 6*4 + net.IPv6len
+}
+
+// writeHeader writes the datagram header for numSamples samples
+// using the current sequence number. e.mu must be held.
+func (e *Encoder) writeHeader(w io.Writer, numSamples uint32) error {
 	var err error
 
 	// sFlow v5
@@ -70,24 +309,10 @@ func (e *Encoder) Encode(w io.Writer, samples []Sample) error {
 		return err
 	}
 
-	err = binary.Write(w, binary.BigEndian, e.Uptime)
-	if err != nil {
-		return err
-	}
-
-	err = binary.Write(w, binary.BigEndian, uint32(len(samples)))
+	err = binary.Write(w, binary.BigEndian, e.clock.uptime())
 	if err != nil {
 		return err
 	}
 
-	for _, sample := range samples {
-		err = sample.encode(w)
-		if err != nil {
-			return err
-		}
-	}
-
-	e.sequenceNum++
-
-	return nil
+	return binary.Write(w, binary.BigEndian, numSamples)
 }
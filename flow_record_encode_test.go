@@ -2,6 +2,9 @@ package sflow
 
 import (
 	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
 	"reflect"
 	"testing"
 
@@ -61,3 +64,36 @@ func TestEncodeDecodeRawPacketFlowRecord(t *testing.T) {
 		t.Errorf("expected\n%+#v\n, got\n%+#v", rec, decoded)
 	}
 }
+
+func TestUnmarshalRawPacketFlowUndecodedProtocol(t *testing.T) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+
+	stdout := os.Stdout
+	os.Stdout = w
+	defer func() { os.Stdout = stdout }()
+
+	rec := records.RawPacketFlow{}
+	err = json.Unmarshal([]byte(`{"protocol":7,"frameLength":64,"headerSize":16,"header":"/wMAIUUAADwAAEAAQAYAAA=="}`), &rec)
+	os.Stdout = stdout
+	w.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	out, err := ioutil.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(out) != 0 {
+		t.Errorf("expected no output, got %q", out)
+	}
+
+	if rec.Protocol != records.HeaderProtocolPPP || len(rec.Header) != 16 || len(rec.DecodedHeader) != 0 {
+		t.Errorf("unexpected record %+v", rec)
+	}
+}
@@ -3,7 +3,6 @@ package sflow
 import (
 	"bytes"
 	"encoding/binary"
-	"errors"
 	"fmt"
 	"io"
 
@@ -11,16 +10,16 @@ import (
 )
 
 type FlowSample struct {
-	SequenceNum      uint32
-	SourceIdType     byte
-	SourceIdIndexVal uint32 // NOTE: this is 3 bytes in the datagram
-	SamplingRate     uint32
-	SamplePool       uint32
-	Drops            uint32
-	Input            uint32
-	Output           uint32
+	SequenceNum      uint32 `json:"sequenceNum"`
+	SourceIdType     byte   `json:"sourceIdType"`
+	SourceIdIndexVal uint32 `json:"sourceIdIndexVal"` // NOTE: this is 3 bytes in the datagram
+	SamplingRate     uint32 `json:"samplingRate"`
+	SamplePool       uint32 `json:"samplePool"`
+	Drops            uint32 `json:"drops"`
+	Input            uint32 `json:"input"`
+	Output           uint32 `json:"output"`
 	numRecords       uint32
-	Records          []records.Record
+	Records          []records.Record `json:"records"`
 }
 
 func (s FlowSample) String() string {
@@ -38,8 +37,8 @@ func (s FlowSample) GetRecords() []records.Record {
 	return s.Records
 }
 
-func decodeFlowSample(r io.ReadSeeker) (Sample, error) {
-	s := &FlowSample{}
+func decodeFlowSample(r io.ReadSeeker, d *decodeState) (Sample, error) {
+	s := getFlowSample()
 
 	var err error
 
@@ -54,18 +53,14 @@ func decodeFlowSample(r io.ReadSeeker) (Sample, error) {
 	}
 
 	var srcIdIndexVal [3]byte
-	n, err := r.Read(srcIdIndexVal[:])
+	_, err = io.ReadFull(r, srcIdIndexVal[:])
 	if err != nil {
 		return nil, err
 	}
 
-	if n != 3 {
-		return nil, errors.New("sflow: counter sample decoding error")
-	}
-
 	s.SourceIdIndexVal = uint32(srcIdIndexVal[2]) |
-		uint32(srcIdIndexVal[1]<<8) |
-		uint32(srcIdIndexVal[0]<<16)
+		uint32(srcIdIndexVal[1])<<8 |
+		uint32(srcIdIndexVal[0])<<16
 
 	err = binary.Read(r, binary.BigEndian, &s.SamplingRate)
 	if err != nil {
@@ -97,37 +92,19 @@ func decodeFlowSample(r io.ReadSeeker) (Sample, error) {
 		return nil, err
 	}
 
-	for i := uint32(0); i < s.numRecords; i++ {
-		format, length := uint32(0), uint32(0)
-
-		err = binary.Read(r, binary.BigEndian, &format)
-		if err != nil {
-			return nil, err
-		}
-
-		err = binary.Read(r, binary.BigEndian, &length)
-		if err != nil {
-			return nil, err
-		}
-
-		var rec records.Record
-		//fmt.Printf("sflow: Decoding record type %d with length %d\n", format, length)
-
-		if rec, err = records.DecodeFlow(r, format); err != nil {
-			//return nil, err
-			_, err := r.Seek(int64(length), 1)
-			if err != nil {
-				return nil, err
-			}
-			continue
-		}
-
-		s.Records = append(s.Records, rec)
+	s.Records, err = decodeRecords(r, s.numRecords, s.Records, d, decodeFlowRecord)
+	if err != nil {
+		return nil, err
 	}
 
 	return s, nil
 }
 
+// decodeFlowRecord decodes a flow record of the given format.
+func decodeFlowRecord(r io.Reader, format, length uint32, a *records.Allocation) (records.Record, error) {
+	return records.DecodeFlowLimited(r, format, a)
+}
+
 func (s FlowSample) encode(w io.Writer) error {
 	var err error
 
@@ -160,7 +137,7 @@ func (s FlowSample) encode(w io.Writer) error {
 		return err
 	}
 	err = binary.Write(w, binary.BigEndian,
-		uint32(s.SourceIdType)|s.SourceIdIndexVal<<24)
+		uint32(s.SourceIdType)<<24|s.SourceIdIndexVal&0xffffff)
 	if err != nil {
 		return err
 	}
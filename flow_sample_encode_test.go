@@ -50,7 +50,7 @@ func TestDecodeEncodeAndDecodeFlowSample(t *testing.T) {
 	buf.Read(skip[:])
 
 	// bytes.Buffer is not an io.ReadSeeker. bytes.Reader is.
-	decodedSample, err := decodeFlowSample(bytes.NewReader(buf.Bytes()))
+	decodedSample, err := decodeFlowSample(bytes.NewReader(buf.Bytes()), newDecodeState(nil))
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -85,3 +85,32 @@ func TestDecodeEncodeAndDecodeFlowSample(t *testing.T) {
 		t.Errorf("expected FrameLength to be 128, got %d", rec.HeaderSize)
 	}
 }
+
+func TestEncodeDecodeFlowSampleSourceId(t *testing.T) {
+	sample := &FlowSample{
+		SequenceNum:      1,
+		SourceIdType:     2,
+		SourceIdIndexVal: 0x123456,
+	}
+
+	buf := &bytes.Buffer{}
+
+	err := sample.encode(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// We need to skip the first 8 bytes. That's the header.
+	var skip [8]byte
+	buf.Read(skip[:])
+
+	decodedSample, err := decodeFlowSample(bytes.NewReader(buf.Bytes()), newDecodeState(nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	decoded := decodedSample.(*FlowSample)
+	if decoded.SourceIdType != 2 || decoded.SourceIdIndexVal != 0x123456 {
+		t.Errorf("expected source id 2:0x123456, got %d:%#x", decoded.SourceIdType, decoded.SourceIdIndexVal)
+	}
+}
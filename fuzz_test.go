@@ -0,0 +1,189 @@
+package sflow
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/kanocz/sflow/records"
+)
+
+// fuzzDumps returns the datagrams of the _test dumps.
+func fuzzDumps(f *testing.F) [][]byte {
+	names, err := filepath.Glob("_test/*.dump")
+	if err != nil {
+		f.Fatal(err)
+	}
+
+	var dumps [][]byte
+	for _, name := range names {
+		b, err := ioutil.ReadFile(name)
+		if err != nil {
+			f.Fatal(err)
+		}
+
+		dumps = append(dumps, b)
+	}
+
+	return dumps
+}
+
+// fuzzRecord is the format and body of an encoded record.
+type fuzzRecord struct {
+	format uint32
+	body   []byte
+}
+
+// fuzzRecords returns the records of the samples of format sampleType
+// in the _test dumps. It walks the encoding without decoding records.
+func fuzzRecords(f *testing.F, sampleType uint32) []fuzzRecord {
+	var recs []fuzzRecord
+
+	for _, b := range fuzzDumps(f) {
+		ipLen := 4
+		if binary.BigEndian.Uint32(b[4:]) == 2 {
+			ipLen = 16
+		}
+
+		b = b[8+ipLen+3*4:]
+		numSamples := binary.BigEndian.Uint32(b)
+		b = b[4:]
+
+		for i := uint32(0); i < numSamples; i++ {
+			format, length := binary.BigEndian.Uint32(b), binary.BigEndian.Uint32(b[4:])
+			sample := b[8 : 8+length]
+			b = b[8+length:]
+
+			if format != sampleType {
+				continue
+			}
+
+			// skip the sample fields up to the number of records
+			if format == TypeFlowSample {
+				sample = sample[7*4:]
+			} else {
+				sample = sample[2*4:]
+			}
+
+			numRecords := binary.BigEndian.Uint32(sample)
+			sample = sample[4:]
+
+			for j := uint32(0); j < numRecords; j++ {
+				format, length := binary.BigEndian.Uint32(sample), binary.BigEndian.Uint32(sample[4:])
+				recs = append(recs, fuzzRecord{format: format, body: sample[8 : 8+length]})
+				sample = sample[8+length:]
+			}
+		}
+	}
+
+	return recs
+}
+
+func FuzzDecode(f *testing.F) {
+	for _, b := range fuzzDumps(f) {
+		f.Add(b)
+	}
+
+	f.Fuzz(func(t *testing.T, b []byte) {
+		dgram, err := NewPacketDecoder().Decode(b)
+		if err != nil {
+			return
+		}
+
+		if len(dgram.Samples) > int(dgram.NumSamples) {
+			t.Fatalf("decoded %d samples of %d", len(dgram.Samples), dgram.NumSamples)
+		}
+
+		dgram.Release()
+	})
+}
+
+// fuzzEncode encodes the samples of dgram at a fixed uptime.
+func fuzzEncode(dgram *Datagram) ([]byte, error) {
+	e := NewEncoder(dgram.IpAddress, dgram.SubAgentId, dgram.SequenceNumber)
+
+	start := time.Unix(0, 0)
+	e.SetStart(start)
+	e.SetClock(func() time.Time { return start })
+
+	buf := &bytes.Buffer{}
+	err := e.Encode(buf, dgram.Samples)
+
+	return buf.Bytes(), err
+}
+
+// FuzzDecodeRoundTrip checks that whatever decodes encodes into a
+// datagram that decodes without errors into the same datagram.
+func FuzzDecodeRoundTrip(f *testing.F) {
+	for _, b := range fuzzDumps(f) {
+		f.Add(b)
+	}
+
+	f.Fuzz(func(t *testing.T, b []byte) {
+		dgram, err := NewPacketDecoder().Decode(b)
+		if err != nil || len(dgram.Samples) == 0 {
+			return
+		}
+
+		encoded, err := fuzzEncode(dgram)
+		if err != nil {
+			return
+		}
+
+		decoded, err := NewPacketDecoder().Decode(encoded)
+		if err != nil {
+			t.Fatalf("decoding the encoded datagram: %v", err)
+		}
+
+		if len(decoded.Errors) != 0 {
+			t.Fatalf("decoding the encoded datagram: %v", decoded.Errors)
+		}
+
+		reencoded, err := fuzzEncode(decoded)
+		if err != nil {
+			t.Fatalf("encoding the decoded datagram: %v", err)
+		}
+
+		if !bytes.Equal(reencoded, encoded) {
+			t.Fatalf("expected the datagram\n%x\nto encode again into itself, got\n%x", encoded, reencoded)
+		}
+	})
+}
+
+func FuzzDecodeFlow(f *testing.F) {
+	for _, rec := range fuzzRecords(f, TypeFlowSample) {
+		f.Add(rec.format, rec.body)
+	}
+
+	f.Fuzz(func(t *testing.T, format uint32, b []byte) {
+		records.DecodeFlow(bytes.NewReader(b), format)
+	})
+}
+
+func FuzzDecodeCounter(f *testing.F) {
+	for _, rec := range fuzzRecords(f, TypeCounterSample) {
+		f.Add(rec.format, rec.body)
+	}
+
+	f.Fuzz(func(t *testing.T, format uint32, b []byte) {
+		records.DecodeCounter(bytes.NewReader(b), format)
+
+		// and the counter records of this package
+		decodeCounterRecord(bytes.NewReader(b), format, uint32(len(b)), nil)
+	})
+}
+
+func FuzzDecodeRawPacketFlow(f *testing.F) {
+	for _, rec := range fuzzRecords(f, TypeFlowSample) {
+		if rec.format == records.TypeRawPacketFlowRecord {
+			f.Add(rec.body)
+		}
+	}
+
+	f.Fuzz(func(t *testing.T, b []byte) {
+		records.DecodeRawPacketFlow(bytes.NewReader(b))
+	})
+}
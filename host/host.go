@@ -0,0 +1,444 @@
+// Package host builds sFlow host counter records from the Linux /proc
+// and /sys file systems, in the manner of hsflowd.
+package host
+
+import (
+	"bufio"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/kanocz/sflow"
+	"github.com/kanocz/sflow/records"
+)
+
+// userHZ is the kernel clock tick rate used in /proc/stat.
+const userHZ = 100
+
+// Source reads host counters. The zero value reads /proc and /sys.
+type Source struct {
+	// ProcPath and SysPath locate the proc and sysfs mounts.
+	// Empty means "/proc" and "/sys".
+	ProcPath string
+	SysPath  string
+
+	// statfs replaces the statfs of the file systems in tests.
+	statfs func(path string) (total, free uint64, err error)
+}
+
+// New returns a Source reading the host's /proc and /sys.
+func New() *Source {
+	return &Source{}
+}
+
+// Counters returns every host counter record that could be read. Its
+// signature matches agent.CounterSource.
+func (s *Source) Counters() []records.Record {
+	var recs []records.Record
+
+	if c, err := s.CPU(); err == nil {
+		recs = append(recs, c)
+	}
+	if c, err := s.Memory(); err == nil {
+		recs = append(recs, c)
+	}
+	if c, err := s.Disk(); err == nil {
+		recs = append(recs, c)
+	}
+	if c, err := s.Net(); err == nil {
+		recs = append(recs, c)
+	}
+
+	return recs
+}
+
+// CPU returns a host CPU counters record read from /proc/loadavg,
+// /proc/stat, /proc/uptime and /proc/cpuinfo.
+func (s *Source) CPU() (sflow.HostCPUCounters, error) {
+	c := sflow.HostCPUCounters{}
+
+	// 0.10 0.20 0.30 2/345 6789
+	fields, err := s.readFields(s.proc("loadavg"))
+	if err != nil {
+		return c, err
+	}
+	if len(fields) > 0 && len(fields[0]) >= 4 {
+		c.Load1m = parseFloat32(fields[0][0])
+		c.Load5m = parseFloat32(fields[0][1])
+		c.Load15m = parseFloat32(fields[0][2])
+
+		procs := strings.SplitN(fields[0][3], "/", 2)
+		c.ProcessesRunning = parseUint32(procs[0])
+		if len(procs) == 2 {
+			c.ProcessesTotal = parseUint32(procs[1])
+		}
+	}
+
+	fields, err = s.readFields(s.proc("stat"))
+	if err != nil {
+		return c, err
+	}
+	for _, line := range fields {
+		switch {
+		case line[0] == "cpu":
+			times := make([]uint32, 10)
+			for i := range times {
+				if i+1 < len(line) {
+					times[i] = uint32(parseUint64(line[i+1]) * 1000 / userHZ)
+				}
+			}
+			c.CPUUser = times[0]
+			c.CPUNice = times[1]
+			c.CPUSys = times[2]
+			c.CPUIdle = times[3]
+			c.CPUWio = times[4]
+			c.CPUIntr = times[5]
+			c.CPUSoftIntr = times[6]
+			c.CPUSteal = times[7]
+			c.CPUGuest = times[8]
+			c.CPUGuestNice = times[9]
+		case strings.HasPrefix(line[0], "cpu"):
+			c.NumCPU++
+		case line[0] == "intr" && len(line) > 1:
+			c.Interrupts = uint32(parseUint64(line[1]))
+		case line[0] == "ctxt" && len(line) > 1:
+			c.ContextSwitches = uint32(parseUint64(line[1]))
+		}
+	}
+
+	fields, err = s.readFields(s.proc("uptime"))
+	if err == nil && len(fields) > 0 {
+		c.Uptime = uint32(parseFloat32(fields[0][0]))
+	}
+
+	// cpuinfo uses "key : value" lines, so it is split by hand.
+	lines, err := s.readLines(s.proc("cpuinfo"))
+	if err == nil {
+		for _, line := range lines {
+			kv := strings.SplitN(line, ":", 2)
+			if len(kv) == 2 && strings.TrimSpace(kv[0]) == "cpu MHz" {
+				c.SpeedCPU = uint32(parseFloat32(strings.TrimSpace(kv[1])))
+				break
+			}
+		}
+	}
+
+	return c, nil
+}
+
+// Memory returns a host memory counters record read from
+// /proc/meminfo and /proc/vmstat.
+func (s *Source) Memory() (sflow.HostMemoryCounters, error) {
+	c := sflow.HostMemoryCounters{}
+
+	fields, err := s.readFields(s.proc("meminfo"))
+	if err != nil {
+		return c, err
+	}
+	for _, line := range fields {
+		if len(line) < 2 {
+			continue
+		}
+
+		// Values are in kB
+		v := parseUint64(line[1]) * 1024
+
+		switch line[0] {
+		case "MemTotal:":
+			c.Total = v
+		case "MemFree:":
+			c.Free = v
+		case "Shmem:":
+			c.Shared = v
+		case "Buffers:":
+			c.Buffers = v
+		case "Cached:":
+			c.Cached = v
+		case "SwapTotal:":
+			c.SwapTotal = v
+		case "SwapFree:":
+			c.SwapFree = v
+		}
+	}
+
+	fields, err = s.readFields(s.proc("vmstat"))
+	if err != nil {
+		return c, err
+	}
+	for _, line := range fields {
+		if len(line) < 2 {
+			continue
+		}
+
+		switch line[0] {
+		case "pgpgin":
+			c.PageIn = uint32(parseUint64(line[1]))
+		case "pgpgout":
+			c.PageOut = uint32(parseUint64(line[1]))
+		case "pswpin":
+			c.SwapIn = uint32(parseUint64(line[1]))
+		case "pswpout":
+			c.SwapOut = uint32(parseUint64(line[1]))
+		}
+	}
+
+	return c, nil
+}
+
+// Disk returns a host disk counters record. I/O counters are summed
+// over the whole disks in /proc/diskstats; capacity is summed over the
+// block device file systems in /proc/mounts.
+func (s *Source) Disk() (sflow.HostDiskCounters, error) {
+	c := sflow.HostDiskCounters{}
+
+	fields, err := s.readFields(s.proc("diskstats"))
+	if err != nil {
+		return c, err
+	}
+	for _, line := range fields {
+		if len(line) < 14 {
+			continue
+		}
+
+		// Only count whole disks, partitions would count twice.
+		if _, err := os.Stat(s.sys("block", line[2])); err != nil {
+			continue
+		}
+		if strings.HasPrefix(line[2], "loop") || strings.HasPrefix(line[2], "ram") {
+			continue
+		}
+
+		c.Reads += uint32(parseUint64(line[3]))
+		c.BytesRead += parseUint64(line[5]) * 512
+		c.ReadTime += uint32(parseUint64(line[6]))
+		c.Writes += uint32(parseUint64(line[7]))
+		c.BytesWritten += parseUint64(line[9]) * 512
+		c.WriteTime += uint32(parseUint64(line[10]))
+	}
+
+	fields, err = s.readFields(s.proc("mounts"))
+	if err != nil {
+		return c, err
+	}
+	fsStat := statfs
+	if s.statfs != nil {
+		fsStat = s.statfs
+	}
+
+	// the fullest partition in hundredths of a percent
+	maxUsed := uint32(0)
+
+	seen := map[string]bool{}
+	for _, line := range fields {
+		if len(line) < 2 || !strings.HasPrefix(line[0], "/dev/") || seen[line[0]] {
+			continue
+		}
+		seen[line[0]] = true
+
+		total, free, err := fsStat(line[1])
+		if err != nil || total == 0 {
+			continue
+		}
+
+		c.Total += total
+		c.Free += free
+
+		used := uint32(float64(total-free) * 10000 / float64(total))
+		if used > maxUsed {
+			maxUsed = used
+		}
+	}
+
+	// part_max_used is an integer on the wire, which
+	// HostDiskCounters holds in a float32 field.
+	c.MaxUsedPercent = math.Float32frombits(maxUsed)
+
+	return c, nil
+}
+
+// Net returns a host network counters record summed over every
+// interface in /proc/net/dev except loopback.
+func (s *Source) Net() (sflow.HostNetCounters, error) {
+	c := sflow.HostNetCounters{}
+
+	lines, err := s.readLines(s.proc("net", "dev"))
+	if err != nil {
+		return c, err
+	}
+	for _, line := range lines {
+		// "  eth0: 1234 5 0 0 0 0 0 0 5678 6 0 0 0 0 0 0"
+		kv := strings.SplitN(line, ":", 2)
+		if len(kv) != 2 {
+			continue
+		}
+		name := strings.TrimSpace(kv[0])
+		f := strings.Fields(kv[1])
+		if name == "lo" || len(f) < 12 {
+			continue
+		}
+
+		c.BytesIn += parseUint64(f[0])
+		c.PacketsIn += uint32(parseUint64(f[1]))
+		c.ErrorsIn += uint32(parseUint64(f[2]))
+		c.DropsIn += uint32(parseUint64(f[3]))
+		c.BytesOut += parseUint64(f[8])
+		c.PacketsOut += uint32(parseUint64(f[9]))
+		c.ErrorsOut += uint32(parseUint64(f[10]))
+		c.DropsOut += uint32(parseUint64(f[11]))
+	}
+
+	return c, nil
+}
+
+// unknownCounter is reported for counters Linux does not keep.
+const unknownCounter = 0xFFFFFFFF
+
+// Interface flags from <linux/if.h>
+const (
+	iffUp      = 0x1
+	iffPromisc = 0x100
+)
+
+// Interfaces returns a generic interface counters record for every
+// interface in /sys/class/net except loopback.
+func (s *Source) Interfaces() ([]sflow.GenericInterfaceCounters, error) {
+	dirs, err := ioutil.ReadDir(s.sys("class", "net"))
+	if err != nil {
+		return nil, err
+	}
+
+	var ifaces []sflow.GenericInterfaceCounters
+
+	for _, dir := range dirs {
+		name := dir.Name()
+		if name == "lo" {
+			continue
+		}
+
+		attr := func(elem ...string) string {
+			b, err := ioutil.ReadFile(s.sys(append([]string{"class", "net", name}, elem...)...))
+			if err != nil {
+				return ""
+			}
+			return strings.TrimSpace(string(b))
+		}
+		stat := func(name string) uint64 {
+			return parseUint64(attr("statistics", name))
+		}
+
+		c := sflow.GenericInterfaceCounters{
+			Index:               parseUint32(attr("ifindex")),
+			Type:                6, // ethernetCsmacd
+			InOctets:            stat("rx_bytes"),
+			InMulticastPackets:  uint32(stat("multicast")),
+			InBroadcastPackets:  unknownCounter,
+			InDiscards:          uint32(stat("rx_dropped")),
+			InErrors:            uint32(stat("rx_errors")),
+			InUnknownProtocols:  unknownCounter,
+			OutOctets:           stat("tx_bytes"),
+			OutUnicastPackets:   uint32(stat("tx_packets")),
+			OutMulticastPackets: unknownCounter,
+			OutBroadcastPackets: unknownCounter,
+			OutDiscards:         uint32(stat("tx_dropped")),
+			OutErrors:           uint32(stat("tx_errors")),
+		}
+		c.InUnicastPackets = uint32(stat("rx_packets")) - c.InMulticastPackets
+
+		// speed is in Mbit/s, -1 if unknown
+		if speed, err := strconv.ParseInt(attr("speed"), 10, 64); err == nil && speed > 0 {
+			c.Speed = uint64(speed) * 1000000
+		}
+
+		switch attr("duplex") {
+		case "full":
+			c.Direction = 1
+		case "half":
+			c.Direction = 2
+		}
+
+		flags, _ := strconv.ParseUint(strings.TrimPrefix(attr("flags"), "0x"), 16, 32)
+		if flags&iffUp != 0 {
+			c.Status |= 1
+		}
+		if attr("operstate") == "up" {
+			c.Status |= 2
+		}
+		if flags&iffPromisc != 0 {
+			c.PromiscuousMode = 1
+		}
+
+		ifaces = append(ifaces, c)
+	}
+
+	return ifaces, nil
+}
+
+func (s *Source) proc(elem ...string) string {
+	root := s.ProcPath
+	if root == "" {
+		root = "/proc"
+	}
+	return filepath.Join(append([]string{root}, elem...)...)
+}
+
+func (s *Source) sys(elem ...string) string {
+	root := s.SysPath
+	if root == "" {
+		root = "/sys"
+	}
+	return filepath.Join(append([]string{root}, elem...)...)
+}
+
+func (s *Source) readLines(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var lines []string
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+
+	return lines, scanner.Err()
+}
+
+// readFields returns the whitespace separated fields
+// of every non-empty line in path.
+func (s *Source) readFields(path string) ([][]string, error) {
+	lines, err := s.readLines(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var fields [][]string
+	for _, line := range lines {
+		if f := strings.Fields(line); len(f) > 0 {
+			fields = append(fields, f)
+		}
+	}
+
+	return fields, nil
+}
+
+func parseUint64(s string) uint64 {
+	n, _ := strconv.ParseUint(s, 10, 64)
+	return n
+}
+
+func parseUint32(s string) uint32 {
+	n, _ := strconv.ParseUint(s, 10, 32)
+	return uint32(n)
+}
+
+func parseFloat32(s string) float32 {
+	f, _ := strconv.ParseFloat(s, 32)
+	return float32(f)
+}
@@ -0,0 +1,193 @@
+package host
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/kanocz/sflow"
+)
+
+var testSource = &Source{
+	ProcPath: "_test/proc",
+	SysPath:  "_test/sys",
+}
+
+func TestCPU(t *testing.T) {
+	c, err := testSource.CPU()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := sflow.HostCPUCounters{
+		Load1m:           0.1,
+		Load5m:           0.2,
+		Load15m:          0.3,
+		ProcessesRunning: 2,
+		ProcessesTotal:   345,
+		NumCPU:           2,
+		SpeedCPU:         2399,
+		Uptime:           3600,
+		CPUUser:          1000,
+		CPUNice:          20,
+		CPUSys:           300,
+		CPUIdle:          40000,
+		CPUWio:           50,
+		CPUIntr:          60,
+		CPUSoftIntr:      70,
+		Interrupts:       123456,
+		ContextSwitches:  654321,
+		CPUSteal:         80,
+		CPUGuest:         90,
+		CPUGuestNice:     100,
+	}
+
+	if c != expected {
+		t.Errorf("expected\n%+#v\n, got\n%+#v", expected, c)
+	}
+}
+
+func TestMemory(t *testing.T) {
+	c, err := testSource.Memory()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := sflow.HostMemoryCounters{
+		Total:     2048000 * 1024,
+		Free:      512000 * 1024,
+		Shared:    2048 * 1024,
+		Buffers:   10240 * 1024,
+		Cached:    204800 * 1024,
+		SwapTotal: 1024000 * 1024,
+		SwapFree:  1000000 * 1024,
+		PageIn:    111,
+		PageOut:   222,
+		SwapIn:    3,
+		SwapOut:   4,
+	}
+
+	if c != expected {
+		t.Errorf("expected\n%+#v\n, got\n%+#v", expected, c)
+	}
+}
+
+func TestDisk(t *testing.T) {
+	c, err := testSource.Disk()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := sflow.HostDiskCounters{
+		Reads:        1000,
+		BytesRead:    20000 * 512,
+		ReadTime:     300,
+		Writes:       2000,
+		BytesWritten: 40000 * 512,
+		WriteTime:    600,
+	}
+
+	if c != expected {
+		t.Errorf("expected\n%+#v\n, got\n%+#v", expected, c)
+	}
+}
+
+func TestDiskMaxUsed(t *testing.T) {
+	proc := t.TempDir()
+
+	for name, content := range map[string]string{
+		"diskstats": "",
+		"mounts":    "/dev/sda1 / ext4 rw 0 0\n/dev/sda2 /home ext4 rw 0 0\n",
+	} {
+		if err := ioutil.WriteFile(filepath.Join(proc, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	s := &Source{
+		ProcPath: proc,
+		SysPath:  "_test/sys",
+		statfs: func(path string) (total, free uint64, err error) {
+			if path == "/" {
+				return 10000, 4361, nil
+			}
+			return 10000, 9000, nil
+		},
+	}
+
+	c, err := s.Disk()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b := &bytes.Buffer{}
+	if err = c.Encode(b); err != nil {
+		t.Fatal(err)
+	}
+
+	// part_max_used follows disk_total and disk_free after the record header
+	if used := binary.BigEndian.Uint32(b.Bytes()[8+16:]); used != 5639 {
+		t.Errorf("expected 5639 hundredths of a percent on the wire, got %d", used)
+	}
+}
+
+func TestNet(t *testing.T) {
+	c, err := testSource.Net()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := sflow.HostNetCounters{
+		BytesIn:    1000,
+		PacketsIn:  10,
+		ErrorsIn:   1,
+		DropsIn:    2,
+		BytesOut:   2000,
+		PacketsOut: 20,
+		ErrorsOut:  4,
+		DropsOut:   5,
+	}
+
+	if c != expected {
+		t.Errorf("expected\n%+#v\n, got\n%+#v", expected, c)
+	}
+}
+
+func TestInterfaces(t *testing.T) {
+	ifaces, err := testSource.Interfaces()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(ifaces) != 1 {
+		t.Fatalf("expected 1 interface, got %d", len(ifaces))
+	}
+
+	expected := sflow.GenericInterfaceCounters{
+		Index:               2,
+		Type:                6,
+		Speed:               1000000000,
+		Direction:           1,
+		Status:              3,
+		InOctets:            1000,
+		InUnicastPackets:    7,
+		InMulticastPackets:  3,
+		InBroadcastPackets:  unknownCounter,
+		InDiscards:          2,
+		InErrors:            1,
+		InUnknownProtocols:  unknownCounter,
+		OutOctets:           2000,
+		OutUnicastPackets:   20,
+		OutMulticastPackets: unknownCounter,
+		OutBroadcastPackets: unknownCounter,
+		OutDiscards:         5,
+		OutErrors:           4,
+		PromiscuousMode:     1,
+	}
+
+	if ifaces[0] != expected {
+		t.Errorf("expected\n%+#v\n, got\n%+#v", expected, ifaces[0])
+	}
+}
@@ -0,0 +1,15 @@
+package host
+
+import "syscall"
+
+// statfs returns the total and free bytes of the file system at path.
+func statfs(path string) (total, free uint64, err error) {
+	var st syscall.Statfs_t
+
+	err = syscall.Statfs(path, &st)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return st.Blocks * uint64(st.Bsize), st.Bfree * uint64(st.Bsize), nil
+}
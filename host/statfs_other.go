@@ -0,0 +1,11 @@
+//go:build !linux
+// +build !linux
+
+package host
+
+import "errors"
+
+// statfs is only implemented on Linux.
+func statfs(path string) (total, free uint64, err error) {
+	return 0, 0, errors.New("sflow: statfs is not supported on this platform")
+}
@@ -0,0 +1,358 @@
+package ipfix
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/kanocz/sflow"
+)
+
+const (
+	// Version is the IPFIX message version number.
+	Version = 10
+
+	// TemplateIDIPv4 and TemplateIDIPv6 are the IDs of the templates
+	// describing IPv4 and IPv6 flows.
+	TemplateIDIPv4 = 256
+	TemplateIDIPv6 = 257
+
+	// DefaultMaxMessageSize keeps messages within a typical
+	// Ethernet MTU when sent over UDP.
+	DefaultMaxMessageSize = 1400
+
+	// DefaultTemplateInterval is the number of messages after which
+	// templates are sent again, so a collector that starts late or
+	// loses a message learns them.
+	DefaultTemplateInterval = 20
+
+	messageHeaderSize = 16
+	setHeaderSize     = 4
+	templateSetID     = 2
+)
+
+var (
+	ErrMessageTooSmall = errors.New("ipfix: max message size too small for a data record")
+)
+
+type field struct {
+	id     uint16
+	length uint16
+}
+
+type template struct {
+	id     uint16
+	fields []field
+}
+
+var commonFields = []field{
+	{IEOctetDeltaCount, 8},
+	{IEPacketDeltaCount, 8},
+	{IEFlowStartMilliseconds, 8},
+	{IEFlowEndMilliseconds, 8},
+	{IEIngressInterface, 4},
+	{IEEgressInterface, 4},
+	{IESourceMacAddress, 6},
+	{IEDestinationMacAddress, 6},
+	{IEEthernetType, 2},
+	{IEVlanID, 2},
+	{IEPostVlanID, 2},
+	{IEProtocolIdentifier, 1},
+	{IEIPClassOfService, 1},
+	{IEIPTTL, 1},
+	{IETCPControlBits, 2},
+	{IESourceTransportPort, 2},
+	{IEDestinationTransportPort, 2},
+	{IEBGPSourceASNumber, 4},
+	{IEBGPDestinationASNumber, 4},
+	{IEBGPPrevAdjacentASNumber, 4},
+	{IEBGPNextAdjacentASNumber, 4},
+	{IESamplingInterval, 4},
+	{IESamplingAlgorithm, 1},
+}
+
+var ipv4Template = template{
+	id: TemplateIDIPv4,
+	fields: append([]field{
+		{IESourceIPv4Address, 4},
+		{IEDestinationIPv4Address, 4},
+		{IESourceIPv4PrefixLength, 1},
+		{IEDestinationIPv4PrefixLength, 1},
+		{IEIPNextHopIPv4Address, 4},
+		{IEBGPNextHopIPv4Address, 4},
+	}, commonFields...),
+}
+
+var ipv6Template = template{
+	id: TemplateIDIPv6,
+	fields: append([]field{
+		{IESourceIPv6Address, 16},
+		{IEDestinationIPv6Address, 16},
+		{IESourceIPv6PrefixLength, 1},
+		{IEDestinationIPv6PrefixLength, 1},
+		{IEIPNextHopIPv6Address, 16},
+		{IEBGPNextHopIPv6Address, 16},
+	}, commonFields...),
+}
+
+func (t *template) recordSize() int {
+	size := 0
+	for _, f := range t.fields {
+		size += int(f.length)
+	}
+	return size
+}
+
+func (t *template) appendTemplate(b []byte) []byte {
+	b = appendUint16(b, t.id)
+	b = appendUint16(b, uint16(len(t.fields)))
+	for _, f := range t.fields {
+		b = appendUint16(b, f.id)
+		b = appendUint16(b, f.length)
+	}
+	return b
+}
+
+func (t *template) appendRecord(b []byte, f *Flow) []byte {
+	for _, field := range t.fields {
+		switch field.id {
+		case IEOctetDeltaCount:
+			b = appendUint64(b, f.Octets)
+		case IEPacketDeltaCount:
+			b = appendUint64(b, f.Packets)
+		case IEFlowStartMilliseconds, IEFlowEndMilliseconds:
+			b = appendUint64(b, uint64(f.Time.UnixNano()/int64(time.Millisecond)))
+		case IEIngressInterface:
+			b = appendUint32(b, f.InputInterface)
+		case IEEgressInterface:
+			b = appendUint32(b, f.OutputInterface)
+		case IESourceMacAddress:
+			b = appendBytes(b, f.SrcMAC, 6)
+		case IEDestinationMacAddress:
+			b = appendBytes(b, f.DstMAC, 6)
+		case IEEthernetType:
+			b = appendUint16(b, f.EtherType)
+		case IEVlanID:
+			b = appendUint16(b, f.VLAN)
+		case IEPostVlanID:
+			b = appendUint16(b, f.PostVLAN)
+		case IEProtocolIdentifier:
+			b = append(b, f.Protocol)
+		case IEIPClassOfService:
+			b = append(b, f.TOS)
+		case IEIPTTL:
+			b = append(b, f.TTL)
+		case IETCPControlBits:
+			b = appendUint16(b, uint16(f.TCPFlags))
+		case IESourceTransportPort:
+			b = appendUint16(b, f.SrcPort)
+		case IEDestinationTransportPort:
+			b = appendUint16(b, f.DstPort)
+		case IEBGPSourceASNumber:
+			b = appendUint32(b, f.SrcAS)
+		case IEBGPDestinationASNumber:
+			b = appendUint32(b, f.DstAS)
+		case IEBGPPrevAdjacentASNumber:
+			b = appendUint32(b, f.PrevAdjacentAS)
+		case IEBGPNextAdjacentASNumber:
+			b = appendUint32(b, f.NextAdjacentAS)
+		case IESamplingInterval:
+			b = appendUint32(b, f.SamplingRate)
+		case IESamplingAlgorithm:
+			b = append(b, SamplingAlgorithmRandom)
+		case IESourceIPv4Address:
+			b = appendBytes(b, f.SrcIP.To4(), 4)
+		case IEDestinationIPv4Address:
+			b = appendBytes(b, f.DstIP.To4(), 4)
+		case IEIPNextHopIPv4Address:
+			b = appendBytes(b, f.NextHop.To4(), 4)
+		case IEBGPNextHopIPv4Address:
+			b = appendBytes(b, f.BGPNextHop.To4(), 4)
+		case IESourceIPv6Address:
+			b = appendBytes(b, f.SrcIP.To16(), 16)
+		case IEDestinationIPv6Address:
+			b = appendBytes(b, f.DstIP.To16(), 16)
+		case IEIPNextHopIPv6Address:
+			b = appendBytes(b, ipv6Only(f.NextHop), 16)
+		case IEBGPNextHopIPv6Address:
+			b = appendBytes(b, ipv6Only(f.BGPNextHop), 16)
+		case IESourceIPv4PrefixLength, IESourceIPv6PrefixLength:
+			b = append(b, f.SrcPrefixLen)
+		case IEDestinationIPv4PrefixLength, IEDestinationIPv6PrefixLength:
+			b = append(b, f.DstPrefixLen)
+		default:
+			b = append(b, make([]byte, field.length)...)
+		}
+	}
+
+	return b
+}
+
+// ipv6Only returns ip if it is an IPv6 address, so IPv4 next hops
+// are exported as zero in the IPv6 template.
+func ipv6Only(ip net.IP) net.IP {
+	if ip.To4() != nil {
+		return nil
+	}
+	return ip
+}
+
+// Exporter writes flows as IPFIX messages. Each call to the underlying
+// writer carries exactly one message, so it can be a UDP connection.
+// It is safe for concurrent use.
+type Exporter struct {
+	// ObservationDomainID is written to the header of every message.
+	ObservationDomainID uint32
+
+	// MaxMessageSize limits the size of a single message.
+	// DefaultMaxMessageSize is used if it is zero.
+	MaxMessageSize int
+
+	// TemplateInterval is the number of messages between template
+	// retransmissions. DefaultTemplateInterval is used if it is zero.
+	TemplateInterval int
+
+	w   io.Writer
+	now func() time.Time
+
+	mu       sync.Mutex
+	seq      uint32
+	messages int
+}
+
+// NewExporter returns an Exporter writing messages to w.
+func NewExporter(w io.Writer, observationDomainID uint32) *Exporter {
+	return &Exporter{
+		ObservationDomainID: observationDomainID,
+		w:                   w,
+		now:                 time.Now,
+	}
+}
+
+// DialExporter returns an Exporter sending messages
+// to the UDP collector at addr.
+func DialExporter(addr string, observationDomainID uint32) (*Exporter, error) {
+	conn, err := net.Dial("udp", addr)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewExporter(conn, observationDomainID), nil
+}
+
+// Close closes the underlying writer if it is an io.Closer.
+func (e *Exporter) Close() error {
+	if c, ok := e.w.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
+
+// ExportDatagram exports the flows of all flow samples in d.
+func (e *Exporter) ExportDatagram(d *sflow.Datagram) error {
+	return e.Export(Flows(d, e.now()))
+}
+
+// Export writes flows in as few messages as MaxMessageSize allows.
+func (e *Exporter) Export(flows []Flow) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	for len(flows) > 0 {
+		msg, n := e.message(flows)
+		if n == 0 {
+			return ErrMessageTooSmall
+		}
+
+		_, err := e.w.Write(msg)
+		if err != nil {
+			return err
+		}
+
+		e.seq += uint32(n)
+		e.messages++
+		flows = flows[n:]
+	}
+
+	return nil
+}
+
+// message builds the next message from the start of flows
+// and returns it with the number of flows it holds.
+func (e *Exporter) message(flows []Flow) ([]byte, int) {
+	maxSize := e.MaxMessageSize
+	if maxSize <= 0 {
+		maxSize = DefaultMaxMessageSize
+	}
+
+	interval := e.TemplateInterval
+	if interval <= 0 {
+		interval = DefaultTemplateInterval
+	}
+
+	b := make([]byte, messageHeaderSize, maxSize)
+
+	if e.messages%interval == 0 {
+		start := len(b)
+		b = appendUint16(b, templateSetID)
+		b = appendUint16(b, 0)
+		b = ipv4Template.appendTemplate(b)
+		b = ipv6Template.appendTemplate(b)
+		binary.BigEndian.PutUint16(b[start+2:], uint16(len(b)-start))
+	}
+
+	n := 0
+	for n < len(flows) {
+		t := &ipv4Template
+		if flows[n].IPv6() {
+			t = &ipv6Template
+		}
+
+		size := t.recordSize()
+		if len(b)+setHeaderSize+size > maxSize {
+			break
+		}
+
+		start := len(b)
+		b = appendUint16(b, t.id)
+		b = appendUint16(b, 0)
+
+		for n < len(flows) && flows[n].IPv6() == (t == &ipv6Template) && len(b)+size <= maxSize {
+			b = t.appendRecord(b, &flows[n])
+			n++
+		}
+
+		binary.BigEndian.PutUint16(b[start+2:], uint16(len(b)-start))
+	}
+
+	binary.BigEndian.PutUint16(b[0:], Version)
+	binary.BigEndian.PutUint16(b[2:], uint16(len(b)))
+	binary.BigEndian.PutUint32(b[4:], uint32(e.now().Unix()))
+	binary.BigEndian.PutUint32(b[8:], e.seq)
+	binary.BigEndian.PutUint32(b[12:], e.ObservationDomainID)
+
+	return b, n
+}
+
+func appendUint16(b []byte, v uint16) []byte {
+	return append(b, byte(v>>8), byte(v))
+}
+
+func appendUint32(b []byte, v uint32) []byte {
+	return append(b, byte(v>>24), byte(v>>16), byte(v>>8), byte(v))
+}
+
+func appendUint64(b []byte, v uint64) []byte {
+	return appendUint32(appendUint32(b, uint32(v>>32)), uint32(v))
+}
+
+// appendBytes appends v padded or truncated to n bytes.
+func appendBytes(b []byte, v []byte, n int) []byte {
+	start := len(b)
+	b = append(b, make([]byte, n)...)
+	copy(b[start:], v)
+	return b
+}
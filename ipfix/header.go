@@ -0,0 +1,165 @@
+package ipfix
+
+import (
+	"encoding/binary"
+	"net"
+
+	"github.com/kanocz/sflow/records"
+)
+
+// decodeHeader fills in f from the sampled header of r and reports
+// whether an IP header was found.
+func decodeHeader(r records.RawPacketFlow, f *Flow) bool {
+	h := r.Header
+	ipVersion := 0
+
+	switch r.Protocol {
+	case records.HeaderProtocolEthernetISO8023:
+		if len(h) < 14 {
+			return false
+		}
+
+		f.DstMAC = net.HardwareAddr(append([]byte(nil), h[0:6]...))
+		f.SrcMAC = net.HardwareAddr(append([]byte(nil), h[6:12]...))
+
+		etherType := binary.BigEndian.Uint16(h[12:])
+		h = h[14:]
+
+		// 802.1Q and 802.1ad tags
+		for (etherType == 0x8100 || etherType == 0x88a8) && len(h) >= 4 {
+			if f.VLAN == 0 {
+				f.VLAN = binary.BigEndian.Uint16(h) & 0x0fff
+			}
+
+			etherType = binary.BigEndian.Uint16(h[2:])
+			h = h[4:]
+		}
+
+		f.EtherType = etherType
+
+		switch etherType {
+		case 0x0800:
+			ipVersion = 4
+		case 0x86dd:
+			ipVersion = 6
+		}
+
+	case records.HeaderProtocolIPv4:
+		ipVersion = 4
+		f.EtherType = 0x0800
+
+	case records.HeaderProtocolIPv6:
+		ipVersion = 6
+		f.EtherType = 0x86dd
+	}
+
+	// the octet count covers the IP packet, not the link layer
+	ipSize := int(r.FrameLength) - int(r.Stripped) - (len(r.Header) - len(h))
+	if ipSize > 0 {
+		f.Octets = uint64(ipSize)
+	}
+
+	switch ipVersion {
+	case 4:
+		return decodeIPv4(h, f)
+	case 6:
+		return decodeIPv6(h, f)
+	}
+
+	return false
+}
+
+func decodeIPv4(h []byte, f *Flow) bool {
+	if len(h) < 20 || h[0]>>4 != 4 {
+		return false
+	}
+
+	f.TOS = h[1]
+	f.TTL = h[8]
+	f.Protocol = h[9]
+	f.SrcIP = net.IP(append([]byte(nil), h[12:16]...))
+	f.DstIP = net.IP(append([]byte(nil), h[16:20]...))
+
+	// only the first fragment carries the transport header
+	if binary.BigEndian.Uint16(h[6:])&0x1fff != 0 {
+		return true
+	}
+
+	headerLen := int(h[0]&0x0f) * 4
+	if headerLen >= 20 && headerLen <= len(h) {
+		decodeTransport(h[headerLen:], f)
+	}
+
+	return true
+}
+
+func decodeIPv6(h []byte, f *Flow) bool {
+	if len(h) < 40 || h[0]>>4 != 6 {
+		return false
+	}
+
+	f.TOS = h[0]<<4 | h[1]>>4
+	f.TTL = h[7]
+	f.SrcIP = net.IP(append([]byte(nil), h[8:24]...))
+	f.DstIP = net.IP(append([]byte(nil), h[24:40]...))
+
+	next := h[6]
+	h = h[40:]
+
+	// skip hop-by-hop, routing, fragment, auth and destination options
+	for next == 0 || next == 43 || next == 44 || next == 51 || next == 60 {
+		if len(h) < 8 {
+			f.Protocol = next
+			return true
+		}
+
+		n := 8 * (int(h[1]) + 1)
+		switch next {
+		case 44:
+			n = 8
+			if binary.BigEndian.Uint16(h[2:])&0xfff8 != 0 {
+				// not the first fragment
+				f.Protocol = h[0]
+				return true
+			}
+		case 51:
+			n = 4 * (int(h[1]) + 2)
+		}
+
+		next = h[0]
+		if n > len(h) {
+			f.Protocol = next
+			return true
+		}
+		h = h[n:]
+	}
+
+	f.Protocol = next
+	decodeTransport(h, f)
+
+	return true
+}
+
+func decodeTransport(h []byte, f *Flow) {
+	switch f.Protocol {
+	case records.IPProtocolTCP:
+		if len(h) >= 14 {
+			f.SrcPort = binary.BigEndian.Uint16(h[0:])
+			f.DstPort = binary.BigEndian.Uint16(h[2:])
+			f.TCPFlags = h[13]
+		}
+
+	case records.IPProtocolUDP:
+		if len(h) >= 4 {
+			f.SrcPort = binary.BigEndian.Uint16(h[0:])
+			f.DstPort = binary.BigEndian.Uint16(h[2:])
+		}
+
+	case records.IPProtocolICMP, 58:
+		// ICMP type and code are exported in the destination
+		// port as type*256+code, as NetFlow collectors expect.
+		if len(h) >= 2 {
+			f.DstPort = uint16(h[0])<<8 | uint16(h[1])
+		}
+	}
+}
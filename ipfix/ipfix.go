@@ -0,0 +1,163 @@
+// Package ipfix translates sampled flows into IPFIX (RFC 7011) data
+// records and exports them to an io.Writer or a UDP collector, much like
+// sflowtool's NetFlow mode.
+//
+// Each flow sample carrying a sampled IPv4 or IPv6 header becomes one
+// data record with a packet count of one and the octet count of the
+// sampled packet's IP layer. Counts are not scaled; the sampling rate is
+// carried in the samplingInterval and samplingAlgorithm information
+// elements so collectors can scale them.
+package ipfix
+
+import (
+	"net"
+	"time"
+
+	"github.com/kanocz/sflow"
+	"github.com/kanocz/sflow/records"
+)
+
+// Information element identifiers used in the exported templates,
+// as assigned by IANA.
+const (
+	IEOctetDeltaCount             = 1
+	IEPacketDeltaCount            = 2
+	IEProtocolIdentifier          = 4
+	IEIPClassOfService            = 5
+	IETCPControlBits              = 6
+	IESourceTransportPort         = 7
+	IESourceIPv4Address           = 8
+	IESourceIPv4PrefixLength      = 9
+	IEIngressInterface            = 10
+	IEDestinationTransportPort    = 11
+	IEDestinationIPv4Address      = 12
+	IEDestinationIPv4PrefixLength = 13
+	IEEgressInterface             = 14
+	IEIPNextHopIPv4Address        = 15
+	IEBGPSourceASNumber           = 16
+	IEBGPDestinationASNumber      = 17
+	IEBGPNextHopIPv4Address       = 18
+	IESourceIPv6Address           = 27
+	IEDestinationIPv6Address      = 28
+	IESourceIPv6PrefixLength      = 29
+	IEDestinationIPv6PrefixLength = 30
+	IESamplingInterval            = 34
+	IESamplingAlgorithm           = 35
+	IESourceMacAddress            = 56
+	IEVlanID                      = 58
+	IEPostVlanID                  = 59
+	IEIPNextHopIPv6Address        = 62
+	IEBGPNextHopIPv6Address       = 63
+	IEDestinationMacAddress       = 80
+	IEBGPNextAdjacentASNumber     = 128
+	IEBGPPrevAdjacentASNumber     = 129
+	IEFlowStartMilliseconds       = 152
+	IEFlowEndMilliseconds         = 153
+	IEIPTTL                       = 192
+	IEEthernetType                = 256
+)
+
+// SamplingAlgorithmRandom is the samplingAlgorithm value for
+// random n-out-of-N sampling, which sFlow agents perform.
+const SamplingAlgorithmRandom = 2
+
+// Flow is a single IPFIX data record built from a flow sample.
+type Flow struct {
+	Time time.Time
+
+	Octets  uint64
+	Packets uint64
+
+	SrcIP    net.IP
+	DstIP    net.IP
+	Protocol uint8
+	TOS      uint8
+	TTL      uint8
+	SrcPort  uint16
+	DstPort  uint16
+	TCPFlags uint8
+
+	InputInterface  uint32
+	OutputInterface uint32
+
+	SrcMAC    net.HardwareAddr
+	DstMAC    net.HardwareAddr
+	EtherType uint16
+	VLAN      uint16
+	PostVLAN  uint16
+
+	NextHop      net.IP
+	SrcPrefixLen uint8
+	DstPrefixLen uint8
+
+	BGPNextHop     net.IP
+	SrcAS          uint32
+	DstAS          uint32
+	PrevAdjacentAS uint32
+	NextAdjacentAS uint32
+
+	SamplingRate uint32
+}
+
+// IPv6 reports whether f is exported with the IPv6 template.
+func (f *Flow) IPv6() bool {
+	return f.SrcIP.To4() == nil
+}
+
+// FlowFromSample builds a Flow from a flow sample taken at t. It reports
+// false if the sample has no raw packet record with an IP header.
+func FlowFromSample(s *sflow.FlowSample, t time.Time) (Flow, bool) {
+	f := Flow{
+		Time:            t,
+		Packets:         1,
+		InputInterface:  s.Input,
+		OutputInterface: s.Output,
+		SamplingRate:    s.SamplingRate,
+	}
+
+	ok := false
+
+	for _, rec := range s.Records {
+		switch r := rec.(type) {
+		case records.RawPacketFlow:
+			ok = decodeHeader(r, &f)
+
+		case records.ExtendedSwitchFlow:
+			f.VLAN = uint16(r.SourceVlan)
+			f.PostVLAN = uint16(r.DestinationVlan)
+
+		case records.ExtendedRouterFlow:
+			f.NextHop = r.NextHop
+			f.SrcPrefixLen = uint8(r.SrcMask)
+			f.DstPrefixLen = uint8(r.DstMask)
+
+		case records.ExtendedGatewayFlow:
+			f.BGPNextHop = r.NextHop
+			f.SrcAS = r.SrcAs
+			f.DstAS = r.DstAs
+			f.PrevAdjacentAS = r.SrcPeerAs
+			f.NextAdjacentAS = r.DstPeerAs
+		}
+	}
+
+	return f, ok
+}
+
+// Flows builds the flows of all flow samples in d received at t.
+func Flows(d *sflow.Datagram, t time.Time) []Flow {
+	var flows []Flow
+
+	for _, sample := range d.Samples {
+		s, ok := sample.(*sflow.FlowSample)
+		if !ok {
+			continue
+		}
+
+		f, ok := FlowFromSample(s, t)
+		if ok {
+			flows = append(flows, f)
+		}
+	}
+
+	return flows
+}
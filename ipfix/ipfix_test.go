@@ -0,0 +1,228 @@
+package ipfix
+
+import (
+	"bytes"
+	"encoding/binary"
+	"net"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/kanocz/sflow"
+	"github.com/kanocz/sflow/records"
+)
+
+type messages [][]byte
+
+func (m *messages) Write(b []byte) (int, error) {
+	*m = append(*m, append([]byte(nil), b...))
+	return len(b), nil
+}
+
+func TestFlowFromSample(t *testing.T) {
+	f, err := os.Open("../_test/flow_sample.dump")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	dgram, err := sflow.NewDecoder(f).Decode()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	flows := Flows(dgram, time.Unix(1700000000, 0))
+	if len(flows) != 1 {
+		t.Fatalf("expected 1 flow, got %d", len(flows))
+	}
+
+	flow := flows[0]
+
+	if !flow.SrcIP.Equal(net.ParseIP("199.58.161.150")) || !flow.DstIP.Equal(net.ParseIP("197.161.57.246")) {
+		t.Errorf("unexpected addresses %v -> %v", flow.SrcIP, flow.DstIP)
+	}
+
+	if flow.Protocol != 17 || flow.SrcPort != 51413 || flow.DstPort != 9728 {
+		t.Errorf("unexpected protocol/ports %d %d %d", flow.Protocol, flow.SrcPort, flow.DstPort)
+	}
+
+	if flow.Octets != 300 || flow.Packets != 1 {
+		t.Errorf("expected 300 octets in 1 packet, got %d in %d", flow.Octets, flow.Packets)
+	}
+
+	if flow.SamplingRate != 4096 || flow.InputInterface != 4 || flow.OutputInterface != 1 {
+		t.Errorf("unexpected sampling rate or interfaces: %+v", flow)
+	}
+
+	if flow.EtherType != 0x0800 || flow.SrcMAC.String() != "00:16:3c:c2:a9:ab" {
+		t.Errorf("unexpected link layer: %+v", flow)
+	}
+
+	// from the extended switch record
+	if flow.VLAN != 16 || flow.PostVLAN != 16 {
+		t.Errorf("unexpected VLANs %d and %d", flow.VLAN, flow.PostVLAN)
+	}
+}
+
+func TestFlowFromSampleIPv6(t *testing.T) {
+	header := make([]byte, 14+40+8)
+	binary.BigEndian.PutUint16(header[12:], 0x86dd)
+	ip := header[14:]
+	ip[0] = 0x60
+	ip[6] = records.IPProtocolUDP
+	ip[7] = 64
+	copy(ip[8:], net.ParseIP("2001:db8::1"))
+	copy(ip[24:], net.ParseIP("2001:db8::2"))
+	binary.BigEndian.PutUint16(ip[40:], 53)
+	binary.BigEndian.PutUint16(ip[42:], 5353)
+
+	s := &sflow.FlowSample{
+		SamplingRate: 100,
+		Records: []records.Record{
+			records.RawPacketFlow{
+				Protocol:    records.HeaderProtocolEthernetISO8023,
+				FrameLength: uint32(len(header)) + 4,
+				Stripped:    4,
+				HeaderSize:  uint32(len(header)),
+				Header:      header,
+			},
+			records.ExtendedSwitchFlow{SourceVlan: 10, DestinationVlan: 20},
+			records.ExtendedRouterFlow{
+				NextHopType: 2,
+				NextHop:     net.ParseIP("2001:db8::fe"),
+				SrcMask:     48,
+				DstMask:     64,
+			},
+			records.ExtendedGatewayFlow{
+				NextHopType:          1,
+				NextHop:              net.IPv4(192, 0, 2, 254).To4(),
+				SrcAs:                64496,
+				SrcPeerAs:            64497,
+				DstAsPathSegmentsLen: 1,
+				DstAsPathSegments: []records.ExtendedGatewayFlowASPathSegment{{
+					SegType: records.AsPathSegmentTypeOrdered,
+					SegLen:  3,
+					Seg:     []uint32{64510, 64500, 64511},
+				}},
+			},
+		},
+	}
+
+	// the flow is built from the decoded sample
+	buf := &bytes.Buffer{}
+	err := sflow.NewEncoder(net.IPv4(192, 0, 2, 1), 0, 1).Encode(buf, []sflow.Sample{s})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dgram, err := sflow.NewPacketDecoder().Decode(buf.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	flows := Flows(dgram, time.Now())
+	if len(flows) != 1 {
+		t.Fatalf("expected 1 flow, got %d", len(flows))
+	}
+
+	flow := flows[0]
+
+	if !flow.IPv6() || flow.SrcPort != 53 || flow.DstPort != 5353 || flow.TTL != 64 {
+		t.Errorf("unexpected flow %+v", flow)
+	}
+
+	if flow.Octets != 48 || flow.VLAN != 10 || flow.PostVLAN != 20 {
+		t.Errorf("unexpected octets or VLANs %+v", flow)
+	}
+
+	if !flow.NextHop.Equal(net.ParseIP("2001:db8::fe")) || flow.SrcPrefixLen != 48 || flow.DstPrefixLen != 64 {
+		t.Errorf("unexpected next hop or prefix lengths %+v", flow)
+	}
+
+	if !flow.BGPNextHop.Equal(net.IPv4(192, 0, 2, 254)) || flow.SrcAS != 64496 || flow.DstAS != 64511 ||
+		flow.PrevAdjacentAS != 64497 || flow.NextAdjacentAS != 64510 {
+		t.Errorf("unexpected BGP fields %+v", flow)
+	}
+}
+
+func TestExport(t *testing.T) {
+	var out messages
+
+	e := NewExporter(&out, 42)
+	e.now = func() time.Time { return time.Unix(1700000000, 0) }
+	e.MaxMessageSize = 400
+	e.TemplateInterval = 2
+
+	flows := make([]Flow, 5)
+	for i := range flows {
+		flows[i] = Flow{
+			SrcIP:   net.IPv4(10, 0, 0, byte(i)),
+			DstIP:   net.IPv4(10, 0, 1, byte(i)),
+			Packets: 1,
+		}
+	}
+
+	err := e.Export(flows)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	recordSize := ipv4Template.recordSize()
+	seq := uint32(0)
+	records := 0
+
+	for i, msg := range out {
+		if len(msg) > 400 {
+			t.Errorf("message %d is %d bytes", i, len(msg))
+		}
+
+		if binary.BigEndian.Uint16(msg[0:]) != Version {
+			t.Errorf("message %d: unexpected version %d", i, binary.BigEndian.Uint16(msg[0:]))
+		}
+
+		if int(binary.BigEndian.Uint16(msg[2:])) != len(msg) {
+			t.Errorf("message %d: length %d, expected %d", i, binary.BigEndian.Uint16(msg[2:]), len(msg))
+		}
+
+		if binary.BigEndian.Uint32(msg[4:]) != 1700000000 || binary.BigEndian.Uint32(msg[12:]) != 42 {
+			t.Errorf("message %d: unexpected export time or domain", i)
+		}
+
+		if binary.BigEndian.Uint32(msg[8:]) != seq {
+			t.Errorf("message %d: sequence number %d, expected %d", i, binary.BigEndian.Uint32(msg[8:]), seq)
+		}
+
+		templates := false
+		for b := msg[messageHeaderSize:]; len(b) > 0; {
+			id := binary.BigEndian.Uint16(b[0:])
+			length := int(binary.BigEndian.Uint16(b[2:]))
+
+			switch id {
+			case templateSetID:
+				templates = true
+			case TemplateIDIPv4:
+				n := (length - setHeaderSize) / recordSize
+				seq += uint32(n)
+				records += n
+			default:
+				t.Errorf("message %d: unexpected set %d", i, id)
+			}
+
+			b = b[length:]
+		}
+
+		if templates != (i%2 == 0) {
+			t.Errorf("message %d: templates sent = %v", i, templates)
+		}
+	}
+
+	if records != len(flows) {
+		t.Errorf("expected %d records, got %d", len(flows), records)
+	}
+
+	e.MaxMessageSize = 20
+	err = e.Export(flows)
+	if err != ErrMessageTooSmall {
+		t.Errorf("expected %v, got %v", ErrMessageTooSmall, err)
+	}
+}
@@ -0,0 +1,277 @@
+package sflow
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+
+	"github.com/kanocz/sflow/records"
+)
+
+// JSON encoding
+//
+// Datagrams, samples and records marshal to JSON with camelCase field
+// names. Samples and records are interfaces, so every sample object
+// carries a "sampleType" and every record object a "recordType" member
+// holding its sFlow data format (enterprise<<12 | format):
+//
+//	{
+//	  "version": 5, "ipVersion": 1, "ipAddress": "192.0.2.1",
+//	  "subAgentId": 0, "sequenceNumber": 7, "uptime": 120000, "numSamples": 1,
+//	  "samples": [{
+//	    "sampleType": 1,
+//	    "sequenceNum": 3, "sourceIdType": 0, "sourceIdIndexVal": 9,
+//	    "samplingRate": 512, "samplePool": 1536, "drops": 0,
+//	    "input": 9, "output": 12,
+//	    "records": [
+//	      {"recordType": 1, "protocol": 1, "frameLength": 318, "stripped": 4,
+//	       "headerSize": 128, "header": "<base64>", "decodedHeader": {...}},
+//	      {"recordType": 1001, "sourceVlan": 10, ...}
+//	    ]
+//	  }]
+//	}
+//
+// Byte slices are base64 encoded, IP addresses are strings and MAC
+// addresses are 12 hex digits. json.Unmarshal into a Datagram restores
+// *FlowSample and *CounterSample samples holding the same concrete
+// record types the decoder produces. RawPacketFlow.DecodedHeader is
+// rebuilt from the header bytes rather than read from JSON.
+
+var (
+	ErrUnknownRecordType = errors.New("sflow: unknown record type")
+)
+
+// flowRecordJSONTypes maps flow record types to the types
+// their JSON objects are unmarshaled into.
+var flowRecordJSONTypes = map[int]reflect.Type{
+	records.TypeRawPacketFlowRecord:               reflect.TypeOf(records.RawPacketFlow{}),
+	records.TypeEthernetFrameFlowRecord:           reflect.TypeOf(records.EthernetFrameFlow{}),
+	records.TypeExtendedSwitchFlowRecord:          reflect.TypeOf(records.ExtendedSwitchFlow{}),
+	records.TypeExtendedRouterFlowRecord:          reflect.TypeOf(records.ExtendedRouterFlow{}),
+	records.TypeExtendedGatewayFlowRecord:         reflect.TypeOf(records.ExtendedGatewayFlow{}),
+	records.TypeExtendedSocketIPv4FlowRecord:      reflect.TypeOf(records.ExtendedSocketIPv4Flow{}),
+	records.TypeExtendedSocketIPv6FlowRecord:      reflect.TypeOf(records.ExtendedSocketIPv6Flow{}),
+	records.TypeExtendedProxySocketIPv4FlowRecord: reflect.TypeOf(records.ExtendedProxySocketIPv4Flow{}),
+	records.TypeExtendedProxySocketIPv6FlowRecord: reflect.TypeOf(records.ExtendedProxySocketIPv6Flow{}),
+	records.TypeHTTPRequestFlowRecord:             reflect.TypeOf(records.HTTPRequestFlow{}),
+}
+
+// counterRecordJSONTypes maps counter record types to the types
+// their JSON objects are unmarshaled into.
+var counterRecordJSONTypes = map[int]reflect.Type{
+	TypeGenericInterfaceCountersRecord:       reflect.TypeOf(GenericInterfaceCounters{}),
+	TypeEthernetCountersRecord:               reflect.TypeOf(EthernetCounters{}),
+	TypeTokenRingCountersRecord:              reflect.TypeOf(TokenRingCounters{}),
+	TypeVgCountersRecord:                     reflect.TypeOf(VgCounters{}),
+	TypeVlanCountersRecord:                   reflect.TypeOf(VlanCounters{}),
+	TypeIEEE80211CountersRecord:              reflect.TypeOf(IEEE80211Counters{}),
+	TypeLAGPortCountersRecord:                reflect.TypeOf(LAGPortCounters{}),
+	TypeSlowPathCountersRecord:               reflect.TypeOf(SlowPathCounters{}),
+	TypeInfiniBandCountersRecord:             reflect.TypeOf(InfiniBandCounters{}),
+	TypeProcessorCountersRecord:              reflect.TypeOf(ProcessorCounters{}),
+	TypeRadioUtilizationCountersRecord:       reflect.TypeOf(RadioUtilizationCounters{}),
+	TypeQueueLengthCountersRecord:            reflect.TypeOf(QueueLengthCounters{}),
+	TypeOpenFlowPortCountersRecord:           reflect.TypeOf(OpenFlowPortCounters{}),
+	TypePortNameCountersRecord:               reflect.TypeOf(PortNameCounters{}),
+	TypeHostCPUCountersRecord:                reflect.TypeOf(HostCPUCounters{}),
+	TypeHostMemoryCountersRecord:             reflect.TypeOf(HostMemoryCounters{}),
+	TypeHostDiskCountersRecord:               reflect.TypeOf(HostDiskCounters{}),
+	TypeHostNetCountersRecord:                reflect.TypeOf(HostNetCounters{}),
+	TypeMIB2IPGroupCountersRecord:            reflect.TypeOf(MIB2IPGroupCounters{}),
+	TypeMIB2ICMPGroupCountersRecord:          reflect.TypeOf(MIB2ICMPGroupCounters{}),
+	TypeMIB2TCPGroupCountersRecord:           reflect.TypeOf(MIB2TCPGroupCounters{}),
+	TypeMIB2UDPGroupCountersRecord:           reflect.TypeOf(MIB2UDPGroupCounters{}),
+	TypeJMXRuntimeCountersRecord:             reflect.TypeOf(JMXRuntimeCounters{}),
+	TypeJMXStatisticsCountersRecord:          reflect.TypeOf(JMXStatisticsCounters{}),
+	records.TypeHTTPCounterRecord:            reflect.TypeOf(records.HTTPCounter{}),
+	TypeOVSDPStatsCountersRecord:             reflect.TypeOf(OVSDPStatsCounters{}),
+	TypeEnergyCountersRecord:                 reflect.TypeOf(EnergyCounters{}),
+	TypeTemperatureCountersRecord:            reflect.TypeOf(TemperatureCounters{}),
+	TypeHumidityCountersRecord:               reflect.TypeOf(HumidityCounters{}),
+	TypeFansCountersRecord:                   reflect.TypeOf(FansCounters{}),
+	TypeBroadcomDeviceBuffersCountersRecord:  reflect.TypeOf(BroadcomDeviceBuffersCounters{}),
+	TypeBroadcomPortBuffersCountersRecord:    reflect.TypeOf(BroadcomPortBuffersCounters{}),
+	TypeBroadcomHardwareTablesCountersRecord: reflect.TypeOf(BroadcomHardwareTablesCounters{}),
+	TypeNvidiaGPUCountersRecord:              reflect.TypeOf(NvidiaGPUCounters{}),
+}
+
+// UnmarshalJSON restores a datagram including its concrete sample types.
+func (d *Datagram) UnmarshalJSON(b []byte) error {
+	type X Datagram
+	x := struct {
+		*X
+		Samples []json.RawMessage `json:"samples"`
+	}{X: (*X)(d)}
+
+	err := json.Unmarshal(b, &x)
+	if err != nil {
+		return err
+	}
+
+	d.Samples = nil
+	for _, raw := range x.Samples {
+		var typ struct {
+			SampleType int `json:"sampleType"`
+		}
+
+		err = json.Unmarshal(raw, &typ)
+		if err != nil {
+			return err
+		}
+
+		var sample Sample
+		switch typ.SampleType {
+		case TypeFlowSample:
+			sample = &FlowSample{}
+		case TypeCounterSample:
+			sample = &CounterSample{}
+		default:
+			return ErrUnknownSampleType
+		}
+
+		err = json.Unmarshal(raw, sample)
+		if err != nil {
+			return err
+		}
+
+		d.Samples = append(d.Samples, sample)
+	}
+
+	return nil
+}
+
+// MarshalJSON encodes the sample with its "sampleType" and
+// the "recordType" of each record.
+func (s FlowSample) MarshalJSON() ([]byte, error) {
+	type X FlowSample
+
+	recs, err := marshalRecords(s.Records)
+	if err != nil {
+		return nil, err
+	}
+
+	return marshalWithType("sampleType", s.SampleType(), struct {
+		X
+		Records []json.RawMessage `json:"records"`
+	}{X(s), recs})
+}
+
+// UnmarshalJSON restores the sample including its concrete record types.
+func (s *FlowSample) UnmarshalJSON(b []byte) error {
+	type X FlowSample
+	x := struct {
+		*X
+		Records []json.RawMessage `json:"records"`
+	}{X: (*X)(s)}
+
+	err := json.Unmarshal(b, &x)
+	if err != nil {
+		return err
+	}
+
+	s.Records, err = unmarshalRecords(x.Records, flowRecordJSONTypes)
+	return err
+}
+
+// MarshalJSON encodes the sample with its "sampleType" and
+// the "recordType" of each record.
+func (s CounterSample) MarshalJSON() ([]byte, error) {
+	type X CounterSample
+
+	recs, err := marshalRecords(s.Records)
+	if err != nil {
+		return nil, err
+	}
+
+	return marshalWithType("sampleType", TypeCounterSample, struct {
+		X
+		Records []json.RawMessage `json:"records"`
+	}{X(s), recs})
+}
+
+// UnmarshalJSON restores the sample including its concrete record types.
+func (s *CounterSample) UnmarshalJSON(b []byte) error {
+	type X CounterSample
+	x := struct {
+		*X
+		Records []json.RawMessage `json:"records"`
+	}{X: (*X)(s)}
+
+	err := json.Unmarshal(b, &x)
+	if err != nil {
+		return err
+	}
+
+	s.Records, err = unmarshalRecords(x.Records, counterRecordJSONTypes)
+	return err
+}
+
+func marshalRecords(recs []records.Record) ([]json.RawMessage, error) {
+	out := make([]json.RawMessage, 0, len(recs))
+
+	for _, rec := range recs {
+		b, err := marshalWithType("recordType", rec.RecordType(), rec)
+		if err != nil {
+			return nil, err
+		}
+
+		out = append(out, b)
+	}
+
+	return out, nil
+}
+
+func unmarshalRecords(raws []json.RawMessage, types map[int]reflect.Type) ([]records.Record, error) {
+	var recs []records.Record
+
+	for _, raw := range raws {
+		var typ struct {
+			RecordType int `json:"recordType"`
+		}
+
+		err := json.Unmarshal(raw, &typ)
+		if err != nil {
+			return nil, err
+		}
+
+		t, ok := types[typ.RecordType]
+		if !ok {
+			return nil, ErrUnknownRecordType
+		}
+
+		rec := reflect.New(t)
+		err = json.Unmarshal(raw, rec.Interface())
+		if err != nil {
+			return nil, err
+		}
+
+		recs = append(recs, rec.Elem().Interface().(records.Record))
+	}
+
+	return recs, nil
+}
+
+// marshalWithType marshals v, which must encode to a JSON object,
+// and adds a key member holding typ as its first member.
+func marshalWithType(key string, typ int, v interface{}) ([]byte, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(b) < 2 || b[0] != '{' {
+		return nil, errors.New("sflow: " + key + " value is not a JSON object")
+	}
+
+	head, err := json.Marshal(map[string]int{key: typ})
+	if err != nil {
+		return nil, err
+	}
+
+	// {"key":typ} + members of b
+	out := head[:len(head)-1]
+	if len(b) > 2 {
+		out = append(out, ',')
+	}
+	return append(out, b[1:]...), nil
+}
@@ -0,0 +1,138 @@
+package sflow
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/kanocz/sflow/records"
+)
+
+func TestJSONRoundTrip(t *testing.T) {
+	for _, name := range []string{
+		"_test/counter_sample.dump",
+		"_test/host_sample.dump",
+		"_test/flow_sample.dump",
+	} {
+		f, err := os.Open(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		dgram, err := NewDecoder(f).Decode()
+		f.Close()
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+
+		b, err := json.Marshal(dgram)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+
+		var got Datagram
+		err = json.Unmarshal(b, &got)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+
+		if len(got.Samples) != len(dgram.Samples) {
+			t.Fatalf("%s: expected %d samples, got %d", name, len(dgram.Samples), len(got.Samples))
+		}
+
+		for i, s := range dgram.Samples {
+			var want, have []records.Record
+			switch s := s.(type) {
+			case *FlowSample:
+				want = s.Records
+				have = got.Samples[i].(*FlowSample).Records
+			case *CounterSample:
+				want = s.Records
+				have = got.Samples[i].(*CounterSample).Records
+			}
+
+			if !reflect.DeepEqual(want, have) {
+				t.Errorf("%s: sample %d records differ\nexpected %#v\n     got %#v", name, i, want, have)
+			}
+		}
+
+		again, err := json.Marshal(&got)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+
+		if !bytes.Equal(b, again) {
+			t.Errorf("%s: JSON differs after round trip\nexpected %s\n     got %s", name, b, again)
+		}
+	}
+}
+
+func TestJSONDiscriminators(t *testing.T) {
+	s := &CounterSample{
+		Records: []records.Record{
+			HostCPUCounters{NumCPU: 4},
+		},
+	}
+
+	b, err := json.Marshal(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var v struct {
+		SampleType int `json:"sampleType"`
+		Records    []struct {
+			RecordType int    `json:"recordType"`
+			NumCPU     uint32 `json:"numCPU"`
+		} `json:"records"`
+	}
+
+	err = json.Unmarshal(b, &v)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if v.SampleType != TypeCounterSample {
+		t.Errorf("expected sampleType %d, got %d", TypeCounterSample, v.SampleType)
+	}
+
+	if len(v.Records) != 1 || v.Records[0].RecordType != TypeHostCPUCountersRecord || v.Records[0].NumCPU != 4 {
+		t.Errorf("unexpected records %s", b)
+	}
+
+	err = json.Unmarshal([]byte(`{"sampleType":2,"records":[{"recordType":12345}]}`), &CounterSample{})
+	if err != ErrUnknownRecordType {
+		t.Errorf("expected %v, got %v", ErrUnknownRecordType, err)
+	}
+}
+
+func TestJSONLAGPortCounters(t *testing.T) {
+	rec := LAGPortCounters{
+		ActorSystemID:       records.HardwareAddr{0x00, 0x1b, 0x21, 0x3c, 0x4d, 0x5e},
+		PartnerOperSystemID: records.HardwareAddr{0x00, 0x1b, 0x21, 0x6f, 0x70, 0x81},
+		AttachedAggID:       501,
+	}
+
+	b, err := json.Marshal(&CounterSample{Records: []records.Record{rec}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, field := range []string{`"actorSystemID":"001b213c4d5e"`, `"partnerOperSystemID":"001b216f7081"`} {
+		if !bytes.Contains(b, []byte(field)) {
+			t.Errorf("expected %s in %s", field, b)
+		}
+	}
+
+	var got CounterSample
+	err = json.Unmarshal(b, &got)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(got.Records) != 1 || !reflect.DeepEqual(got.Records[0], rec) {
+		t.Errorf("expected\n%#v\n, got\n%#v", rec, got.Records)
+	}
+}
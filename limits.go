@@ -0,0 +1,60 @@
+package sflow
+
+import (
+	"github.com/kanocz/sflow/records"
+)
+
+// ErrLimitExceeded is returned when a datagram exceeds the Limits of
+// its decoder. Decoding stops at the first limit exceeded.
+var ErrLimitExceeded = records.ErrLimitExceeded
+
+// Limits bound the resources decoding a datagram may use, so that
+// crafted datagrams cannot make the decoder allocate excessive memory.
+// Zero values mean no limit.
+type Limits struct {
+	// MaxSamples is the maximum number of samples of a datagram.
+	MaxSamples uint32
+
+	// MaxRecords is the maximum number of records of a sample.
+	MaxRecords uint32
+
+	// MaxSliceElements and MaxAllocation bound the variable length
+	// slices of records. Records are accounted for with their
+	// declared length, in addition to the slices they hold.
+	records.Limits
+}
+
+// DefaultLimits are the limits of decoders without Limits of their
+// own. Changes must happen before decoding starts.
+var DefaultLimits = Limits{
+	MaxSamples: 1024,
+	MaxRecords: 256,
+	Limits:     records.DefaultLimits,
+}
+
+// decodeState is the state of decoding a datagram.
+type decodeState struct {
+	limits *Limits
+	alloc  *records.Allocation
+
+	// errs holds the problems decoding skipped over.
+	errs []*DecodeError
+}
+
+// newDecodeState returns the state for decoding a datagram within l,
+// or within DefaultLimits if l is nil.
+func newDecodeState(l *Limits) *decodeState {
+	if l == nil {
+		l = &DefaultLimits
+	}
+
+	return &decodeState{
+		limits: l,
+		alloc:  records.NewAllocation(l.Limits),
+	}
+}
+
+// report adds e to the problems decoding skipped over.
+func (s *decodeState) report(e *DecodeError) {
+	s.errs = append(s.errs, e)
+}
@@ -0,0 +1,132 @@
+package sflow
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"testing"
+
+	"github.com/kanocz/sflow/records"
+)
+
+func TestLimitSamples(t *testing.T) {
+	// a datagram header claiming a billion samples
+	b := []byte{0, 0, 0, 5, 0, 0, 0, 1, 192, 0, 2, 1, 0, 0, 0, 0, 0, 0, 0, 1, 0, 0, 0, 1, 0x40, 0, 0, 0}
+
+	_, err := NewPacketDecoder().Decode(b)
+
+	var e *DecodeError
+	if !errors.As(err, &e) || !errors.Is(err, ErrLimitExceeded) {
+		t.Fatalf("expected the sample limit to be exceeded, got %v", err)
+	}
+
+	if e.Sample != -1 || e.Offset != int64(len(b)) {
+		t.Errorf("unexpected error %+v", e)
+	}
+
+	d := &PacketDecoder{Limits: &Limits{}}
+
+	_, err = d.Decode(b)
+	if !errors.Is(err, io.EOF) {
+		t.Errorf("expected no limits, got %v", err)
+	}
+}
+
+func TestLimitRecords(t *testing.T) {
+	buf := &bytes.Buffer{}
+
+	err := NewEncoder(net.IPv4(192, 0, 2, 1), 0, 1).Encode(buf, []Sample{&CounterSample{
+		Records: []records.Record{HostCPUCounters{}, HostCPUCounters{}, HostCPUCounters{}},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	d := &PacketDecoder{Limits: &Limits{MaxRecords: 2}}
+
+	_, err = d.Decode(buf.Bytes())
+
+	var e *DecodeError
+	if !errors.As(err, &e) || !errors.Is(err, ErrLimitExceeded) {
+		t.Fatalf("expected the record limit to be exceeded, got %v", err)
+	}
+
+	if e.Sample != 0 || e.SampleType != TypeCounterSample || e.Record != -1 || e.Offset != 6*4+4 {
+		t.Errorf("unexpected error %+v", e)
+	}
+
+	d.Limits.MaxRecords = 3
+
+	dgram, err := d.Decode(buf.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if n := len(dgram.Samples[0].(*CounterSample).Records); n != 3 {
+		t.Errorf("expected 3 records, got %d", n)
+	}
+}
+
+func TestLimitAllocation(t *testing.T) {
+	buf := &bytes.Buffer{}
+
+	err := NewEncoder(net.IPv4(192, 0, 2, 1), 0, 1).Encode(buf, []Sample{
+		&CounterSample{Records: []records.Record{HostCPUCounters{}}},
+		&CounterSample{Records: []records.Record{HostCPUCounters{}}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	size := binary.Size(HostCPUCounters{})
+
+	// the allocation is shared by the samples of a datagram
+	d := &PacketDecoder{Limits: &Limits{Limits: records.Limits{MaxAllocation: 2*size - 1}}}
+
+	_, err = d.Decode(buf.Bytes())
+
+	var e *DecodeError
+	if !errors.As(err, &e) || !errors.Is(err, ErrLimitExceeded) {
+		t.Fatalf("expected the allocation limit to be exceeded, got %v", err)
+	}
+
+	recordOffset := int64(6*4+4) + 8 + 12 + 8 + int64(size) + 8 + 12
+	if e.Sample != 1 || e.Record != 0 || e.RecordType != TypeHostCPUCountersRecord || e.Offset != recordOffset {
+		t.Errorf("unexpected error %+v", e)
+	}
+
+	d.Limits.MaxAllocation = 2 * size
+
+	_, err = d.Decode(buf.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestAllocation(t *testing.T) {
+	a := records.NewAllocation(records.Limits{MaxSliceElements: 16, MaxAllocation: 64})
+
+	if err := a.Allocate(17, 1); !errors.Is(err, ErrLimitExceeded) {
+		t.Errorf("expected too many slice elements, got %v", err)
+	}
+
+	if err := a.Allocate(16, 4); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := a.Allocate(1, 1); !errors.Is(err, ErrLimitExceeded) {
+		t.Errorf("expected the allocation to be exhausted, got %v", err)
+	}
+
+	if a.Allocated() != 64 {
+		t.Errorf("expected 64 bytes allocated, got %d", a.Allocated())
+	}
+
+	a = records.NewAllocation(records.Limits{MaxAllocation: 64})
+
+	if err := a.Allocate(1<<62, 8); !errors.Is(err, ErrLimitExceeded) {
+		t.Errorf("expected an overflowing allocation to be refused, got %v", err)
+	}
+}
@@ -0,0 +1,364 @@
+// Package openmetrics exposes the counter records of sFlow counter
+// samples as Prometheus metrics, in the OpenMetrics or the Prometheus
+// text format.
+//
+// Every numeric field of a counter record becomes a metric named after
+// the record and the field, such as sflow_host_net_bytes_in_total for
+// HostNetCounters.BytesIn. Fields that sflow.IsCounter reports as
+// counters are exposed as counters, the other ones as gauges. Series
+// are labelled with the agent address (agent), the sub-agent ID
+// (sub_agent), the data source type (source_type) and index (ifindex,
+// the ifIndex of interface data sources) and the record data format
+// (record_type). A series that is not updated for Expiry disappears,
+// so that agents that stop reporting do not leave stale values behind.
+//
+//	e := openmetrics.NewExporter()
+//	http.Handle("/metrics", e)
+//
+//	for {
+//		dgram, err := decoder.Decode(packet)
+//		...
+//		e.Add(dgram)
+//	}
+package openmetrics
+
+import (
+	"bufio"
+	"io"
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"github.com/kanocz/sflow"
+)
+
+// DefaultNamespace prefixes metric names when
+// Exporter.Namespace is not set.
+const DefaultNamespace = "sflow"
+
+// DefaultExpiry is the time a series is kept without updates when
+// Exporter.Expiry is not set.
+const DefaultExpiry = 5 * time.Minute
+
+// Content types
+const (
+	ContentTypeOpenMetrics = "application/openmetrics-text; version=1.0.0; charset=utf-8"
+	ContentTypeText        = "text/plain; version=0.0.4; charset=utf-8"
+)
+
+// metric is a numeric field of a record type.
+type metric struct {
+	index   int
+	name    string
+	help    string
+	counter bool
+}
+
+// seriesKey identifies the series of one record.
+type seriesKey struct {
+	agent            string
+	subAgentId       uint32
+	sourceIdType     byte
+	sourceIdIndexVal uint32
+	recordType       int
+}
+
+// series holds the latest values of a record.
+type series struct {
+	labels  string
+	metrics []metric
+	values  []string
+	updated time.Time
+}
+
+// Exporter collects counter records and serves them as metrics.
+// An Exporter is safe for concurrent use.
+type Exporter struct {
+	// Namespace prefixes metric names. Empty means DefaultNamespace.
+	Namespace string
+
+	// Expiry is how long a series is kept without updates.
+	// Zero means DefaultExpiry.
+	Expiry time.Duration
+
+	mu      sync.Mutex
+	now     func() time.Time
+	series  map[seriesKey]*series
+	metrics map[reflect.Type][]metric
+}
+
+// NewExporter returns a new Exporter without series.
+func NewExporter() *Exporter {
+	return &Exporter{
+		now:     time.Now,
+		series:  make(map[seriesKey]*series),
+		metrics: make(map[reflect.Type][]metric),
+	}
+}
+
+// Add updates the series of the counter samples of d. It keeps
+// no references to d, which may be released afterwards.
+func (e *Exporter) Add(d *sflow.Datagram) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	now := e.now()
+
+	for _, sample := range d.Samples {
+		s, ok := sample.(*sflow.CounterSample)
+		if !ok {
+			continue
+		}
+
+		for _, rec := range s.Records {
+			v := reflect.ValueOf(rec)
+			if v.Kind() != reflect.Struct {
+				continue
+			}
+
+			metrics := e.recordMetrics(rec.RecordType(), rec.RecordName(), v.Type())
+			if len(metrics) == 0 {
+				continue
+			}
+
+			key := seriesKey{
+				agent:            string(d.IpAddress.To16()),
+				subAgentId:       d.SubAgentId,
+				sourceIdType:     s.SourceIdType,
+				sourceIdIndexVal: s.SourceIdIndexVal,
+				recordType:       rec.RecordType(),
+			}
+
+			ser, ok := e.series[key]
+			if !ok {
+				ser = &series{
+					labels:  labels(d, s, key.recordType),
+					metrics: metrics,
+					values:  make([]string, len(metrics)),
+				}
+				e.series[key] = ser
+			}
+
+			for i, m := range metrics {
+				ser.values[i] = value(v.Field(m.index))
+			}
+			ser.updated = now
+		}
+	}
+
+	e.expire(now)
+}
+
+// expire removes the series that were not updated for Expiry.
+// e.mu must be held.
+func (e *Exporter) expire(now time.Time) {
+	expiry := e.Expiry
+	if expiry <= 0 {
+		expiry = DefaultExpiry
+	}
+
+	for key, ser := range e.series {
+		if now.Sub(ser.updated) > expiry {
+			delete(e.series, key)
+		}
+	}
+}
+
+// recordMetrics returns the metrics of records of type typ.
+// e.mu must be held.
+func (e *Exporter) recordMetrics(recordType int, recordName string, typ reflect.Type) []metric {
+	if metrics, ok := e.metrics[typ]; ok {
+		return metrics
+	}
+
+	prefix := snakeCase(strings.TrimSuffix(recordName, "Counters"))
+
+	var metrics []metric
+	for i := 0; i < typ.NumField(); i++ {
+		f := typ.Field(i)
+		if f.PkgPath != "" {
+			continue
+		}
+
+		counter := false
+
+		switch f.Type.Kind() {
+		case reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			counter = sflow.IsCounter(recordType, f.Name)
+		case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+			reflect.Float32, reflect.Float64:
+		default:
+			continue
+		}
+
+		metrics = append(metrics, metric{
+			index:   i,
+			name:    prefix + "_" + snakeCase(f.Name),
+			help:    recordName + " " + f.Name,
+			counter: counter,
+		})
+	}
+
+	e.metrics[typ] = metrics
+
+	return metrics
+}
+
+// ServeHTTP writes the current series in the OpenMetrics format if
+// the request accepts it and in the Prometheus text format otherwise.
+func (e *Exporter) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	openMetrics := strings.Contains(r.Header.Get("Accept"), "application/openmetrics-text")
+
+	if openMetrics {
+		w.Header().Set("Content-Type", ContentTypeOpenMetrics)
+	} else {
+		w.Header().Set("Content-Type", ContentTypeText)
+	}
+
+	e.write(w, openMetrics)
+}
+
+// WriteOpenMetrics writes the current series to w in the
+// OpenMetrics text format.
+func (e *Exporter) WriteOpenMetrics(w io.Writer) error {
+	return e.write(w, true)
+}
+
+// WriteText writes the current series to w in the
+// Prometheus text format.
+func (e *Exporter) WriteText(w io.Writer) error {
+	return e.write(w, false)
+}
+
+// sample is a line of a metric family.
+type sample struct {
+	labels string
+	value  string
+}
+
+// family holds the samples of a metric.
+type family struct {
+	metric
+	samples []sample
+}
+
+func (e *Exporter) write(w io.Writer, openMetrics bool) error {
+	namespace := e.Namespace
+	if namespace == "" {
+		namespace = DefaultNamespace
+	}
+
+	e.mu.Lock()
+
+	e.expire(e.now())
+
+	families := make(map[string]*family)
+	for _, ser := range e.series {
+		for i, m := range ser.metrics {
+			f, ok := families[m.name]
+			if !ok {
+				f = &family{metric: m}
+				families[m.name] = f
+			}
+
+			f.samples = append(f.samples, sample{labels: ser.labels, value: ser.values[i]})
+		}
+	}
+
+	e.mu.Unlock()
+
+	names := make([]string, 0, len(families))
+	for name := range families {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	bw := bufio.NewWriter(w)
+
+	for _, name := range names {
+		f := families[name]
+
+		sort.Slice(f.samples, func(i, j int) bool {
+			return f.samples[i].labels < f.samples[j].labels
+		})
+
+		name = namespace + "_" + name
+		sampleName, typ := name, "gauge"
+		if f.counter {
+			sampleName, typ = name+"_total", "counter"
+			if !openMetrics {
+				name = sampleName
+			}
+		}
+
+		bw.WriteString("# HELP " + name + " " + f.help + "\n")
+		bw.WriteString("# TYPE " + name + " " + typ + "\n")
+
+		for _, s := range f.samples {
+			bw.WriteString(sampleName + "{" + s.labels + "} " + s.value + "\n")
+		}
+	}
+
+	if openMetrics {
+		bw.WriteString("# EOF\n")
+	}
+
+	return bw.Flush()
+}
+
+// labels returns the label set of the series of a record.
+func labels(d *sflow.Datagram, s *sflow.CounterSample, recordType int) string {
+	agent := "-"
+	if d.IpAddress != nil {
+		agent = d.IpAddress.String()
+	}
+
+	return `agent="` + agent +
+		`",sub_agent="` + strconv.FormatUint(uint64(d.SubAgentId), 10) +
+		`",source_type="` + strconv.Itoa(int(s.SourceIdType)) +
+		`",ifindex="` + strconv.FormatUint(uint64(s.SourceIdIndexVal), 10) +
+		`",record_type="` + strconv.Itoa(recordType>>12) + ":" + strconv.Itoa(recordType&0xfff) + `"`
+}
+
+// value formats a numeric field.
+func value(v reflect.Value) string {
+	switch v.Kind() {
+	case reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(v.Uint(), 10)
+	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10)
+	}
+
+	return strconv.FormatFloat(v.Float(), 'g', -1, v.Type().Bits())
+}
+
+// snakeCase turns a Go name such as HostCPU or MIB2IPGroup
+// into host_cpu and mib2_ip_group.
+func snakeCase(s string) string {
+	r := []rune(s)
+	b := &strings.Builder{}
+
+	for i, c := range r {
+		if i > 0 && unicode.IsUpper(c) {
+			prev := r[i-1]
+			next := rune(0)
+			if i+1 < len(r) {
+				next = r[i+1]
+			}
+
+			if unicode.IsLower(prev) || unicode.IsDigit(prev) ||
+				(unicode.IsUpper(prev) && unicode.IsLower(next)) {
+				b.WriteByte('_')
+			}
+		}
+
+		b.WriteRune(unicode.ToLower(c))
+	}
+
+	return b.String()
+}
@@ -0,0 +1,183 @@
+package openmetrics
+
+import (
+	"bytes"
+	"net"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kanocz/sflow"
+	"github.com/kanocz/sflow/records"
+)
+
+func interfaceDatagram(agent net.IP, index uint32, inOctets uint64) *sflow.Datagram {
+	return &sflow.Datagram{
+		IpAddress:  agent,
+		SubAgentId: 2,
+		Samples: []sflow.Sample{&sflow.CounterSample{
+			SourceIdIndexVal: index,
+			Records: []records.Record{sflow.GenericInterfaceCounters{
+				Index:    index,
+				Speed:    1e9,
+				InOctets: inOctets,
+			}},
+		}},
+	}
+}
+
+func TestExporter(t *testing.T) {
+	e := NewExporter()
+
+	e.Add(interfaceDatagram(net.IPv4(192, 0, 2, 1), 3, 1<<60+1))
+
+	f, err := os.Open("../_test/host_sample.dump")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	dgram, err := sflow.NewDecoder(f).Decode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	e.Add(dgram)
+
+	buf := &bytes.Buffer{}
+	err = e.WriteOpenMetrics(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	out := buf.String()
+	for _, line := range []string{
+		"# HELP sflow_generic_interface_in_octets GenericInterfaceCounters InOctets\n" +
+			"# TYPE sflow_generic_interface_in_octets counter\n" +
+			`sflow_generic_interface_in_octets_total{agent="192.0.2.1",sub_agent="2",source_type="0",ifindex="3",record_type="0:1"} 1152921504606846977` + "\n",
+		"# TYPE sflow_generic_interface_speed gauge\n" +
+			`sflow_generic_interface_speed{agent="192.0.2.1",sub_agent="2",source_type="0",ifindex="3",record_type="0:1"} 1000000000` + "\n",
+		"# TYPE sflow_host_cpu_load1m gauge\n",
+		"# TYPE sflow_host_cpu_cpu_user counter\n",
+		`sflow_host_net_bytes_in_total{agent="192.168.1.7",sub_agent="100000",`,
+	} {
+		if !strings.Contains(out, line) {
+			t.Errorf("expected %q in\n%s", line, out)
+		}
+	}
+
+	if !strings.HasSuffix(out, "# EOF\n") {
+		t.Error("expected an EOF marker")
+	}
+
+	buf.Reset()
+	err = e.WriteText(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(buf.String(), "# TYPE sflow_generic_interface_in_octets_total counter\n") ||
+		strings.Contains(buf.String(), "# EOF") {
+		t.Errorf("unexpected text format\n%s", buf.String())
+	}
+}
+
+func TestGauges(t *testing.T) {
+	e := NewExporter()
+
+	e.Add(&sflow.Datagram{
+		IpAddress: net.IPv4(192, 0, 2, 1),
+		Samples: []sflow.Sample{&sflow.CounterSample{
+			SourceIdType: 2,
+			Records: []records.Record{
+				sflow.EnergyCounters{Voltage: 230000, Current: 900, Energy: 1000},
+				sflow.ProcessorCounters{CPU5s: 5000, TotalMemory: 1 << 30},
+			},
+		}},
+	})
+
+	buf := &bytes.Buffer{}
+	err := e.WriteOpenMetrics(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	out := buf.String()
+	for _, line := range []string{
+		"# TYPE sflow_energy_voltage gauge\n",
+		"# TYPE sflow_energy_current gauge\n",
+		"# TYPE sflow_energy_real_power gauge\n",
+		"# TYPE sflow_energy_energy counter\n",
+		"# TYPE sflow_processor_cpu5s gauge\n",
+		"# TYPE sflow_processor_total_memory gauge\n",
+		"# TYPE sflow_processor_free_memory gauge\n",
+	} {
+		if !strings.Contains(out, line) {
+			t.Errorf("expected %q in\n%s", line, out)
+		}
+	}
+
+	if strings.Contains(out, "sflow_energy_voltage_total") || strings.Contains(out, "sflow_processor_cpu5s_total") {
+		t.Errorf("expected gauges without a _total suffix in\n%s", out)
+	}
+}
+
+func TestExpiry(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+
+	e := NewExporter()
+	e.Namespace = "test"
+	e.Expiry = time.Minute
+	e.now = func() time.Time { return now }
+
+	e.Add(interfaceDatagram(net.IPv4(192, 0, 2, 1), 1, 100))
+	e.Add(interfaceDatagram(net.IPv4(192, 0, 2, 2), 1, 200))
+
+	now = now.Add(45 * time.Second)
+	e.Add(interfaceDatagram(net.IPv4(192, 0, 2, 2), 1, 300))
+
+	now = now.Add(30 * time.Second)
+
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest("GET", "/metrics", nil)
+	req.Header.Set("Accept", "application/openmetrics-text; version=1.0.0")
+	e.ServeHTTP(rec, req)
+
+	if ct := rec.Header().Get("Content-Type"); ct != ContentTypeOpenMetrics {
+		t.Errorf("unexpected content type %q", ct)
+	}
+
+	out := rec.Body.String()
+	if strings.Contains(out, `agent="192.0.2.1"`) {
+		t.Errorf("expected the series of 192.0.2.1 to expire\n%s", out)
+	}
+
+	if !strings.Contains(out, `test_generic_interface_in_octets_total{agent="192.0.2.2",sub_agent="2",source_type="0",ifindex="1",record_type="0:1"} 300`) {
+		t.Errorf("expected the series of 192.0.2.2\n%s", out)
+	}
+
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	if ct := rec.Header().Get("Content-Type"); ct != ContentTypeText {
+		t.Errorf("unexpected content type %q", ct)
+	}
+}
+
+func TestSnakeCase(t *testing.T) {
+	for in, out := range map[string]string{
+		"HostCPU":       "host_cpu",
+		"MIB2IPGroup":   "mib2_ip_group",
+		"InOctets":      "in_octets",
+		"FCSErrors":     "fcs_errors",
+		"Load1m":        "load1m",
+		"IEEE80211":     "ieee80211",
+		"CPUGuestNice":  "cpu_guest_nice",
+		"SpeedCPU":      "speed_cpu",
+		"JMXStatistics": "jmx_statistics",
+	} {
+		if s := snakeCase(in); s != out {
+			t.Errorf("%s: expected %s, got %s", in, out, s)
+		}
+	}
+}
@@ -0,0 +1,357 @@
+package pcap
+
+import (
+	"encoding/binary"
+	"io"
+	"net"
+	"sort"
+	"time"
+
+	"github.com/kanocz/sflow"
+)
+
+// DefaultPort is the UDP port sFlow datagrams are sent to.
+const DefaultPort = 6343
+
+// maxPendingFragments bounds the number of datagrams
+// waiting for their remaining fragments.
+const maxPendingFragments = 1024
+
+// Datagram is an sFlow datagram read from a capture.
+type Datagram struct {
+	*sflow.Datagram
+
+	// Time is the capture timestamp of the packet that completed
+	// the datagram.
+	Time time.Time
+
+	// Source is the address the datagram was sent from.
+	Source *net.UDPAddr
+
+	// Payload is the raw UDP payload.
+	Payload []byte
+}
+
+// DatagramReader reads sFlow datagrams from the UDP packets of a
+// capture, reassembling fragmented IPv4 and IPv6 packets.
+type DatagramReader struct {
+	// Port is the UDP destination port of sFlow datagrams.
+	// Packets to other ports are skipped. Zero matches every port.
+	Port int
+
+	r       *Reader
+	decoder *sflow.PacketDecoder
+	frags   map[fragmentKey]*fragments
+}
+
+// NewDatagramReader returns a DatagramReader reading the pcap or
+// pcapng file in r and accepting datagrams sent to DefaultPort.
+func NewDatagramReader(r io.Reader) (*DatagramReader, error) {
+	rd, err := NewReader(r)
+	if err != nil {
+		return nil, err
+	}
+
+	return &DatagramReader{
+		Port:    DefaultPort,
+		r:       rd,
+		decoder: sflow.NewPacketDecoder(),
+		frags:   make(map[fragmentKey]*fragments),
+	}, nil
+}
+
+// Next returns the next datagram in the capture, or io.EOF at its end.
+// If a datagram cannot be decoded it is returned without its decoded
+// Datagram together with the decoding error, and reading can continue.
+func (r *DatagramReader) Next() (*Datagram, error) {
+	for {
+		p, err := r.r.ReadPacket()
+		if err != nil {
+			return nil, err
+		}
+
+		src, sport, payload, ok := r.udp(p)
+		if !ok {
+			continue
+		}
+
+		d := &Datagram{
+			Time:    p.Time,
+			Source:  &net.UDPAddr{IP: src, Port: sport},
+			Payload: payload,
+		}
+
+		d.Datagram, err = r.decoder.Decode(payload)
+		if err != nil {
+			d.Datagram = nil
+			return d, err
+		}
+
+		return d, nil
+	}
+}
+
+// udp extracts the UDP payload of p if it is a complete datagram
+// for the reader's port.
+func (r *DatagramReader) udp(p Packet) (net.IP, int, []byte, bool) {
+	b := p.Data
+	etherType := uint16(0)
+
+	switch p.LinkType {
+	case LinkTypeEthernet:
+		if len(b) < 14 {
+			return nil, 0, nil, false
+		}
+		etherType = binary.BigEndian.Uint16(b[12:])
+		b = b[14:]
+
+		for (etherType == 0x8100 || etherType == 0x88a8) && len(b) >= 4 {
+			etherType = binary.BigEndian.Uint16(b[2:])
+			b = b[4:]
+		}
+
+	case LinkTypeNull:
+		if len(b) < 4 {
+			return nil, 0, nil, false
+		}
+		// the address family is in host byte order
+		family := binary.LittleEndian.Uint32(b)
+		if family > 0xffff {
+			family = binary.BigEndian.Uint32(b)
+		}
+		switch family {
+		case 2:
+			etherType = 0x0800
+		case 10, 24, 28, 30:
+			etherType = 0x86dd
+		}
+		b = b[4:]
+
+	case LinkTypeLinuxSLL:
+		if len(b) < 16 {
+			return nil, 0, nil, false
+		}
+		etherType = binary.BigEndian.Uint16(b[14:])
+		b = b[16:]
+
+	case LinkTypeRaw, LinkTypeIPv4, LinkTypeIPv6:
+		if len(b) < 1 {
+			return nil, 0, nil, false
+		}
+		switch b[0] >> 4 {
+		case 4:
+			etherType = 0x0800
+		case 6:
+			etherType = 0x86dd
+		}
+	}
+
+	var src net.IP
+	var ok bool
+
+	switch etherType {
+	case 0x0800:
+		src, b, ok = r.ipv4(b)
+	case 0x86dd:
+		src, b, ok = r.ipv6(b)
+	}
+
+	if !ok || len(b) < 8 {
+		return nil, 0, nil, false
+	}
+
+	sport := int(binary.BigEndian.Uint16(b[0:]))
+	dport := int(binary.BigEndian.Uint16(b[2:]))
+	length := int(binary.BigEndian.Uint16(b[4:]))
+
+	if r.Port != 0 && dport != r.Port {
+		return nil, 0, nil, false
+	}
+
+	if length < 8 || length > len(b) {
+		// truncated by the capture
+		return nil, 0, nil, false
+	}
+
+	return src, sport, b[8:length], true
+}
+
+// ipv4 returns the source address and UDP packet of an IPv4 packet.
+func (r *DatagramReader) ipv4(b []byte) (net.IP, []byte, bool) {
+	if len(b) < 20 || b[0]>>4 != 4 {
+		return nil, nil, false
+	}
+
+	headerLen := int(b[0]&0x0f) * 4
+	totalLen := int(binary.BigEndian.Uint16(b[2:]))
+	if headerLen < 20 || totalLen < headerLen || totalLen > len(b) || b[9] != 17 {
+		return nil, nil, false
+	}
+
+	src := net.IP(append([]byte(nil), b[12:16]...))
+	payload := b[headerLen:totalLen]
+
+	flags := binary.BigEndian.Uint16(b[6:])
+	more := flags&0x2000 != 0
+	offset := int(flags&0x1fff) * 8
+
+	if !more && offset == 0 {
+		return src, payload, true
+	}
+
+	key := fragmentKey{
+		id:    uint32(binary.BigEndian.Uint16(b[4:])),
+		proto: b[9],
+	}
+	copy(key.src[:], b[12:16])
+	copy(key.dst[:], b[16:20])
+
+	payload, ok := r.reassemble(key, offset, more, payload)
+	return src, payload, ok
+}
+
+// ipv6 returns the source address and UDP packet of an IPv6 packet.
+func (r *DatagramReader) ipv6(b []byte) (net.IP, []byte, bool) {
+	if len(b) < 40 || b[0]>>4 != 6 {
+		return nil, nil, false
+	}
+
+	payloadLen := int(binary.BigEndian.Uint16(b[4:]))
+	if 40+payloadLen > len(b) {
+		return nil, nil, false
+	}
+
+	src := net.IP(append([]byte(nil), b[8:24]...))
+	next := b[6]
+	h := b[40 : 40+payloadLen]
+
+	var key fragmentKey
+	copy(key.src[:], b[8:24])
+	copy(key.dst[:], b[24:40])
+
+	for {
+		switch next {
+		case 17:
+			return src, h, true
+
+		case 0, 43, 60:
+			// hop-by-hop, routing and destination options
+			if len(h) < 8 {
+				return nil, nil, false
+			}
+			n := 8 * (int(h[1]) + 1)
+			if n > len(h) {
+				return nil, nil, false
+			}
+			next, h = h[0], h[n:]
+
+		case 44:
+			if len(h) < 8 {
+				return nil, nil, false
+			}
+
+			flags := binary.BigEndian.Uint16(h[2:])
+			key.id = binary.BigEndian.Uint32(h[4:])
+			key.proto = h[0]
+			next = h[0]
+
+			payload, ok := r.reassemble(key, int(flags&0xfff8), flags&1 != 0, h[8:])
+			if !ok {
+				return nil, nil, false
+			}
+			h = payload
+
+		default:
+			return nil, nil, false
+		}
+	}
+}
+
+type fragmentKey struct {
+	src, dst [16]byte
+	id       uint32
+	proto    uint8
+}
+
+type fragment struct {
+	offset int
+	data   []byte
+}
+
+type fragments struct {
+	parts []fragment
+	// length of the reassembled payload, once the last fragment is seen
+	length int
+}
+
+// reassemble adds a fragment and returns the reassembled payload
+// once all fragments have been seen.
+func (r *DatagramReader) reassemble(key fragmentKey, offset int, more bool, data []byte) ([]byte, bool) {
+	f, ok := r.frags[key]
+	if !ok {
+		if len(r.frags) >= maxPendingFragments {
+			// drop incomplete datagrams rather than grow without bound
+			r.frags = make(map[fragmentKey]*fragments)
+		}
+
+		f = &fragments{length: -1}
+		r.frags[key] = f
+	}
+
+	if !more {
+		length := offset + len(data)
+
+		if f.length >= 0 && f.length != length {
+			// last fragments disagree on the length
+			delete(r.frags, key)
+			return nil, false
+		}
+
+		f.length = length
+	}
+
+	if f.length >= 0 && offset > f.length {
+		// past the end of the reassembled payload
+		return nil, false
+	}
+
+	f.parts = append(f.parts, fragment{offset: offset, data: append([]byte(nil), data...)})
+
+	if f.length < 0 {
+		return nil, false
+	}
+
+	sort.Slice(f.parts, func(i, j int) bool {
+		return f.parts[i].offset < f.parts[j].offset
+	})
+
+	end := 0
+	for _, part := range f.parts {
+		if part.offset >= f.length {
+			break
+		}
+		if part.offset > end {
+			// a gap remains
+			return nil, false
+		}
+		if part.offset+len(part.data) > end {
+			end = part.offset + len(part.data)
+		}
+	}
+
+	if end < f.length {
+		return nil, false
+	}
+
+	payload := make([]byte, f.length)
+	for _, part := range f.parts {
+		// parts seen before the last fragment may overlap its end
+		if part.offset < f.length {
+			copy(payload[part.offset:], part.data)
+		}
+	}
+
+	delete(r.frags, key)
+
+	return payload, true
+}
@@ -0,0 +1,127 @@
+// Package pcap reads and writes pcap and pcapng capture files.
+//
+// Sampled packet headers are written the way sflowtool's tcpdump output
+// does, so they can be opened in Wireshark or tcpdump. DatagramReader
+// goes the other way and extracts sFlow datagrams from captures of
+// the UDP traffic between agents and collectors.
+//
+// Classic pcap files hold a single link type and carry no metadata.
+// pcapng files additionally get one interface per agent and input
+// ifIndex, and a comment on every packet with the agent, input and
+// output ifIndex and sampling rate.
+package pcap
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/kanocz/sflow"
+	"github.com/kanocz/sflow/records"
+)
+
+// Link types as registered with tcpdump.org.
+const (
+	LinkTypeNull      = 0
+	LinkTypeEthernet  = 1
+	LinkTypeTokenRing = 6
+	LinkTypePPP       = 9
+	LinkTypeFDDI      = 10
+	LinkTypeRaw       = 101
+	LinkTypeLinuxSLL  = 113
+	LinkTypeIPv4      = 228
+	LinkTypeIPv6      = 229
+)
+
+// SnapLen is the snapshot length written to file and interface
+// headers; no sampled header is longer.
+const SnapLen = records.MaximumHeaderLength
+
+var (
+	ErrLinkType = errors.New("pcap: packet link type differs from the file link type")
+)
+
+// Packet is a sampled packet header together with where and
+// when it was sampled.
+type Packet struct {
+	Time time.Time
+
+	// Data holds the captured bytes of the packet.
+	Data []byte
+
+	// OriginalLength is the length of the packet on the wire,
+	// which is usually more than len(Data).
+	OriginalLength int
+
+	LinkType uint32
+
+	Agent        net.IP
+	SubAgentID   uint32
+	Input        uint32
+	Output       uint32
+	SamplingRate uint32
+}
+
+// Comment describes where p was sampled.
+func (p *Packet) Comment() string {
+	return fmt.Sprintf("agent=%s subAgent=%d input=%d output=%d samplingRate=%d",
+		p.Agent, p.SubAgentID, p.Input, p.Output, p.SamplingRate)
+}
+
+// linkTypes maps sampled header protocols to link types.
+var linkTypes = map[uint32]uint32{
+	records.HeaderProtocolEthernetISO8023:   LinkTypeEthernet,
+	records.HeaderProtocolISO88024Tokenring: LinkTypeTokenRing,
+	records.HeaderProtocolFDDI:              LinkTypeFDDI,
+	records.HeaderProtocolPPP:               LinkTypePPP,
+	records.HeaderProtocolIPv4:              LinkTypeIPv4,
+	records.HeaderProtocolIPv6:              LinkTypeIPv6,
+}
+
+// Packets returns the sampled headers of all flow samples in d,
+// which was received at t. Headers of protocols without a
+// matching link type are left out.
+func Packets(d *sflow.Datagram, t time.Time) []Packet {
+	var packets []Packet
+
+	for _, sample := range d.Samples {
+		s, ok := sample.(*sflow.FlowSample)
+		if !ok {
+			continue
+		}
+
+		for _, rec := range s.Records {
+			r, ok := rec.(records.RawPacketFlow)
+			if !ok {
+				continue
+			}
+
+			linkType, ok := linkTypes[r.Protocol]
+			if !ok {
+				continue
+			}
+
+			// stripped bytes, such as the FCS, are not part of the
+			// packet as a capture on the host would see it
+			length := int(r.FrameLength) - int(r.Stripped)
+			if length < len(r.Header) {
+				length = len(r.Header)
+			}
+
+			packets = append(packets, Packet{
+				Time:           t,
+				Data:           r.Header,
+				OriginalLength: length,
+				LinkType:       linkType,
+				Agent:          d.IpAddress,
+				SubAgentID:     d.SubAgentId,
+				Input:          s.Input,
+				Output:         s.Output,
+				SamplingRate:   s.SamplingRate,
+			})
+		}
+	}
+
+	return packets
+}
@@ -0,0 +1,317 @@
+package pcap
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"math"
+	"time"
+)
+
+const (
+	magicNanoseconds = 0xa1b23c4d
+
+	blockTypeSimplePacket = 3
+
+	optIfTsresol = 9
+)
+
+var (
+	ErrUnknownFormat = errors.New("pcap: unknown capture file format")
+	ErrInvalidBlock  = errors.New("pcap: invalid block")
+	ErrInterface     = errors.New("pcap: packet refers to an undescribed interface")
+)
+
+// ngInterface holds what an interface description says
+// about the packets of that interface.
+type ngInterface struct {
+	linkType uint32
+	snapLen  uint32
+	// timestamp units per second
+	tsUnits uint64
+}
+
+// Reader reads packets from a pcap or pcapng file.
+type Reader struct {
+	r     io.Reader
+	order binary.ByteOrder
+	ng    bool
+
+	// classic pcap
+	linkType uint32
+	nanos    bool
+
+	// pcapng, per section
+	interfaces []ngInterface
+}
+
+// NewReader reads the file header from r and returns a Reader.
+// The format and byte order are detected from the file header.
+func NewReader(r io.Reader) (*Reader, error) {
+	var magic [4]byte
+
+	_, err := io.ReadFull(r, magic[:])
+	if err != nil {
+		return nil, err
+	}
+
+	rd := &Reader{r: r}
+
+	if binary.LittleEndian.Uint32(magic[:]) == blockTypeSectionHeader {
+		rd.ng = true
+		err = rd.readSectionHeader()
+		if err != nil {
+			return nil, err
+		}
+		return rd, nil
+	}
+
+	switch {
+	case binary.LittleEndian.Uint32(magic[:]) == magicMicroseconds:
+		rd.order = binary.LittleEndian
+	case binary.BigEndian.Uint32(magic[:]) == magicMicroseconds:
+		rd.order = binary.BigEndian
+	case binary.LittleEndian.Uint32(magic[:]) == magicNanoseconds:
+		rd.order, rd.nanos = binary.LittleEndian, true
+	case binary.BigEndian.Uint32(magic[:]) == magicNanoseconds:
+		rd.order, rd.nanos = binary.BigEndian, true
+	default:
+		return nil, ErrUnknownFormat
+	}
+
+	hdr := make([]byte, 20)
+	_, err = io.ReadFull(r, hdr)
+	if err != nil {
+		return nil, err
+	}
+
+	rd.linkType = rd.order.Uint32(hdr[16:]) & 0xffff
+
+	return rd, nil
+}
+
+// ReadPacket returns the next packet. It returns io.EOF
+// at the end of the file.
+func (r *Reader) ReadPacket() (Packet, error) {
+	if r.ng {
+		return r.readNgPacket()
+	}
+
+	hdr := make([]byte, 16)
+	_, err := io.ReadFull(r.r, hdr)
+	if err != nil {
+		return Packet{}, err
+	}
+
+	capLen := r.order.Uint32(hdr[8:])
+	if capLen > math.MaxUint16*4 {
+		return Packet{}, ErrInvalidBlock
+	}
+
+	data := make([]byte, capLen)
+	_, err = io.ReadFull(r.r, data)
+	if err != nil {
+		return Packet{}, noEOF(err)
+	}
+
+	frac := time.Duration(r.order.Uint32(hdr[4:]))
+	if !r.nanos {
+		frac *= time.Microsecond
+	}
+
+	return Packet{
+		Time:           time.Unix(int64(r.order.Uint32(hdr[0:])), int64(frac)),
+		Data:           data,
+		OriginalLength: int(r.order.Uint32(hdr[12:])),
+		LinkType:       r.linkType,
+	}, nil
+}
+
+func (r *Reader) readNgPacket() (Packet, error) {
+	for {
+		blockType, body, err := r.readBlock()
+		if err != nil {
+			return Packet{}, err
+		}
+
+		switch blockType {
+		case blockTypeInterfaceDescription:
+			err = r.parseInterface(body)
+
+		case blockTypeEnhancedPacket:
+			return r.parseEnhancedPacket(body)
+
+		case blockTypeSimplePacket:
+			return r.parseSimplePacket(body)
+		}
+
+		if err != nil {
+			return Packet{}, err
+		}
+	}
+}
+
+// readSectionHeader reads the rest of a section header
+// whose block type has already been read.
+func (r *Reader) readSectionHeader() error {
+	var hdr [8]byte
+
+	_, err := io.ReadFull(r.r, hdr[:])
+	if err != nil {
+		return noEOF(err)
+	}
+
+	// the byte order magic follows the block length
+	switch binary.LittleEndian.Uint32(hdr[4:]) {
+	case byteOrderMagic:
+		r.order = binary.LittleEndian
+	case 0x4d3c2b1a:
+		r.order = binary.BigEndian
+	default:
+		return ErrUnknownFormat
+	}
+
+	length := r.order.Uint32(hdr[0:])
+	if length < 28 || length%4 != 0 {
+		return ErrInvalidBlock
+	}
+
+	rest := make([]byte, length-12)
+	_, err = io.ReadFull(r.r, rest)
+	if err != nil {
+		return noEOF(err)
+	}
+
+	r.interfaces = nil
+
+	return nil
+}
+
+// readBlock reads the next pcapng block and returns its type and body.
+func (r *Reader) readBlock() (uint32, []byte, error) {
+	var hdr [8]byte
+
+	_, err := io.ReadFull(r.r, hdr[:])
+	if err != nil {
+		return 0, nil, err
+	}
+
+	if binary.LittleEndian.Uint32(hdr[0:]) == blockTypeSectionHeader {
+		// a new section, which may change the byte order
+		r.r = io.MultiReader(bytes.NewReader(append([]byte(nil), hdr[4:]...)), r.r)
+		err = r.readSectionHeader()
+		return blockTypeSectionHeader, nil, err
+	}
+
+	blockType := r.order.Uint32(hdr[0:])
+	length := r.order.Uint32(hdr[4:])
+	if length < 12 || length%4 != 0 || length > 1<<24 {
+		return 0, nil, ErrInvalidBlock
+	}
+
+	b := make([]byte, length-8)
+	_, err = io.ReadFull(r.r, b)
+	if err != nil {
+		return 0, nil, noEOF(err)
+	}
+
+	return blockType, b[:len(b)-4], nil
+}
+
+func (r *Reader) parseInterface(body []byte) error {
+	if len(body) < 8 {
+		return ErrInvalidBlock
+	}
+
+	iface := ngInterface{
+		linkType: uint32(r.order.Uint16(body[0:])),
+		snapLen:  r.order.Uint32(body[4:]),
+		tsUnits:  1000000,
+	}
+
+	for opts := body[8:]; len(opts) >= 4; {
+		code := r.order.Uint16(opts[0:])
+		length := int(r.order.Uint16(opts[2:]))
+		if code == optEndOfOpt || 4+length > len(opts) {
+			break
+		}
+
+		if code == optIfTsresol && length >= 1 {
+			res := opts[4]
+			units := uint64(1)
+			for i := 0; i < int(res&0x7f) && i < 63; i++ {
+				if res&0x80 != 0 {
+					units *= 2
+				} else {
+					units *= 10
+				}
+			}
+			iface.tsUnits = units
+		}
+
+		opts = opts[4+(length+3)&^3:]
+	}
+
+	r.interfaces = append(r.interfaces, iface)
+
+	return nil
+}
+
+func (r *Reader) parseEnhancedPacket(body []byte) (Packet, error) {
+	if len(body) < 20 {
+		return Packet{}, ErrInvalidBlock
+	}
+
+	id := r.order.Uint32(body[0:])
+	if int(id) >= len(r.interfaces) {
+		return Packet{}, ErrInterface
+	}
+	iface := r.interfaces[id]
+
+	ts := uint64(r.order.Uint32(body[4:]))<<32 | uint64(r.order.Uint32(body[8:]))
+	capLen := r.order.Uint32(body[12:])
+	if int(capLen) > len(body)-20 {
+		return Packet{}, ErrInvalidBlock
+	}
+
+	sec := ts / iface.tsUnits
+	frac := ts % iface.tsUnits
+
+	return Packet{
+		Time:           time.Unix(int64(sec), int64(frac*uint64(time.Second)/iface.tsUnits)),
+		Data:           body[20 : 20+capLen],
+		OriginalLength: int(r.order.Uint32(body[16:])),
+		LinkType:       iface.linkType,
+	}, nil
+}
+
+func (r *Reader) parseSimplePacket(body []byte) (Packet, error) {
+	if len(body) < 4 || len(r.interfaces) == 0 {
+		return Packet{}, ErrInterface
+	}
+	iface := r.interfaces[0]
+
+	length := r.order.Uint32(body[0:])
+	capLen := length
+	if iface.snapLen > 0 && capLen > iface.snapLen {
+		capLen = iface.snapLen
+	}
+	if int(capLen) > len(body)-4 {
+		capLen = uint32(len(body) - 4)
+	}
+
+	return Packet{
+		Data:           body[4 : 4+capLen],
+		OriginalLength: int(length),
+		LinkType:       iface.linkType,
+	}, nil
+}
+
+// noEOF turns io.EOF in the middle of a record into io.ErrUnexpectedEOF.
+func noEOF(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
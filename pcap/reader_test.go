@@ -0,0 +1,309 @@
+package pcap
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"io/ioutil"
+	"net"
+	"testing"
+	"time"
+)
+
+func udpPacket(sport, dport int, payload []byte) []byte {
+	b := make([]byte, 8, 8+len(payload))
+	binary.BigEndian.PutUint16(b[0:], uint16(sport))
+	binary.BigEndian.PutUint16(b[2:], uint16(dport))
+	binary.BigEndian.PutUint16(b[4:], uint16(8+len(payload)))
+	return append(b, payload...)
+}
+
+func ethernetFrame(etherType uint16, payload []byte) []byte {
+	b := make([]byte, 14, 14+len(payload))
+	binary.BigEndian.PutUint16(b[12:], etherType)
+	return append(b, payload...)
+}
+
+// ipv4Fragment returns an IPv4 fragment of an UDP packet
+// holding data at offset, which must be a multiple of 8.
+func ipv4Fragment(src, dst net.IP, offset int, more bool, data []byte) []byte {
+	flags := uint16(offset / 8)
+	if more {
+		flags |= 0x2000
+	}
+
+	b := make([]byte, 20, 20+len(data))
+	b[0] = 0x45
+	binary.BigEndian.PutUint16(b[2:], uint16(20+len(data)))
+	binary.BigEndian.PutUint16(b[4:], 4242)
+	binary.BigEndian.PutUint16(b[6:], flags)
+	b[8] = 64
+	b[9] = 17
+	copy(b[12:], src.To4())
+	copy(b[16:], dst.To4())
+
+	return append(b, data...)
+}
+
+// ipv4Fragments splits an UDP packet into IPv4 fragments of at most
+// size payload bytes, which must be a multiple of 8.
+func ipv4Fragments(src, dst net.IP, udp []byte, size int) [][]byte {
+	var packets [][]byte
+
+	for offset := 0; offset < len(udp); offset += size {
+		end := offset + size
+		if end > len(udp) {
+			end = len(udp)
+		}
+
+		packets = append(packets, ipv4Fragment(src, dst, offset, end < len(udp), udp[offset:end]))
+	}
+
+	return packets
+}
+
+// ipv6Fragment returns an IPv6 fragment like ipv4Fragment.
+func ipv6Fragment(src, dst net.IP, offset int, more bool, data []byte) []byte {
+	flags := uint16(offset)
+	if more {
+		flags |= 1
+	}
+
+	b := make([]byte, 48, 48+len(data))
+	b[0] = 0x60
+	binary.BigEndian.PutUint16(b[4:], uint16(8+len(data)))
+	b[6] = 44
+	b[7] = 64
+	copy(b[8:], src.To16())
+	copy(b[24:], dst.To16())
+
+	b[40] = 17
+	binary.BigEndian.PutUint16(b[42:], flags)
+	binary.BigEndian.PutUint32(b[44:], 4242)
+
+	return append(b, data...)
+}
+
+// ipv6Fragments splits an UDP packet into IPv6 fragments like ipv4Fragments.
+func ipv6Fragments(src, dst net.IP, udp []byte, size int) [][]byte {
+	var packets [][]byte
+
+	for offset := 0; offset < len(udp); offset += size {
+		end := offset + size
+		if end > len(udp) {
+			end = len(udp)
+		}
+
+		packets = append(packets, ipv6Fragment(src, dst, offset, end < len(udp), udp[offset:end]))
+	}
+
+	return packets
+}
+
+func TestReaderRoundTrip(t *testing.T) {
+	packets := []Packet{
+		{Time: time.Unix(1700000000, 123456000), Data: []byte{1, 2, 3}, OriginalLength: 60, LinkType: LinkTypeEthernet},
+		{Time: time.Unix(1700000001, 0), Data: []byte{4, 5, 6, 7, 8}, OriginalLength: 5, LinkType: LinkTypeEthernet},
+	}
+
+	pcapBuf := &bytes.Buffer{}
+	pw, err := NewWriter(pcapBuf, LinkTypeEthernet)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ngBuf := &bytes.Buffer{}
+	nw, err := NewNgWriter(ngBuf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, p := range packets {
+		if err = pw.WritePacket(p); err != nil {
+			t.Fatal(err)
+		}
+		if err = nw.WritePacket(p); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, buf := range []*bytes.Buffer{pcapBuf, ngBuf} {
+		r, err := NewReader(buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for i, want := range packets {
+			got, err := r.ReadPacket()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !got.Time.Equal(want.Time) || !bytes.Equal(got.Data, want.Data) ||
+				got.OriginalLength != want.OriginalLength || got.LinkType != want.LinkType {
+				t.Errorf("packet %d: expected %+v, got %+v", i, want, got)
+			}
+		}
+
+		_, err = r.ReadPacket()
+		if err != io.EOF {
+			t.Errorf("expected io.EOF, got %v", err)
+		}
+	}
+}
+
+func TestDatagramReader(t *testing.T) {
+	payload, err := ioutil.ReadFile("../_test/flow_sample.dump")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	udp := udpPacket(40000, DefaultPort, payload)
+	src4, dst4 := net.ParseIP("192.0.2.1"), net.ParseIP("192.0.2.2")
+	src6, dst6 := net.ParseIP("2001:db8::1"), net.ParseIP("2001:db8::2")
+
+	var frames [][]byte
+	// unfragmented, then fragmented IPv4 and IPv6
+	frames = append(frames, ethernetFrame(0x0800, ipv4Fragments(src4, dst4, udp, len(udp))[0]))
+	fragments := ipv4Fragments(src4, dst4, udp, 128)
+	for _, p := range fragments {
+		frames = append(frames, ethernetFrame(0x0800, p))
+	}
+	// an UDP packet to another port is skipped
+	frames = append(frames, ethernetFrame(0x0800, ipv4Fragments(src4, dst4, udpPacket(40000, 53, payload), len(udp))[0]))
+	for _, p := range ipv6Fragments(src6, dst6, udp, 64) {
+		frames = append(frames, ethernetFrame(0x86dd, p))
+	}
+
+	buf := &bytes.Buffer{}
+	w, err := NewNgWriter(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Unix(1700000000, 0)
+	for i, frame := range frames {
+		err = w.WritePacket(Packet{
+			Time:           start.Add(time.Duration(i) * time.Second),
+			Data:           frame,
+			OriginalLength: len(frame),
+			LinkType:       LinkTypeEthernet,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	r, err := NewDatagramReader(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []struct {
+		src  net.IP
+		time time.Time
+	}{
+		{src4, start},
+		{src4, start.Add(time.Duration(len(fragments)) * time.Second)},
+		{src6, start.Add(time.Duration(len(frames)-1) * time.Second)},
+	}
+
+	for i, want := range expected {
+		d, err := r.Next()
+		if err != nil {
+			t.Fatalf("datagram %d: %v", i, err)
+		}
+
+		if !d.Source.IP.Equal(want.src) || d.Source.Port != 40000 {
+			t.Errorf("datagram %d: unexpected source %v", i, d.Source)
+		}
+
+		if !d.Time.Equal(want.time) {
+			t.Errorf("datagram %d: expected time %v, got %v", i, want.time, d.Time)
+		}
+
+		if !bytes.Equal(d.Payload, payload) {
+			t.Errorf("datagram %d: payload differs", i)
+		}
+
+		if d.IpAddress.String() != "208.85.240.52" || len(d.Samples) != 1 {
+			t.Errorf("datagram %d: unexpected datagram %v", i, d.Datagram)
+		}
+	}
+
+	_, err = r.Next()
+	if err != io.EOF {
+		t.Errorf("expected io.EOF, got %v", err)
+	}
+}
+
+func TestOverlappingFragments(t *testing.T) {
+	src4, dst4 := net.ParseIP("192.0.2.1"), net.ParseIP("192.0.2.2")
+	src6, dst6 := net.ParseIP("2001:db8::1"), net.ParseIP("2001:db8::2")
+
+	data := make([]byte, 24)
+	for i := range data {
+		data[i] = byte(i)
+	}
+
+	type fragment struct {
+		offset int
+		more   bool
+		data   []byte
+	}
+
+	for _, c := range []struct {
+		name      string
+		fragments []fragment
+		payload   []byte
+	}{
+		{
+			// a last fragment ending before fragments seen earlier
+			name: "shorter",
+			fragments: []fragment{
+				{0, true, data},
+				{16, true, data[16:]},
+				{8, false, nil},
+			},
+			payload: data[:8],
+		},
+		{
+			// a fragment past the end of the last one
+			name: "past the end",
+			fragments: []fragment{
+				{8, false, data[8:16]},
+				{24, true, data[:8]},
+				{0, true, data[:8]},
+			},
+			payload: data[:16],
+		},
+		{
+			// a second last fragment of another length
+			name: "conflicting",
+			fragments: []fragment{
+				{8, false, data[8:16]},
+				{8, false, data[8:24]},
+				{0, true, data[:8]},
+			},
+		},
+	} {
+		for _, ipv6 := range []bool{false, true} {
+			r := &DatagramReader{frags: make(map[fragmentKey]*fragments)}
+
+			var payload []byte
+			var ok bool
+
+			for _, f := range c.fragments {
+				if ipv6 {
+					_, payload, ok = r.ipv6(ipv6Fragment(src6, dst6, f.offset, f.more, f.data))
+				} else {
+					_, payload, ok = r.ipv4(ipv4Fragment(src4, dst4, f.offset, f.more, f.data))
+				}
+			}
+
+			if ok != (c.payload != nil) || !bytes.Equal(payload, c.payload) {
+				t.Errorf("%s (IPv6 %v): expected %v, got %v (%v)", c.name, ipv6, c.payload, payload, ok)
+			}
+		}
+	}
+}
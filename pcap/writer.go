@@ -0,0 +1,215 @@
+package pcap
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/kanocz/sflow"
+)
+
+const (
+	magicMicroseconds = 0xa1b2c3d4
+
+	blockTypeSectionHeader        = 0x0a0d0d0a
+	blockTypeInterfaceDescription = 1
+	blockTypeEnhancedPacket       = 6
+
+	byteOrderMagic = 0x1a2b3c4d
+
+	optEndOfOpt      = 0
+	optComment       = 1
+	optIfName        = 2
+	optIfDescription = 3
+)
+
+// Writer writes packets of a single link type to a classic pcap file
+// with microsecond timestamps.
+type Writer struct {
+	w        io.Writer
+	linkType uint32
+}
+
+// NewWriter writes the file header to w and returns a Writer
+// for packets of linkType.
+func NewWriter(w io.Writer, linkType uint32) (*Writer, error) {
+	hdr := make([]byte, 24)
+	binary.LittleEndian.PutUint32(hdr[0:], magicMicroseconds)
+	binary.LittleEndian.PutUint16(hdr[4:], 2)
+	binary.LittleEndian.PutUint16(hdr[6:], 4)
+	binary.LittleEndian.PutUint32(hdr[16:], SnapLen)
+	binary.LittleEndian.PutUint32(hdr[20:], linkType)
+
+	_, err := w.Write(hdr)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Writer{w: w, linkType: linkType}, nil
+}
+
+// WritePacket writes p, which must have the link type of the file.
+func (w *Writer) WritePacket(p Packet) error {
+	if p.LinkType != w.linkType {
+		return ErrLinkType
+	}
+
+	hdr := make([]byte, 16)
+	binary.LittleEndian.PutUint32(hdr[0:], uint32(p.Time.Unix()))
+	binary.LittleEndian.PutUint32(hdr[4:], uint32(p.Time.Nanosecond()/int(time.Microsecond)))
+	binary.LittleEndian.PutUint32(hdr[8:], uint32(len(p.Data)))
+	binary.LittleEndian.PutUint32(hdr[12:], uint32(p.OriginalLength))
+
+	_, err := w.w.Write(append(hdr, p.Data...))
+	return err
+}
+
+// WriteDatagram writes the sampled headers in d, received at t.
+// Headers of other link types than the file's are skipped.
+func (w *Writer) WriteDatagram(d *sflow.Datagram, t time.Time) error {
+	for _, p := range Packets(d, t) {
+		if p.LinkType != w.linkType {
+			continue
+		}
+
+		err := w.WritePacket(p)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// interfaceKey identifies a pcapng interface.
+type interfaceKey struct {
+	agent      string
+	subAgentID uint32
+	input      uint32
+	linkType   uint32
+}
+
+// NgWriter writes packets to a pcapng file. Packets are assigned to one
+// interface per agent, sub-agent, input ifIndex and link type, and carry
+// a comment describing where they were sampled.
+type NgWriter struct {
+	w          io.Writer
+	interfaces map[interfaceKey]uint32
+}
+
+// NewNgWriter writes the section header to w and returns an NgWriter.
+func NewNgWriter(w io.Writer) (*NgWriter, error) {
+	body := make([]byte, 16)
+	binary.LittleEndian.PutUint32(body[0:], byteOrderMagic)
+	binary.LittleEndian.PutUint16(body[4:], 1)
+	binary.LittleEndian.PutUint16(body[6:], 0)
+	// unknown section length
+	binary.LittleEndian.PutUint64(body[8:], 0xffffffffffffffff)
+
+	err := writeBlock(w, blockTypeSectionHeader, body)
+	if err != nil {
+		return nil, err
+	}
+
+	return &NgWriter{
+		w:          w,
+		interfaces: make(map[interfaceKey]uint32),
+	}, nil
+}
+
+// WritePacket writes p, preceded by an interface description
+// the first time its interface is seen.
+func (w *NgWriter) WritePacket(p Packet) error {
+	id, err := w.interfaceID(p)
+	if err != nil {
+		return err
+	}
+
+	ts := uint64(p.Time.UnixNano() / int64(time.Microsecond))
+
+	body := make([]byte, 20, 20+len(p.Data)+64)
+	binary.LittleEndian.PutUint32(body[0:], id)
+	binary.LittleEndian.PutUint32(body[4:], uint32(ts>>32))
+	binary.LittleEndian.PutUint32(body[8:], uint32(ts))
+	binary.LittleEndian.PutUint32(body[12:], uint32(len(p.Data)))
+	binary.LittleEndian.PutUint32(body[16:], uint32(p.OriginalLength))
+	body = appendPadded(body, p.Data)
+	body = appendOption(body, optComment, p.Comment())
+	body = appendOption(body, optEndOfOpt, "")
+
+	return writeBlock(w.w, blockTypeEnhancedPacket, body)
+}
+
+// WriteDatagram writes the sampled headers in d, received at t.
+func (w *NgWriter) WriteDatagram(d *sflow.Datagram, t time.Time) error {
+	for _, p := range Packets(d, t) {
+		err := w.WritePacket(p)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (w *NgWriter) interfaceID(p Packet) (uint32, error) {
+	key := interfaceKey{
+		agent:      p.Agent.String(),
+		subAgentID: p.SubAgentID,
+		input:      p.Input,
+		linkType:   p.LinkType,
+	}
+
+	if id, ok := w.interfaces[key]; ok {
+		return id, nil
+	}
+
+	body := make([]byte, 8)
+	binary.LittleEndian.PutUint16(body[0:], uint16(p.LinkType))
+	binary.LittleEndian.PutUint32(body[4:], SnapLen)
+	body = appendOption(body, optIfName, fmt.Sprintf("%s/%d", key.agent, p.Input))
+	body = appendOption(body, optIfDescription,
+		fmt.Sprintf("sFlow agent %s sub-agent %d ifIndex %d", key.agent, p.SubAgentID, p.Input))
+	body = appendOption(body, optEndOfOpt, "")
+
+	err := writeBlock(w.w, blockTypeInterfaceDescription, body)
+	if err != nil {
+		return 0, err
+	}
+
+	id := uint32(len(w.interfaces))
+	w.interfaces[key] = id
+
+	return id, nil
+}
+
+// writeBlock writes a pcapng block with the given type and body,
+// which must be padded to a multiple of 4 bytes.
+func writeBlock(w io.Writer, blockType uint32, body []byte) error {
+	length := uint32(12 + len(body))
+
+	b := make([]byte, 8, length)
+	binary.LittleEndian.PutUint32(b[0:], blockType)
+	binary.LittleEndian.PutUint32(b[4:], length)
+	b = append(b, body...)
+	b = append(b, 0, 0, 0, 0)
+	binary.LittleEndian.PutUint32(b[len(b)-4:], length)
+
+	_, err := w.Write(b)
+	return err
+}
+
+func appendOption(b []byte, code uint16, value string) []byte {
+	var hdr [4]byte
+	binary.LittleEndian.PutUint16(hdr[0:], code)
+	binary.LittleEndian.PutUint16(hdr[2:], uint16(len(value)))
+
+	return appendPadded(append(b, hdr[:]...), []byte(value))
+}
+
+// appendPadded appends v padded with zeros to a multiple of 4 bytes.
+func appendPadded(b []byte, v []byte) []byte {
+	b = append(b, v...)
+	return append(b, make([]byte, (4-len(v)%4)%4)...)
+}
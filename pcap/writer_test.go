@@ -0,0 +1,126 @@
+package pcap
+
+import (
+	"bytes"
+	"encoding/binary"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kanocz/sflow"
+)
+
+func decodeFlowSample(t *testing.T) *sflow.Datagram {
+	f, err := os.Open("../_test/flow_sample.dump")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	dgram, err := sflow.NewDecoder(f).Decode()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return dgram
+}
+
+func TestWriter(t *testing.T) {
+	buf := &bytes.Buffer{}
+
+	w, err := NewWriter(buf, LinkTypeEthernet)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ts := time.Unix(1700000000, 123456000)
+	err = w.WriteDatagram(decodeFlowSample(t), ts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b := buf.Bytes()
+	if len(b) != 24+16+128 {
+		t.Fatalf("expected %d bytes, got %d", 24+16+128, len(b))
+	}
+
+	if binary.LittleEndian.Uint32(b[0:]) != magicMicroseconds || binary.LittleEndian.Uint32(b[20:]) != LinkTypeEthernet {
+		t.Errorf("unexpected file header % x", b[:24])
+	}
+
+	rec := b[24:]
+	if binary.LittleEndian.Uint32(rec[0:]) != 1700000000 || binary.LittleEndian.Uint32(rec[4:]) != 123456 {
+		t.Errorf("unexpected timestamp % x", rec[:8])
+	}
+
+	// 318 byte frame with a 4 byte FCS stripped, 128 bytes captured
+	if binary.LittleEndian.Uint32(rec[8:]) != 128 || binary.LittleEndian.Uint32(rec[12:]) != 314 {
+		t.Errorf("unexpected lengths % x", rec[8:16])
+	}
+
+	err = w.WritePacket(Packet{LinkType: LinkTypeIPv4})
+	if err != ErrLinkType {
+		t.Errorf("expected %v, got %v", ErrLinkType, err)
+	}
+}
+
+func TestNgWriter(t *testing.T) {
+	buf := &bytes.Buffer{}
+
+	w, err := NewNgWriter(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dgram := decodeFlowSample(t)
+	ts := time.Unix(1700000000, 0)
+
+	// the second datagram reuses the interface of the first
+	for i := 0; i < 2; i++ {
+		err = w.WriteDatagram(dgram, ts)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var types []uint32
+	var comment string
+
+	for b := buf.Bytes(); len(b) > 0; {
+		blockType := binary.LittleEndian.Uint32(b[0:])
+		length := binary.LittleEndian.Uint32(b[4:])
+
+		if length%4 != 0 || int(length) > len(b) || binary.LittleEndian.Uint32(b[length-4:]) != length {
+			t.Fatalf("malformed block of type %d and length %d", blockType, length)
+		}
+
+		if blockType == blockTypeEnhancedPacket {
+			if binary.LittleEndian.Uint32(b[20:]) != 128 || binary.LittleEndian.Uint32(b[24:]) != 314 {
+				t.Errorf("unexpected lengths % x", b[20:28])
+			}
+
+			opts := b[28+128 : length-4]
+			if binary.LittleEndian.Uint16(opts[0:]) == optComment {
+				comment = string(opts[4 : 4+binary.LittleEndian.Uint16(opts[2:])])
+			}
+		}
+
+		types = append(types, blockType)
+		b = b[length:]
+	}
+
+	expected := []uint32{blockTypeSectionHeader, blockTypeInterfaceDescription, blockTypeEnhancedPacket, blockTypeEnhancedPacket}
+	if len(types) != len(expected) {
+		t.Fatalf("expected blocks %v, got %v", expected, types)
+	}
+	for i := range types {
+		if types[i] != expected[i] {
+			t.Errorf("expected blocks %v, got %v", expected, types)
+		}
+	}
+
+	if !strings.Contains(comment, "agent=208.85.240.52") || !strings.Contains(comment, "samplingRate=4096") {
+		t.Errorf("unexpected comment %q", comment)
+	}
+}
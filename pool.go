@@ -0,0 +1,89 @@
+package sflow
+
+import (
+	"sync"
+
+	"github.com/kanocz/sflow/records"
+)
+
+var (
+	datagramPool = sync.Pool{
+		New: func() interface{} { return &Datagram{} },
+	}
+
+	flowSamplePool = sync.Pool{
+		New: func() interface{} { return &FlowSample{} },
+	}
+
+	counterSamplePool = sync.Pool{
+		New: func() interface{} { return &CounterSample{} },
+	}
+
+	// bufferPool holds *[]byte scratch buffers for record decoding.
+	bufferPool = sync.Pool{
+		New: func() interface{} { b := make([]byte, 0, 512); return &b },
+	}
+)
+
+func getDatagram() *Datagram {
+	d := datagramPool.Get().(*Datagram)
+	*d = Datagram{
+		IpAddress: d.IpAddress[:0],
+		Samples:   d.Samples[:0],
+	}
+	return d
+}
+
+func getFlowSample() *FlowSample {
+	s := flowSamplePool.Get().(*FlowSample)
+	*s = FlowSample{Records: s.Records[:0]}
+	return s
+}
+
+func getCounterSample() *CounterSample {
+	s := counterSamplePool.Get().(*CounterSample)
+	*s = CounterSample{Records: s.Records[:0]}
+	return s
+}
+
+// getBuffer returns a scratch buffer of length n. It must be
+// returned with putBuffer once nothing refers to its contents.
+func getBuffer(n int) *[]byte {
+	b := bufferPool.Get().(*[]byte)
+	if cap(*b) < n {
+		*b = make([]byte, n)
+	}
+	*b = (*b)[:n]
+	return b
+}
+
+func putBuffer(b *[]byte) {
+	bufferPool.Put(b)
+}
+
+// Release returns d and its samples to the decoder's pools so that later
+// decodes can reuse them. Releasing is optional; after Release neither d
+// nor any sample or record slice obtained from it may be used.
+func (d *Datagram) Release() {
+	for i, sample := range d.Samples {
+		switch s := sample.(type) {
+		case *FlowSample:
+			clearRecords(s.Records)
+			flowSamplePool.Put(s)
+		case *CounterSample:
+			clearRecords(s.Records)
+			counterSamplePool.Put(s)
+		}
+		d.Samples[i] = nil
+	}
+
+	datagramPool.Put(d)
+}
+
+// clearRecords drops the references held by recs
+// so pooled slices do not keep records alive.
+func clearRecords(recs []records.Record) {
+	for i := range recs {
+		recs[i] = nil
+	}
+}
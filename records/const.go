@@ -32,9 +32,9 @@ const (
 var flowRecordTypes = map[uint32]interface{}{
 	TypeRawPacketFlowRecord: RawPacketFlow{},
 	// TypeEthernetFrameFlowRecord:           EthernetFrameFlow{},
-	// TypeExtendedSwitchFlowRecord:          ExtendedSwitchFlow{},
-	// TypeExtendedRouterFlowRecord:          ExtendedRouterFlow{},
-	// TypeExtendedGatewayFlowRecord:         ExtendedGatewayFlow{},
+	TypeExtendedSwitchFlowRecord:  ExtendedSwitchFlow{},
+	TypeExtendedRouterFlowRecord:  ExtendedRouterFlow{},
+	TypeExtendedGatewayFlowRecord: ExtendedGatewayFlow{},
 	// TypeExtendedSocketIPv4FlowRecord:      ExtendedSocketIPv4Flow{},
 	// TypeExtendedSocketIPv6FlowRecord:      ExtendedSocketIPv6Flow{},
 	// TypeExtendedProxySocketIPv4FlowRecord: ExtendedProxySocketIPv4Flow{},
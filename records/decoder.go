@@ -12,7 +12,15 @@ type PostDecoder interface {
 	PostDecode() error
 }
 
+// DecodeFlow decodes a flow record of type recordType within
+// DefaultLimits.
 func DecodeFlow(r io.Reader, recordType uint32) (Record, error) {
+	return DecodeFlowLimited(r, recordType, NewAllocation(DefaultLimits))
+}
+
+// DecodeFlowLimited decodes a flow record of type recordType. The
+// slices it allocates are accounted for in a.
+func DecodeFlowLimited(r io.Reader, recordType uint32, a *Allocation) (Record, error) {
 	var err error
 
 	switch recordType {
@@ -22,7 +30,7 @@ func DecodeFlow(r io.Reader, recordType uint32) (Record, error) {
 		if recordStruct, found := flowRecordTypes[recordType]; found {
 			data := reflect.New(reflect.TypeOf(recordStruct)).Elem()
 
-			_, err = decodeInto(r, data.Addr().Interface())
+			_, err = decodeInto(r, data.Addr().Interface(), a)
 
 			// Some records calculate extra data from the decoded values
 			if data, ok := data.Addr().Interface().(PostDecoder); ok {
@@ -33,10 +41,18 @@ func DecodeFlow(r io.Reader, recordType uint32) (Record, error) {
 		}
 	}
 
-	return nil, fmt.Errorf("Flow record type %d is not implemented yet\n", recordType)
+	return nil, fmt.Errorf("%w: flow record type %d", ErrUnsupportedRecordType, recordType)
 }
 
+// DecodeCounter decodes a counter record of type recordType within
+// DefaultLimits.
 func DecodeCounter(r io.Reader, recordType uint32) (Record, error) {
+	return DecodeCounterLimited(r, recordType, NewAllocation(DefaultLimits))
+}
+
+// DecodeCounterLimited decodes a counter record of type recordType.
+// The slices it allocates are accounted for in a.
+func DecodeCounterLimited(r io.Reader, recordType uint32, a *Allocation) (Record, error) {
 	var err error
 
 	switch recordType {
@@ -44,7 +60,7 @@ func DecodeCounter(r io.Reader, recordType uint32) (Record, error) {
 		if recordStruct, found := counterRecordTypes[recordType]; found {
 			data := reflect.New(reflect.TypeOf(recordStruct)).Elem()
 
-			_, err = decodeInto(r, data.Addr().Interface())
+			_, err = decodeInto(r, data.Addr().Interface(), a)
 
 			// Some records calculate extra data from the decoded values
 			if data, ok := data.Addr().Interface().(PostDecoder); ok {
@@ -55,11 +71,11 @@ func DecodeCounter(r io.Reader, recordType uint32) (Record, error) {
 		}
 	}
 
-	return nil, fmt.Errorf("Counter record type %d is not implemented yet\n", recordType)
+	return nil, fmt.Errorf("%w: counter record type %d", ErrUnsupportedRecordType, recordType)
 }
 
 // Decode an sflow packet read from 'r' into the struct given by 's' - The structs datatypes have to match the binary representation in the bytestream exactly
-func decodeInto(r io.Reader, s interface{}) (int, error) {
+func decodeInto(r io.Reader, s interface{}, a *Allocation) (int, error) {
 	var err error
 	var bytesRead int
 
@@ -141,6 +157,10 @@ func decodeInto(r io.Reader, s interface{}) (int, error) {
 						}
 					}
 
+					if err = a.Allocate(uint64(bufferSize), 1); err != nil {
+						return bytesRead, err
+					}
+
 					buffer := make([]byte, bufferSize)
 					if err = binary.Read(r, binary.BigEndian, &buffer); err != nil {
 						return bytesRead, err
@@ -149,6 +169,10 @@ func decodeInto(r io.Reader, s interface{}) (int, error) {
 
 					field.SetBytes(buffer)
 				case reflect.TypeOf(HardwareAddr{}):
+					if err = a.Allocate(6, 1); err != nil {
+						return bytesRead, err
+					}
+
 					buffer := make([]byte, 6)
 					if err = binary.Read(r, binary.BigEndian, &buffer); err != nil {
 						return bytesRead, err
@@ -166,15 +190,30 @@ func decodeInto(r io.Reader, s interface{}) (int, error) {
 					if bufferSize > 0 {
 						switch field.Type().Elem().Kind() {
 						case reflect.Struct, reflect.Slice, reflect.Array:
+							if err = a.Allocate(bufferSize, int(field.Type().Elem().Size())); err != nil {
+								return bytesRead, err
+							}
+
 							// For slices of unspecified types we call Decode revursively for every element
 							field.Set(reflect.MakeSlice(field.Type(), int(bufferSize), int(bufferSize)))
 
 							for x := 0; x < int(bufferSize); x++ {
-								decodeInto(r, field.Index(x).Addr().Interface())
+								n, err := decodeInto(r, field.Index(x).Addr().Interface(), a)
+								bytesRead += n
+								if err != nil {
+									return bytesRead, err
+								}
 							}
 						default:
-							//Apply padding
-							size := bufferSize + (4-(bufferSize%4))%4
+							// Byte slices are padded to a multiple of four bytes
+							size := bufferSize
+							if field.Type().Elem().Kind() == reflect.Uint8 {
+								size += (4 - (bufferSize % 4)) % 4
+							}
+
+							if err = a.Allocate(size, int(field.Type().Elem().Size())); err != nil {
+								return bytesRead, err
+							}
 
 							// For slices of defined length types we can look up the length and decode directly
 							field.Set(reflect.MakeSlice(field.Type(), int(size), int(size)))
@@ -190,7 +229,11 @@ func decodeInto(r io.Reader, s interface{}) (int, error) {
 			case reflect.Struct:
 				// For structs we call Decode revursively
 				field.Set(reflect.Zero(field.Type()))
-				decodeInto(r, field.Addr().Interface())
+				n, err := decodeInto(r, field.Addr().Interface(), a)
+				bytesRead += n
+				if err != nil {
+					return bytesRead, err
+				}
 
 			default:
 				return bytesRead, fmt.Errorf("Unhandled Field Kind: %s", field.Kind())
@@ -8,19 +8,19 @@ import (
 )
 
 type EthernetFrameFlow struct {
-	Dot3StatsAlignmentErrors           uint32
-	Dot3StatsFCSErrors                 uint32
-	Dot3StatsSingleCollisionFrames     uint32
-	Dot3StatsMultipleCollisionFrames   uint32
-	Dot3StatsSQETestErrors             uint32
-	Dot3StatsDeferredTransmissions     uint32
-	Dot3StatsLateCollisions            uint32
-	Dot3StatsExcessiveCollisions       uint32
-	Dot3StatsInternalMacTransmitErrors uint32
-	Dot3StatsCarrierSenseErrors        uint32
-	Dot3StatsFrameTooLongs             uint32
-	Dot3StatsInternalMacReceiveErrors  uint32
-	Dot3StatsSymbolErrors              uint32
+	Dot3StatsAlignmentErrors           uint32 `json:"dot3StatsAlignmentErrors"`
+	Dot3StatsFCSErrors                 uint32 `json:"dot3StatsFCSErrors"`
+	Dot3StatsSingleCollisionFrames     uint32 `json:"dot3StatsSingleCollisionFrames"`
+	Dot3StatsMultipleCollisionFrames   uint32 `json:"dot3StatsMultipleCollisionFrames"`
+	Dot3StatsSQETestErrors             uint32 `json:"dot3StatsSQETestErrors"`
+	Dot3StatsDeferredTransmissions     uint32 `json:"dot3StatsDeferredTransmissions"`
+	Dot3StatsLateCollisions            uint32 `json:"dot3StatsLateCollisions"`
+	Dot3StatsExcessiveCollisions       uint32 `json:"dot3StatsExcessiveCollisions"`
+	Dot3StatsInternalMacTransmitErrors uint32 `json:"dot3StatsInternalMacTransmitErrors"`
+	Dot3StatsCarrierSenseErrors        uint32 `json:"dot3StatsCarrierSenseErrors"`
+	Dot3StatsFrameTooLongs             uint32 `json:"dot3StatsFrameTooLongs"`
+	Dot3StatsInternalMacReceiveErrors  uint32 `json:"dot3StatsInternalMacReceiveErrors"`
+	Dot3StatsSymbolErrors              uint32 `json:"dot3StatsSymbolErrors"`
 	/*
 	   struct ethernet_counters {
 	      unsigned int dot3StatsAlignmentErrors;
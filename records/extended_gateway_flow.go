@@ -8,18 +8,18 @@ import (
 )
 
 type ExtendedGatewayFlow struct {
-	NextHopType          uint32
-	NextHop              net.IP `ipVersionLookUp:"NextHopType"`
-	As                   uint32
-	SrcAs                uint32
-	SrcPeerAs            uint32
-	DstAs                uint32 `ignoreOnMarshal:"true"`
-	DstPeerAs            uint32 `ignoreOnMarshal:"true"`
-	DstAsPathSegmentsLen uint32
-	DstAsPathSegments    []ExtendedGatewayFlowASPathSegment `lengthLookUp:"DstAsPathSegmentsLen"`
-	CommunitiesLen       uint32
-	Communities          []uint32 `lengthLookUp:"CommunitiesLen"`
-	LocalPref            uint32
+	NextHopType          uint32                             `json:"nextHopType"`
+	NextHop              net.IP                             `ipVersionLookUp:"NextHopType" json:"nextHop"`
+	As                   uint32                             `json:"as"`
+	SrcAs                uint32                             `json:"srcAs"`
+	SrcPeerAs            uint32                             `json:"srcPeerAs"`
+	DstAs                uint32                             `ignoreOnMarshal:"true" json:"dstAs"`
+	DstPeerAs            uint32                             `ignoreOnMarshal:"true" json:"dstPeerAs"`
+	DstAsPathSegmentsLen uint32                             `json:"dstAsPathSegmentsLen"`
+	DstAsPathSegments    []ExtendedGatewayFlowASPathSegment `lengthLookUp:"DstAsPathSegmentsLen" json:"dstAsPathSegments"`
+	CommunitiesLen       uint32                             `json:"communitiesLen"`
+	Communities          []uint32                           `lengthLookUp:"CommunitiesLen" json:"communities"`
+	LocalPref            uint32                             `json:"localPref"`
 }
 
 // As Path Segment ordering Types
@@ -29,9 +29,9 @@ const (
 )
 
 type ExtendedGatewayFlowASPathSegment struct {
-	SegType uint32 // 1: Unordered Set || 2: Ordered Set
-	SegLen  uint32
-	Seg     []uint32 `lengthLookUp:"SegLen"`
+	SegType uint32   `json:"segType"` // 1: Unordered Set || 2: Ordered Set
+	SegLen  uint32   `json:"segLen"`
+	Seg     []uint32 `lengthLookUp:"SegLen" json:"seg"`
 }
 
 func (f ExtendedGatewayFlow) String() string {
@@ -73,7 +73,7 @@ func (f ExtendedGatewayFlow) calculateBinarySize() int {
 
 func (f *ExtendedGatewayFlow) PostDecode() error {
 	for _, asSegment := range f.DstAsPathSegments {
-		if asSegment.SegType == AsPathSegmentTypeOrdered {
+		if asSegment.SegType == AsPathSegmentTypeOrdered && len(asSegment.Seg) > 0 {
 			// If the AS Segment is ordered then the last Element is the DstAs and the first the DstPeerAs
 			f.DstAs = asSegment.Seg[len(asSegment.Seg)-1:][0]
 			f.DstPeerAs = asSegment.Seg[0:1][0]
@@ -8,10 +8,10 @@ import (
 )
 
 type ExtendedRouterFlow struct {
-	NextHopType uint32
-	NextHop     net.IP `ipVersionLookUp:"NextHopType"`
-	SrcMask     uint32
-	DstMask     uint32
+	NextHopType uint32 `json:"nextHopType"`
+	NextHop     net.IP `ipVersionLookUp:"NextHopType" json:"nextHop"`
+	SrcMask     uint32 `json:"srcMask"`
+	DstMask     uint32 `json:"dstMask"`
 }
 
 func (f ExtendedRouterFlow) String() string {
@@ -8,11 +8,11 @@ import (
 
 // ExtendedSocketIPv4Flow - TypeExtendedSocketIPv4FlowRecord
 type ExtendedSocketIPv4Flow struct {
-	Protocol   uint32
-	LocalIP    net.IP `ipVersion:"4"`
-	RemoteIP   net.IP `ipVersion:"4"`
-	LocalPort  uint32
-	RemotePort uint32
+	Protocol   uint32 `json:"protocol"`
+	LocalIP    net.IP `ipVersion:"4" json:"localIP"`
+	RemoteIP   net.IP `ipVersion:"4" json:"remoteIP"`
+	LocalPort  uint32 `json:"localPort"`
+	RemotePort uint32 `json:"remotePort"`
 }
 
 func (f ExtendedSocketIPv4Flow) RecordName() string {
@@ -35,11 +35,11 @@ func (f ExtendedSocketIPv4Flow) Encode(w io.Writer) error {
 
 // ExtendedSocketIPv6Flow - TypeExtendedSocketIPv6FlowRecord
 type ExtendedSocketIPv6Flow struct {
-	Protocol   uint32
-	LocalIP    net.IP `ipVersion:"6"`
-	RemoteIP   net.IP `ipVersion:"6"`
-	LocalPort  uint32
-	RemotePort uint32
+	Protocol   uint32 `json:"protocol"`
+	LocalIP    net.IP `ipVersion:"6" json:"localIP"`
+	RemoteIP   net.IP `ipVersion:"6" json:"remoteIP"`
+	LocalPort  uint32 `json:"localPort"`
+	RemotePort uint32 `json:"remotePort"`
 }
 
 func (f ExtendedSocketIPv6Flow) RecordName() string {
@@ -62,7 +62,7 @@ func (f ExtendedSocketIPv6Flow) Encode(w io.Writer) error {
 
 // ExtendedProxySocketIPv4 - TypeExtendedProxySocketIPv4FlowRecord
 type ExtendedProxySocketIPv4Flow struct {
-	Socket ExtendedSocketIPv4Flow
+	Socket ExtendedSocketIPv4Flow `json:"socket"`
 }
 
 func (f ExtendedProxySocketIPv4Flow) RecordName() string {
@@ -85,7 +85,7 @@ func (f ExtendedProxySocketIPv4Flow) Encode(w io.Writer) error {
 
 // ExtendedProxySocketIPv6 - TypeExtendedProxySocketIPv6FlowRecord
 type ExtendedProxySocketIPv6Flow struct {
-	Socket ExtendedSocketIPv6Flow
+	Socket ExtendedSocketIPv6Flow `json:"socket"`
 }
 
 func (f ExtendedProxySocketIPv6Flow) RecordName() string {
@@ -8,10 +8,10 @@ import (
 
 // ExtendedSwitchFlow is an extended switch flow record.
 type ExtendedSwitchFlow struct {
-	SourceVlan          uint32
-	SourcePriority      uint32
-	DestinationVlan     uint32
-	DestinationPriority uint32
+	SourceVlan          uint32 `json:"sourceVlan"`
+	SourcePriority      uint32 `json:"sourcePriority"`
+	DestinationVlan     uint32 `json:"destinationVlan"`
+	DestinationPriority uint32 `json:"destinationPriority"`
 }
 
 func (f ExtendedSwitchFlow) String() string {
@@ -19,26 +19,26 @@ const (
 
 // HTTPRequestFlow - TypeHTTPRequestFlowRecord
 type HTTPRequestFlow struct {
-	Method       uint32
-	Protocol     uint32 /* HTTP protocol version: Encoded as major_number * 1000 + minor_number. e.g. HTTP1.1 is encoded as 1001 */
-	URILen       uint32
-	URI          []byte `lengthLookUp:"URILen"` /* URI exactly as it came from the client */
-	HostLen      uint32
-	Host         []byte `lengthLookUp:"HostLen"` /* Host value from request header */
-	RefererLen   uint32
-	Referer      []byte `lengthLookUp:"RefererLen"` /* Referer value from request header */
-	UserAgentLen uint32
-	UserAgent    []byte `lengthLookUp:"UserAgentLen"` /* User-Agent value from request header */
-	XFFLen       uint32
-	XFF          []byte `lengthLookUp:"XFFLen"` /* X-Forwarded-For value from request header */
-	AuthUserLen  uint32
-	AuthUser     []byte `lengthLookUp:"AuthUserLen"` /* RFC 1413 identity of user*/
-	MimeTypeLen  uint32
-	MimeType     []byte `lengthLookUp:"MimeTypeLen"` /* Mime-Type of response */
-	ReqBytes     uint64 /* Content-Length of request */
-	RespBytes    uint64 /* Content-Length of response */
-	Duration     uint32 /* duration of the operation (in microseconds) */
-	Status       uint32 /* HTTP status code */
+	Method       uint32 `json:"method"`
+	Protocol     uint32 `json:"protocol"` /* HTTP protocol version: Encoded as major_number * 1000 + minor_number. e.g. HTTP1.1 is encoded as 1001 */
+	URILen       uint32 `json:"uriLen"`
+	URI          []byte `lengthLookUp:"URILen" json:"uri"` /* URI exactly as it came from the client */
+	HostLen      uint32 `json:"hostLen"`
+	Host         []byte `lengthLookUp:"HostLen" json:"host"` /* Host value from request header */
+	RefererLen   uint32 `json:"refererLen"`
+	Referer      []byte `lengthLookUp:"RefererLen" json:"referer"` /* Referer value from request header */
+	UserAgentLen uint32 `json:"userAgentLen"`
+	UserAgent    []byte `lengthLookUp:"UserAgentLen" json:"userAgent"` /* User-Agent value from request header */
+	XFFLen       uint32 `json:"xffLen"`
+	XFF          []byte `lengthLookUp:"XFFLen" json:"xff"` /* X-Forwarded-For value from request header */
+	AuthUserLen  uint32 `json:"authUserLen"`
+	AuthUser     []byte `lengthLookUp:"AuthUserLen" json:"authUser"` /* RFC 1413 identity of user*/
+	MimeTypeLen  uint32 `json:"mimeTypeLen"`
+	MimeType     []byte `lengthLookUp:"MimeTypeLen" json:"mimeType"` /* Mime-Type of response */
+	ReqBytes     uint64 `json:"reqBytes"`                            /* Content-Length of request */
+	RespBytes    uint64 `json:"respBytes"`                           /* Content-Length of response */
+	Duration     uint32 `json:"duration"`                            /* duration of the operation (in microseconds) */
+	Status       uint32 `json:"status"`                              /* HTTP status code */
 }
 
 // RecordName returns the Name of this flow record
@@ -59,21 +59,21 @@ func (f HTTPRequestFlow) Encode(w io.Writer) error {
 
 // HTTPCounters - TypeHTTPCounterRecord
 type HTTPCounter struct {
-	MethodOptionCount  uint32
-	MethodGetCount     uint32
-	MethodHeadCount    uint32
-	MethodPostCount    uint32
-	MethodPutCount     uint32
-	MethodDeleteCount  uint32
-	MethodTraceCount   uint32
-	MethodConnectCount uint32
-	MethodOtherCount   uint32
-	Status1XXCount     uint32
-	Status2XXCount     uint32
-	Status3XXCount     uint32
-	Status4XXCount     uint32
-	Status5XXCount     uint32
-	StatusOtherCount   uint32
+	MethodOptionCount  uint32 `json:"methodOptionCount"`
+	MethodGetCount     uint32 `json:"methodGetCount"`
+	MethodHeadCount    uint32 `json:"methodHeadCount"`
+	MethodPostCount    uint32 `json:"methodPostCount"`
+	MethodPutCount     uint32 `json:"methodPutCount"`
+	MethodDeleteCount  uint32 `json:"methodDeleteCount"`
+	MethodTraceCount   uint32 `json:"methodTraceCount"`
+	MethodConnectCount uint32 `json:"methodConnectCount"`
+	MethodOtherCount   uint32 `json:"methodOtherCount"`
+	Status1XXCount     uint32 `json:"status1XXCount"`
+	Status2XXCount     uint32 `json:"status2XXCount"`
+	Status3XXCount     uint32 `json:"status3XXCount"`
+	Status4XXCount     uint32 `json:"status4XXCount"`
+	Status5XXCount     uint32 `json:"status5XXCount"`
+	StatusOtherCount   uint32 `json:"statusOtherCount"`
 }
 
 // RecordName returns the Name of this flow record
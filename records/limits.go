@@ -0,0 +1,76 @@
+package records
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrLimitExceeded is returned when decoding would exceed its Limits.
+var ErrLimitExceeded = errors.New("sflow: decoding limit exceeded")
+
+// Limits bound the memory decoding may allocate for lengths and counts
+// read from the wire, so that crafted records cannot make the decoder
+// allocate excessive memory. Zero values mean no limit.
+type Limits struct {
+	// MaxSliceElements is the maximum number of elements of a variable
+	// length slice, such as ExtendedGatewayFlow.Communities.
+	MaxSliceElements uint32
+
+	// MaxAllocation is the maximum number of bytes allocated for the
+	// records of a datagram.
+	MaxAllocation int
+}
+
+// DefaultLimits are the limits of DecodeFlow and DecodeCounter.
+var DefaultLimits = Limits{
+	MaxSliceElements: 65536,
+	MaxAllocation:    4 << 20,
+}
+
+// Allocation accounts for the memory allocated while decoding the
+// records of a datagram, and enforces its Limits. A nil Allocation
+// has no limits. An Allocation is not safe for concurrent use.
+type Allocation struct {
+	limits    Limits
+	allocated int
+}
+
+// NewAllocation returns a new Allocation within l.
+func NewAllocation(l Limits) *Allocation {
+	return &Allocation{limits: l}
+}
+
+// Allocate accounts for a slice of n elements of size bytes each. It
+// returns an error wrapping ErrLimitExceeded if the slice has more
+// elements than allowed, or if it does not fit into the allocation.
+func (a *Allocation) Allocate(n uint64, size int) error {
+	if a == nil {
+		return nil
+	}
+
+	if a.limits.MaxSliceElements > 0 && n > uint64(a.limits.MaxSliceElements) {
+		return fmt.Errorf("%w: %d slice elements, maximum %d", ErrLimitExceeded,
+			n, a.limits.MaxSliceElements)
+	}
+
+	if a.limits.MaxAllocation > 0 {
+		remaining := uint64(a.limits.MaxAllocation - a.allocated)
+		if size > 0 && n > remaining/uint64(size) {
+			return fmt.Errorf("%w: allocating %d elements of %d bytes, %d of %d bytes allocated",
+				ErrLimitExceeded, n, size, a.allocated, a.limits.MaxAllocation)
+		}
+	}
+
+	a.allocated += int(n) * size
+
+	return nil
+}
+
+// Allocated returns the number of bytes allocated so far.
+func (a *Allocation) Allocated() int {
+	if a == nil {
+		return 0
+	}
+
+	return a.allocated
+}
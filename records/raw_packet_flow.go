@@ -32,23 +32,23 @@ const (
 	HeaderTypeIPv4 = "0800"
 	HeaderTypeIPv6 = "86DD"
 
-//IPX: type_len == 0x0200 || type_len == 0x0201 || type_len == 0x0600
+// IPX: type_len == 0x0200 || type_len == 0x0201 || type_len == 0x0600
 )
 
 // RawPacketFlow is a raw Ethernet header flow record.
 type RawPacketFlow struct {
-	Protocol      uint32
-	FrameLength   uint32
-	Stripped      uint32
-	HeaderSize    uint32
-	Header        []byte
-	DecodedHeader map[string]interface{}
+	Protocol      uint32                 `json:"protocol"`
+	FrameLength   uint32                 `json:"frameLength"`
+	Stripped      uint32                 `json:"stripped"`
+	HeaderSize    uint32                 `json:"headerSize"`
+	Header        []byte                 `json:"header"`
+	DecodedHeader map[string]interface{} `json:"decodedHeader"`
 }
 
 // EthernetHeader as found in RawPacketFlow.Header
 type EthernetHeader struct {
-	DstMac HardwareAddr
-	SrcMac HardwareAddr
+	DstMac HardwareAddr `json:"dstMac"`
+	SrcMac HardwareAddr `json:"srcMac"`
 }
 
 // HardwareAddr alias of net.HardwareAddr to be able to add JSON Marhshalling
@@ -60,65 +60,104 @@ func (e HardwareAddr) MarshalJSON() ([]byte, error) {
 	return json.Marshal(strings.Replace(fmt.Sprintf("%s", x), ":", "", -1))
 }
 
-// UnmarshalJSON reads a MAC Address via net.ParseMAC into HardwareAddr
+// UnmarshalJSON reads a MAC Address as written by MarshalJSON, or in
+// any format accepted by net.ParseMAC, into HardwareAddr
 func (e *HardwareAddr) UnmarshalJSON(value []byte) error {
-	x, err := net.ParseMAC(string(value))
+	var str string
+	if err := json.Unmarshal(value, &str); err != nil {
+		return err
+	}
+
+	if str == "" {
+		*e = nil
+		return nil
+	}
+
+	if len(str) == 12 {
+		x, err := hex.DecodeString(str)
+		if err != nil {
+			return err
+		}
+		*e = HardwareAddr(x)
+		return nil
+	}
+
+	x, err := net.ParseMAC(str)
 	*e = HardwareAddr(x)
 	return err
 }
 
+// UnmarshalJSON reads a RawPacketFlow and rebuilds
+// DecodedHeader from the header bytes.
+func (f *RawPacketFlow) UnmarshalJSON(b []byte) error {
+	type X RawPacketFlow
+	x := X{}
+
+	err := json.Unmarshal(b, &x)
+	if err != nil {
+		return err
+	}
+
+	*f = RawPacketFlow(x)
+
+	// we don't care so much if it succeeds, see DecodeRawPacketFlow
+	f.decodeHeader(f.Protocol)
+
+	return nil
+}
+
 // IPv4Header as found in RawPacketFlow.Header
 type IPv4Header struct {
-	VersionAndLen uint8
-	Tos           uint8
-	TotLen        uint16
-	ID            uint16
-	FragOff       uint16
-	TTL           uint8
-	Protocol      uint8
-	Check         uint16
-	SrcAddr       net.IP `ipVersion:"4"`
-	DstAddr       net.IP `ipVersion:"4"`
+	VersionAndLen uint8  `json:"versionAndLen"`
+	Tos           uint8  `json:"tos"`
+	TotLen        uint16 `json:"totLen"`
+	ID            uint16 `json:"id"`
+	FragOff       uint16 `json:"fragOff"`
+	TTL           uint8  `json:"ttl"`
+	Protocol      uint8  `json:"protocol"`
+	Check         uint16 `json:"check"`
+	SrcAddr       net.IP `ipVersion:"4" json:"srcAddr"`
+	DstAddr       net.IP `ipVersion:"4" json:"dstAddr"`
 }
 
 // IPv6Header as found in RawPacketFlow.Header
 type IPv6Header struct {
-	VersionAndPriority uint8
-	Label1             uint8
-	Label2             uint8
-	Label3             uint8
-	PayloadLength      uint16
-	NextHeader         uint8
-	TTL                uint8
-	SrcAddr            net.IP `ipVersion:"6"`
-	DstAddr            net.IP `ipVersion:"6"`
+	VersionAndPriority uint8  `json:"versionAndPriority"`
+	Label1             uint8  `json:"label1"`
+	Label2             uint8  `json:"label2"`
+	Label3             uint8  `json:"label3"`
+	PayloadLength      uint16 `json:"payloadLength"`
+	NextHeader         uint8  `json:"nextHeader"`
+	TTL                uint8  `json:"ttl"`
+	SrcAddr            net.IP `ipVersion:"6" json:"srcAddr"`
+	DstAddr            net.IP `ipVersion:"6" json:"dstAddr"`
 }
 
 // TCPHeader as found in RawPacketFlow.Header
 type TCPHeader struct {
-	SrcPort  uint16
-	DstPort  uint16
-	Seq      uint32
-	Ack      uint32
-	UnUsed   uint8
-	Flags    uint8
-	Window   uint16
-	Checksum uint16
-	Urgent   uint16
+	SrcPort  uint16 `json:"srcPort"`
+	DstPort  uint16 `json:"dstPort"`
+	Seq      uint32 `json:"seq"`
+	Ack      uint32 `json:"ack"`
+	UnUsed   uint8  `json:"unUsed"`
+	Flags    uint8  `json:"flags"`
+	Window   uint16 `json:"window"`
+	Checksum uint16 `json:"checksum"`
+	Urgent   uint16 `json:"urgent"`
 }
 
 // UDPHeader as found in RawPacketFlow.Header
 type UDPHeader struct {
-	SrcPort  uint16
-	DstPort  uint16
-	Length   uint16
-	Checksum uint16
+	SrcPort  uint16 `json:"srcPort"`
+	DstPort  uint16 `json:"dstPort"`
+	Length   uint16 `json:"length"`
+	Checksum uint16 `json:"checksum"`
 }
 
 // ICMPHeader as found in RawPacketFlow.Header
 type ICMPHeader struct {
-	Type uint8
-	Code uint8
+	Type uint8 `json:"type"`
+	Code uint8 `json:"code"`
 }
 
 func (f RawPacketFlow) String() string {
@@ -143,7 +182,7 @@ func (f *RawPacketFlow) decodeIPHeader(ipVersion int, h io.Reader) error {
 	if ipVersion == 4 {
 		ip := IPv4Header{}
 
-		_, err = decodeInto(h, &ip)
+		_, err = decodeInto(h, &ip, nil)
 		f.DecodedHeader["ip"] = ip
 
 		if err != nil {
@@ -158,7 +197,7 @@ func (f *RawPacketFlow) decodeIPHeader(ipVersion int, h io.Reader) error {
 			break
 		case IPProtocolTCP:
 			tcp := TCPHeader{}
-			_, err = decodeInto(h, &tcp)
+			_, err = decodeInto(h, &tcp, nil)
 			f.DecodedHeader["tcp"] = tcp
 
 			if err != nil {
@@ -166,14 +205,14 @@ func (f *RawPacketFlow) decodeIPHeader(ipVersion int, h io.Reader) error {
 			}
 		case IPProtocolUDP:
 			udp := UDPHeader{}
-			_, err = decodeInto(h, &udp)
+			_, err = decodeInto(h, &udp, nil)
 			f.DecodedHeader["udp"] = udp
 			if err != nil {
 				return err
 			}
 		case IPProtocolICMP:
 			icmp := ICMPHeader{}
-			_, err = decodeInto(h, &icmp)
+			_, err = decodeInto(h, &icmp, nil)
 			f.DecodedHeader["icmp"] = icmp
 			if err != nil {
 				return err
@@ -212,7 +251,7 @@ func (f *RawPacketFlow) decodeHeader(headerType uint32) error {
 	switch headerType {
 	case HeaderProtocolEthernetISO8023:
 		ethernet := EthernetHeader{}
-		_, err = decodeInto(h, &ethernet)
+		_, err = decodeInto(h, &ethernet, nil)
 		f.DecodedHeader["ethernet"] = ethernet
 		if err != nil {
 			return err
@@ -257,7 +296,7 @@ func (f *RawPacketFlow) decodeHeader(headerType uint32) error {
 			return err
 		}
 	default:
-		fmt.Printf("Unknown Headertype: %d\n", headerType)
+		// headers of other protocols are left undecoded
 	}
 
 	//fmt.Printf("Headers: %+#v\n", f.DecodedHeader)
@@ -301,7 +340,7 @@ func DecodeRawPacketFlow(r io.Reader) (RawPacketFlow, error) {
 
 	f.Header = make([]byte, f.HeaderSize+padding)
 
-	_, err = r.Read(f.Header)
+	_, err = io.ReadFull(r, f.Header)
 	if err != nil {
 		return f, err
 	}
@@ -8,6 +8,10 @@ import (
 var (
 	ErrEncodingRecord = errors.New("sflow: failed to encode record")
 	ErrDecodingRecord = errors.New("sflow: failed to decode record")
+
+	// ErrUnsupportedRecordType is returned by DecodeFlow and
+	// DecodeCounter for record formats they do not know.
+	ErrUnsupportedRecordType = errors.New("sflow: unsupported record type")
 )
 
 type Record interface {
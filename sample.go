@@ -1,8 +1,10 @@
 package sflow
 
 import (
+	"bytes"
 	"encoding/binary"
 	"errors"
+	"fmt"
 	"io"
 
 	"github.com/kanocz/sflow/records"
@@ -25,32 +27,166 @@ type Sample interface {
 	encode(w io.Writer) error
 }
 
-func decodeSample(r io.ReadSeeker) (Sample, error) {
+// decodeSample decodes the next sample of a datagram from a buffer
+// bounded to its declared length, so that the next sample is found even
+// if this one does not decode. Samples that do not decode, or that do
+// not use their full length, are reported to d, and nil is returned
+// for the ones that do not decode. Errors are only returned when the
+// datagram ends before the sample, or when it exceeds the limits of d.
+func decodeSample(r io.ReadSeeker, d *decodeState) (Sample, error) {
 	format, length, err := uint32(0), uint32(0), error(nil)
 
+	start := offset(r)
+
 	err = binary.Read(r, binary.BigEndian, &format)
 	if err != nil {
-		return nil, err
+		return nil, sampleError(start, 0, err)
 	}
 
 	err = binary.Read(r, binary.BigEndian, &length)
 	if err != nil {
-		return nil, err
+		return nil, sampleError(start, format, err)
+	}
+
+	if length > MaximumSampleLength {
+		return nil, sampleError(start, format, fmt.Errorf("sflow: sample length more than %d: %d",
+			MaximumSampleLength, length))
+	}
+
+	buf := getBuffer(int(length))
+	defer putBuffer(buf)
+
+	_, err = io.ReadFull(r, *buf)
+	if err != nil {
+		return nil, sampleError(start, format, err)
 	}
 
+	body := bytes.NewReader(*buf)
+	reported := len(d.errs)
+
+	var sample Sample
+
 	switch format {
 	case TypeCounterSample:
-		return decodeCounterSample(r)
+		sample, err = decodeCounterSample(body, d)
 
 	case TypeFlowSample:
-		return decodeFlowSample(r)
+		sample, err = decodeFlowSample(body, d)
 
 	default:
-		_, err = r.Seek(int64(length), 1)
+		err = ErrUnknownSampleType
+	}
+
+	// record offsets are relative to the sample body
+	for _, e := range d.errs[reported:] {
+		e.Offset += start + 8
+		e.SampleType = format
+	}
+
+	if e, ok := err.(*DecodeError); ok {
+		e.Offset += start + 8
+	}
+
+	if errors.Is(err, ErrLimitExceeded) {
+		return nil, sampleError(start, format, err)
+	}
+
+	if err != nil {
+		d.report(sampleError(start, format, err))
+		return nil, nil
+	}
+
+	if body.Len() > 0 {
+		d.report(sampleError(start, format, &LengthError{
+			Length: length,
+			Used:   length - uint32(body.Len()),
+		}))
+	}
+
+	return sample, nil
+}
+
+// decodeRecords decodes the numRecords records that follow in the
+// sample body r and appends them to recs. Every record is decoded from
+// a buffer bounded to its declared length, so that a record that does
+// not decode leaves the following ones intact. Records that do not
+// decode are left out and reported to d, as are records that do not
+// use their full length. Records of unsupported formats are left out
+// silently. Errors are only returned for records exceeding the limits
+// of d.
+func decodeRecords(r io.ReadSeeker, numRecords uint32, recs []records.Record, d *decodeState,
+	decode func(r io.Reader, format, length uint32, a *records.Allocation) (records.Record, error)) ([]records.Record, error) {
+
+	if max := d.limits.MaxRecords; max > 0 && numRecords > max {
+		return recs, fmt.Errorf("%w: %d records, maximum %d", ErrLimitExceeded, numRecords, max)
+	}
+
+	for i := uint32(0); i < numRecords; i++ {
+		format, length := uint32(0), uint32(0)
+
+		start := offset(r)
+
+		err := binary.Read(r, binary.BigEndian, &format)
+		if err != nil {
+			d.report(recordError(start, i, format, err))
+			return recs, nil
+		}
+
+		err = binary.Read(r, binary.BigEndian, &length)
+		if err != nil {
+			d.report(recordError(start, i, format, err))
+			return recs, nil
+		}
+
+		if length > MaximumRecordLength {
+			d.report(recordError(start, i, format, fmt.Errorf("sflow: record length more than %d: %d",
+				MaximumRecordLength, length)))
+			return recs, nil
+		}
+
+		// the decoded record takes about its encoded length
+		err = d.alloc.Allocate(uint64(length), 1)
+		if err != nil {
+			return recs, recordError(start, i, format, err)
+		}
+
+		buf := getBuffer(int(length))
+
+		_, err = io.ReadFull(r, *buf)
 		if err != nil {
-			return nil, err
+			// the record does not fit into the sample
+			putBuffer(buf)
+			d.report(recordError(start, i, format, err))
+			return recs, nil
+		}
+
+		body := bytes.NewReader(*buf)
+
+		rec, err := decode(body, format, length, d.alloc)
+		used := length - uint32(body.Len())
+
+		putBuffer(buf)
+
+		if errors.Is(err, ErrLimitExceeded) {
+			return recs, recordError(start, i, format, err)
 		}
 
-		return nil, ErrUnknownSampleType
+		if err != nil {
+			if !errors.Is(err, records.ErrUnsupportedRecordType) {
+				d.report(recordError(start, i, format, err))
+			}
+			continue
+		}
+
+		if used != length {
+			d.report(recordError(start, i, format, &LengthError{
+				Length: length,
+				Used:   used,
+			}))
+		}
+
+		recs = append(recs, rec)
 	}
+
+	return recs, nil
 }
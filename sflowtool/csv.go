@@ -0,0 +1,51 @@
+package sflowtool
+
+import (
+	"io"
+	"io/ioutil"
+
+	"github.com/kanocz/sflow"
+)
+
+// WriteCSV writes d to w in sflowtool's line format: a FLOW line for
+// each flow sample and a CNTR line with the generic interface counters
+// of each counter sample.
+//
+//	FLOW,agent,inputPort,outputPort,srcMAC,dstMAC,ethernetType,in_vlan,out_vlan,srcIP,dstIP,IPProtocol,ipTos,ipTTL,srcPort,dstPort,tcpFlags,packetSize,IPSize,samplingRate
+//	CNTR,agent,ifIndex,ifType,ifSpeed,ifDirection,ifStatus,ifInOctets,ifInUcastPkts,ifInMulticastPkts,ifInBroadcastPkts,ifInDiscards,ifInErrors,ifInUnknownProtos,ifOutOctets,ifOutUcastPkts,ifOutMulticastPkts,ifOutBroadcastPkts,ifOutDiscards,ifOutErrors,ifPromiscuousMode
+func WriteCSV(w io.Writer, d *sflow.Datagram) error {
+	p := &printer{w: w}
+	agent := address(d.IpAddress)
+
+	for _, sample := range d.Samples {
+		switch s := sample.(type) {
+		case *sflow.FlowSample:
+			pkt := writeFlowSampleText(&printer{w: ioutil.Discard}, s)
+
+			p.printf("FLOW,%s,%d,%d,", agent, s.Input, s.Output)
+			p.printf("%x,%x,0x%04x,%d,%d", pkt.srcMAC[:], pkt.dstMAC[:], pkt.ethType, pkt.inVlan, pkt.outVlan)
+			p.printf(",%s,%s,%d,0x%02x,%d,%d,%d,0x%02x",
+				address(pkt.srcIP), address(pkt.dstIP), pkt.ipProtocol, pkt.ipTos, pkt.ipTTL,
+				pkt.srcPort, pkt.dstPort, pkt.tcpFlags)
+			p.printf(",%d,%d,%d\n", pkt.size, pkt.ipSize, s.SamplingRate)
+
+		case *sflow.CounterSample:
+			c := sflow.GenericInterfaceCounters{}
+			for _, rec := range s.Records {
+				if r, ok := rec.(sflow.GenericInterfaceCounters); ok {
+					c = r
+				}
+			}
+
+			p.printf("CNTR,%s,", agent)
+			p.printf("%d,%d,%d,%d,%d,%d,%d,%d,%d,%d,%d,%d,%d,%d,%d,%d,%d,%d,%d\n",
+				c.Index, c.Type, c.Speed, c.Direction, c.Status,
+				c.InOctets, c.InUnicastPackets, c.InMulticastPackets, c.InBroadcastPackets,
+				c.InDiscards, c.InErrors, c.InUnknownProtocols,
+				c.OutOctets, c.OutUnicastPackets, c.OutMulticastPackets, c.OutBroadcastPackets,
+				c.OutDiscards, c.OutErrors, c.PromiscuousMode)
+		}
+	}
+
+	return p.err
+}
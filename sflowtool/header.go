@@ -0,0 +1,252 @@
+package sflowtool
+
+import (
+	"encoding/binary"
+	"net"
+	"strings"
+
+	"github.com/kanocz/sflow/records"
+)
+
+// packet holds the fields of a flow sample that end up in a FLOW line.
+type packet struct {
+	srcMAC, dstMAC  [6]byte
+	ethType         int
+	inVlan, outVlan uint32
+	srcIP, dstIP    net.IP
+	ipProtocol      uint8
+	ipTos           uint8
+	ipTTL           uint8
+	srcPort         uint16
+	dstPort         uint16
+	tcpFlags        uint8
+	size            int
+	ipSize          int
+}
+
+// decodeRawPacket prints a sampled header record and decodes its
+// link, network and transport layers the way sflowtool does.
+func decodeRawPacket(p *printer, f records.RawPacketFlow, pkt *packet) {
+	p.printf("flowSampleType HEADER\n")
+	p.printf("headerProtocol %d\n", f.Protocol)
+	p.printf("sampledPacketSize %d\n", f.FrameLength)
+	p.printf("strippedBytes %d\n", f.Stripped)
+	p.printf("headerLen %d\n", f.HeaderSize)
+	p.printf("headerBytes %s\n", hexBytes(f.Header))
+
+	pkt.size = int(f.FrameLength)
+	pkt.ipSize = int(f.FrameLength) - int(f.Stripped)
+
+	ipVersion, offset := 0, 0
+
+	switch f.Protocol {
+	case records.HeaderProtocolEthernetISO8023:
+		ipVersion, offset = decodeLinkLayer(p, f.Header, pkt)
+	case records.HeaderProtocolIPv4:
+		ipVersion = 4
+	case records.HeaderProtocolIPv6:
+		ipVersion = 6
+	default:
+		p.printf("NO_DECODE headerProtocol=%d\n", f.Protocol)
+	}
+
+	if ipVersion == 0 {
+		return
+	}
+
+	pkt.ipSize -= offset
+	p.printf("IPSize %d\n", pkt.ipSize)
+
+	if ipVersion == 4 {
+		decodeIPv4(p, f.Header[offset:], pkt)
+	} else {
+		decodeIPv6(p, f.Header[offset:], pkt)
+	}
+}
+
+// decodeLinkLayer decodes an Ethernet header, skipping an 802.1Q tag,
+// and returns the IP version and offset of the following IP header,
+// or 0 if there is none.
+func decodeLinkLayer(p *printer, h []byte, pkt *packet) (int, int) {
+	if len(h) < 14 {
+		return 0, 0
+	}
+
+	copy(pkt.dstMAC[:], h[0:6])
+	copy(pkt.srcMAC[:], h[6:12])
+	p.printf("dstMAC %x\n", h[0:6])
+	p.printf("srcMAC %x\n", h[6:12])
+
+	typeLen := int(binary.BigEndian.Uint16(h[12:14]))
+	offset := 14
+
+	if typeLen == 0x8100 {
+		if len(h) < offset+4 {
+			return 0, 0
+		}
+
+		vlanData := binary.BigEndian.Uint16(h[offset:])
+		pkt.inVlan = uint32(vlanData & 0x0fff)
+		p.printf("decodedVLAN %d\n", vlanData&0x0fff)
+		p.printf("decodedPriority %d\n", vlanData>>13)
+
+		typeLen = int(binary.BigEndian.Uint16(h[offset+2:]))
+		offset += 4
+	}
+
+	pkt.ethType = typeLen
+
+	switch typeLen {
+	case 0x0800:
+		if len(h)-offset < 20 || h[offset]>>4 != 4 || h[offset]&0x0f < 5 {
+			return 0, 0
+		}
+		return 4, offset
+	case 0x86dd:
+		if len(h)-offset < 40 || h[offset]>>4 != 6 {
+			return 0, 0
+		}
+		return 6, offset
+	}
+
+	return 0, 0
+}
+
+func decodeIPv4(p *printer, h []byte, pkt *packet) {
+	if len(h) < 20 {
+		return
+	}
+
+	pkt.srcIP = net.IP(append([]byte(nil), h[12:16]...))
+	pkt.dstIP = net.IP(append([]byte(nil), h[16:20]...))
+	pkt.ipProtocol = h[9]
+	pkt.ipTos = h[1]
+	pkt.ipTTL = h[8]
+
+	p.printf("ip.tot_len %d\n", binary.BigEndian.Uint16(h[2:]))
+	p.printf("srcIP %s\n", pkt.srcIP)
+	p.printf("dstIP %s\n", pkt.dstIP)
+	p.printf("IPProtocol %d\n", pkt.ipProtocol)
+	p.printf("IPTOS %d\n", pkt.ipTos)
+	p.printf("IPTTL %d\n", pkt.ipTTL)
+	p.printf("IPID %d\n", binary.BigEndian.Uint16(h[4:]))
+
+	fragOffset := binary.BigEndian.Uint16(h[6:]) & 0x1fff
+	if fragOffset > 0 {
+		p.printf("IPFragmentOffset %d\n", fragOffset)
+		return
+	}
+
+	headerLen := int(h[0]&0x0f) * 4
+	if headerLen < 20 || headerLen > len(h) {
+		return
+	}
+
+	decodeLayer4(p, h[headerLen:], pkt)
+}
+
+func decodeIPv6(p *printer, h []byte, pkt *packet) {
+	if len(h) < 40 || h[0]>>4 != 6 {
+		return
+	}
+
+	pkt.ipTos = h[0]&0x0f<<4 | h[1]>>4
+	p.printf("IPTOS %d\n", pkt.ipTos)
+
+	label := uint32(h[1]&0x0f)<<16 | uint32(h[2])<<8 | uint32(h[3])
+	p.printf("IP6_label 0x%x\n", label)
+
+	payloadLen := binary.BigEndian.Uint16(h[4:])
+	if payloadLen == 0 {
+		p.printf("IPV6_payloadLen <jumbo>\n")
+	} else {
+		p.printf("IPV6_payloadLen %d\n", payloadLen)
+	}
+
+	nextHeader := h[6]
+	pkt.ipTTL = h[7]
+	p.printf("IPTTL %d\n", pkt.ipTTL)
+
+	pkt.srcIP = net.IP(append([]byte(nil), h[8:24]...))
+	pkt.dstIP = net.IP(append([]byte(nil), h[24:40]...))
+	p.printf("srcIP %s\n", pkt.srcIP)
+	p.printf("dstIP %s\n", pkt.dstIP)
+
+	h = h[40:]
+
+	// skip hop-by-hop, routing, fragment, auth and destination options
+	for nextHeader == 0 || nextHeader == 43 || nextHeader == 44 ||
+		nextHeader == 51 || nextHeader == 60 {
+		p.printf("IP6HeaderExtension: %d\n", nextHeader)
+
+		if len(h) < 2 {
+			return
+		}
+
+		optionLen := 8 * (int(h[1]) + 1)
+		if nextHeader == 51 {
+			// the auth header length counts 4-byte units, minus 2
+			optionLen = 4 * (int(h[1]) + 2)
+		}
+
+		nextHeader = h[0]
+		if optionLen > len(h) {
+			return
+		}
+		h = h[optionLen:]
+	}
+
+	pkt.ipProtocol = nextHeader
+	p.printf("IPProtocol %d\n", pkt.ipProtocol)
+
+	decodeLayer4(p, h, pkt)
+}
+
+func decodeLayer4(p *printer, h []byte, pkt *packet) {
+	if len(h) < 8 {
+		return
+	}
+
+	switch pkt.ipProtocol {
+	case records.IPProtocolICMP:
+		pkt.srcPort = uint16(h[0])
+		pkt.dstPort = uint16(h[1])
+		p.printf("ICMPType %d\n", h[0])
+		p.printf("ICMPCode %d\n", h[1])
+
+	case records.IPProtocolTCP:
+		if len(h) < 14 {
+			return
+		}
+
+		pkt.srcPort = binary.BigEndian.Uint16(h[0:])
+		pkt.dstPort = binary.BigEndian.Uint16(h[2:])
+		pkt.tcpFlags = h[13]
+		p.printf("TCPSrcPort %d\n", pkt.srcPort)
+		p.printf("TCPDstPort %d\n", pkt.dstPort)
+		p.printf("TCPFlags %d\n", pkt.tcpFlags)
+
+	case records.IPProtocolUDP:
+		pkt.srcPort = binary.BigEndian.Uint16(h[0:])
+		pkt.dstPort = binary.BigEndian.Uint16(h[2:])
+		p.printf("UDPSrcPort %d\n", pkt.srcPort)
+		p.printf("UDPDstPort %d\n", pkt.dstPort)
+		p.printf("UDPBytes %d\n", binary.BigEndian.Uint16(h[4:]))
+	}
+}
+
+// hexBytes formats b as upper case hex bytes separated by dashes.
+func hexBytes(b []byte) string {
+	const digits = "0123456789ABCDEF"
+
+	var s strings.Builder
+	for i, c := range b {
+		if i > 0 {
+			s.WriteByte('-')
+		}
+		s.WriteByte(digits[c>>4])
+		s.WriteByte(digits[c&0x0f])
+	}
+
+	return s.String()
+}
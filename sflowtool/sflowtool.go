@@ -0,0 +1,56 @@
+// Package sflowtool renders decoded datagrams in the output formats of
+// the sflowtool reference collector, so results can be diffed against it
+// and fed to scripts written for it: the default key/value text dump
+// (WriteText) and the line-based "-l" CSV form (WriteCSV).
+package sflowtool
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"time"
+)
+
+// Origin describes how a datagram was received. sflowtool prints this
+// ahead of the datagram contents in its text output.
+type Origin struct {
+	// Addr is the address the datagram was received from.
+	// The agent address is printed when it is nil.
+	Addr net.IP
+
+	// Size is the length of the datagram in bytes.
+	Size int
+
+	// Time is when the datagram was received.
+	// The current time is printed when it is zero.
+	Time time.Time
+}
+
+// printer writes formatted output to w and remembers the first error.
+type printer struct {
+	w   io.Writer
+	err error
+}
+
+func (p *printer) printf(format string, a ...interface{}) {
+	if p.err != nil {
+		return
+	}
+
+	_, p.err = fmt.Fprintf(p.w, format, a...)
+}
+
+// tag formats a sample or record type as sflowtool's enterprise:format.
+func tag(typ int) string {
+	return fmt.Sprintf("%d:%d", typ>>12, typ&0xfff)
+}
+
+// address formats an IP address the way sflowtool does,
+// printing "-" for a missing address.
+func address(ip net.IP) string {
+	if ip == nil {
+		return "-"
+	}
+
+	return ip.String()
+}
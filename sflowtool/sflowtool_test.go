@@ -0,0 +1,193 @@
+package sflowtool
+
+import (
+	"bytes"
+	"net"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kanocz/sflow"
+	"github.com/kanocz/sflow/records"
+)
+
+func decodeFile(t *testing.T, name string) *sflow.Datagram {
+	f, err := os.Open(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	dgram, err := sflow.NewDecoder(f).Decode()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return dgram
+}
+
+func TestWriteTextFlowSample(t *testing.T) {
+	// localtime is printed in the local time zone
+	local := time.Local
+	time.Local = time.UTC
+	t.Cleanup(func() { time.Local = local })
+
+	dgram := decodeFile(t, "../_test/flow_sample.dump")
+
+	buf := &bytes.Buffer{}
+	err := WriteText(buf, dgram, Origin{Size: 344, Time: time.Unix(1700000000, 0)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `startDatagram =================================
+datagramSourceIP 208.85.240.52
+datagramSize 344
+unixSecondsUTC 1700000000
+localtime 2023-11-14T22:13:20+0000
+datagramVersion 5
+agentSubId 1
+agent 208.85.240.52
+packetSequenceNo 1260270
+sysUpTime 1382429804
+samplesInPacket 1
+startSample ----------------------
+sampleType_tag 0:1
+sampleType FLOWSAMPLE
+sampleSequenceNo 4446
+sourceId 0:4
+meanSkipCount 4096
+samplePool 18206720
+dropEvents 0
+inputPort 4
+outputPort 1
+flowBlock_tag 0:1
+flowSampleType HEADER
+headerProtocol 1
+sampledPacketSize 318
+strippedBytes 4
+headerLen 128
+headerBytes 00-D0-01-FF-58-00-00-16-3C-C2-A9-AB-08-00-45-00-01-2C-00-00-40-00-40-11-D1-58-C7-3A-A1-96-C5-A1-39-F6-C8-D5-26-00-01-18-A6-17-64-31-3A-72-64-32-3A-69-64-32-30-3A-6B-96-8B-CA-4A-C0-B5-CF-10-3A-D6-BF-8D-D7-34-01-46-51-B7-FA-35-3A-6E-6F-64-65-73-32-30-38-3A-61-4A-B8-64-54-EE-85-5F-13-9A-20-96-E9-83-FF-CF-F4-D0-C5-A5-DE-67-0A-8F-DB-1D-61-4A-78-12-83-31-A3-77-86-68-5E-1C-24-CE-33-19-DE
+dstMAC 00d001ff5800
+srcMAC 00163cc2a9ab
+IPSize 300
+ip.tot_len 300
+srcIP 199.58.161.150
+dstIP 197.161.57.246
+IPProtocol 17
+IPTOS 0
+IPTTL 64
+IPID 0
+UDPSrcPort 51413
+UDPDstPort 9728
+UDPBytes 280
+flowBlock_tag 0:1001
+in_vlan 16
+in_priority 0
+out_vlan 16
+out_priority 0
+endSample   ----------------------
+endDatagram   =================================
+`
+
+	if buf.String() != expected {
+		t.Errorf("expected\n%s\ngot\n%s", expected, buf.String())
+	}
+}
+
+func TestWriteTextHostCounters(t *testing.T) {
+	dgram := decodeFile(t, "../_test/host_sample.dump")
+
+	buf := &bytes.Buffer{}
+	err := WriteText(buf, dgram, Origin{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, line := range []string{
+		"datagramSourceIP 192.168.1.7\n",
+		"sampleType COUNTERSSAMPLE\n",
+		"sourceId 2:1\n",
+		"counterBlock_tag 0:2005\n",
+		"disk_partition_max_used 56.39\n",
+		"cpu_load_one 0.470\n",
+		"nio_pkts_in 72\n",
+	} {
+		if !strings.Contains(buf.String(), line) {
+			t.Errorf("expected output to contain %q, got\n%s", line, buf.String())
+		}
+	}
+}
+
+func TestWriteTextCounterNames(t *testing.T) {
+	dgram := &sflow.Datagram{
+		Version:   5,
+		IpAddress: net.IPv4(192, 0, 2, 1),
+		Samples: []sflow.Sample{&sflow.CounterSample{
+			Records: []records.Record{
+				sflow.LAGPortCounters{
+					ActorSystemID:       records.HardwareAddr{0x00, 0x1b, 0x21, 0x3c, 0x4d, 0x5e},
+					PartnerOperSystemID: records.HardwareAddr{0x00, 0x1b, 0x21, 0x6f, 0x70, 0x81},
+					ActorOperState:      0x3f,
+				},
+				sflow.MIB2TCPGroupCounters{RtoAlgorithm: 1, CurrEstab: 12},
+				sflow.JMXStatisticsCounters{HeapUsed: 42, GCTime: 7},
+				sflow.OVSDPStatsCounters{Hits: 9, MaskHits: 3},
+				sflow.NvidiaGPUCounters{Temperature: 55, MemTotal: 1 << 34},
+			},
+		}},
+	}
+
+	buf := &bytes.Buffer{}
+	err := WriteText(buf, dgram, Origin{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, line := range []string{
+		"actorSystemID 001b213c4d5e\n",
+		"partnerSystemID 001b216f7081\n",
+		"actorOperState 63\n",
+		"tcpRtoAlgorithm 1\n",
+		"tcpCurrEstab 12\n",
+		"heap_mem_used 42\n",
+		"gc_mS 7\n",
+		"OVS_dp_hits 9\n",
+		"OVS_dp_mask_hits 3\n",
+		"nvml_temperature_C 55\n",
+		"nvml_mem_bytes_total 17179869184\n",
+	} {
+		if !strings.Contains(buf.String(), line) {
+			t.Errorf("expected output to contain %q, got\n%s", line, buf.String())
+		}
+	}
+}
+
+func TestWriteCSV(t *testing.T) {
+	buf := &bytes.Buffer{}
+
+	err := WriteCSV(buf, decodeFile(t, "../_test/flow_sample.dump"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = WriteCSV(buf, decodeFile(t, "../_test/counter_sample.dump"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 lines, got %q", lines)
+	}
+
+	expected := "FLOW,208.85.240.52,4,1,00163cc2a9ab,00d001ff5800,0x0800,16,16,199.58.161.150,197.161.57.246,17,0x00,64,51413,9728,0x00,318,300,4096"
+	if lines[0] != expected {
+		t.Errorf("expected %q, got %q", expected, lines[0])
+	}
+
+	if !strings.HasPrefix(lines[1], "CNTR,") || strings.Count(lines[1], ",") != 20 {
+		t.Errorf("unexpected counter line %q", lines[1])
+	}
+}
@@ -0,0 +1,563 @@
+package sflowtool
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/kanocz/sflow"
+	"github.com/kanocz/sflow/records"
+)
+
+// WriteText writes d to w in sflowtool's default key/value format,
+// one "key value" pair per line, framed by startDatagram/endDatagram
+// and startSample/endSample lines.
+//
+// Records sflowtool knows are printed with its key names. Other records
+// are printed with the names of their JSON fields.
+func WriteText(w io.Writer, d *sflow.Datagram, o Origin) error {
+	p := &printer{w: w}
+
+	addr := o.Addr
+	if addr == nil {
+		addr = d.IpAddress
+	}
+
+	t := o.Time
+	if t.IsZero() {
+		t = time.Now()
+	}
+
+	p.printf("startDatagram =================================\n")
+	p.printf("datagramSourceIP %s\n", address(addr))
+	p.printf("datagramSize %d\n", o.Size)
+	p.printf("unixSecondsUTC %d\n", t.Unix())
+	p.printf("localtime %s\n", t.Local().Format("2006-01-02T15:04:05-0700"))
+	p.printf("datagramVersion %d\n", d.Version)
+	p.printf("agentSubId %d\n", d.SubAgentId)
+	p.printf("agent %s\n", address(d.IpAddress))
+	p.printf("packetSequenceNo %d\n", d.SequenceNumber)
+	p.printf("sysUpTime %d\n", d.Uptime)
+	p.printf("samplesInPacket %d\n", d.NumSamples)
+
+	for _, sample := range d.Samples {
+		p.printf("startSample ----------------------\n")
+
+		switch s := sample.(type) {
+		case *sflow.FlowSample:
+			writeFlowSampleText(p, s)
+		case *sflow.CounterSample:
+			writeCounterSampleText(p, s)
+		default:
+			p.printf("sampleType_tag %s\n", tag(sample.SampleType()))
+		}
+
+		p.printf("endSample   ----------------------\n")
+	}
+
+	p.printf("endDatagram   =================================\n")
+
+	return p.err
+}
+
+func writeFlowSampleText(p *printer, s *sflow.FlowSample) *packet {
+	p.printf("sampleType_tag %s\n", tag(sflow.TypeFlowSample))
+	p.printf("sampleType FLOWSAMPLE\n")
+	p.printf("sampleSequenceNo %d\n", s.SequenceNum)
+	p.printf("sourceId %d:%d\n", s.SourceIdType, s.SourceIdIndexVal)
+	p.printf("meanSkipCount %d\n", s.SamplingRate)
+	p.printf("samplePool %d\n", s.SamplePool)
+	p.printf("dropEvents %d\n", s.Drops)
+	p.printf("inputPort %d\n", s.Input)
+
+	if s.Output&0x80000000 != 0 {
+		if n := s.Output & 0x7fffffff; n > 0 {
+			p.printf("outputPort multiple %d\n", n)
+		} else {
+			p.printf("outputPort multiple >1\n")
+		}
+	} else {
+		p.printf("outputPort %d\n", s.Output)
+	}
+
+	pkt := &packet{}
+
+	for _, rec := range s.Records {
+		p.printf("flowBlock_tag %s\n", tag(rec.RecordType()))
+
+		switch r := rec.(type) {
+		case records.RawPacketFlow:
+			decodeRawPacket(p, r, pkt)
+
+		case records.ExtendedSwitchFlow:
+			pkt.inVlan = r.SourceVlan
+			pkt.outVlan = r.DestinationVlan
+			p.printf("in_vlan %d\n", r.SourceVlan)
+			p.printf("in_priority %d\n", r.SourcePriority)
+			p.printf("out_vlan %d\n", r.DestinationVlan)
+			p.printf("out_priority %d\n", r.DestinationPriority)
+
+		case records.ExtendedRouterFlow:
+			p.printf("nextHop %s\n", address(r.NextHop))
+			p.printf("srcSubnetMask %d\n", r.SrcMask)
+			p.printf("dstSubnetMask %d\n", r.DstMask)
+
+		case records.ExtendedGatewayFlow:
+			writeGatewayText(p, r)
+
+		case records.ExtendedSocketIPv4Flow:
+			writeSocketText(p, "socket4", r.Protocol, r.LocalIP, r.RemoteIP, r.LocalPort, r.RemotePort)
+		case records.ExtendedSocketIPv6Flow:
+			writeSocketText(p, "socket6", r.Protocol, r.LocalIP, r.RemoteIP, r.LocalPort, r.RemotePort)
+		case records.ExtendedProxySocketIPv4Flow:
+			s := r.Socket
+			writeSocketText(p, "proxy_socket4", s.Protocol, s.LocalIP, s.RemoteIP, s.LocalPort, s.RemotePort)
+		case records.ExtendedProxySocketIPv6Flow:
+			s := r.Socket
+			writeSocketText(p, "proxy_socket6", s.Protocol, s.LocalIP, s.RemoteIP, s.LocalPort, s.RemotePort)
+
+		case records.HTTPRequestFlow:
+			writeHTTPRequestText(p, r)
+
+		default:
+			writeFieldsText(p, rec)
+		}
+	}
+
+	return pkt
+}
+
+// writeGatewayText prints the BGP path of r, with the first AS of the
+// path as the peer AS and the last as the destination AS.
+func writeGatewayText(p *printer, r records.ExtendedGatewayFlow) {
+	p.printf("bgp_nexthop %s\n", address(r.NextHop))
+	p.printf("my_as %d\n", r.As)
+	p.printf("src_as %d\n", r.SrcAs)
+	p.printf("src_peer_as %d\n", r.SrcPeerAs)
+
+	dstAs, dstPeerAs := uint32(0), uint32(0)
+
+	if len(r.DstAsPathSegments) > 0 {
+		var path strings.Builder
+
+		for i, seg := range r.DstAsPathSegments {
+			for j, as := range seg.Seg {
+				if i == 0 && j == 0 {
+					dstPeerAs = as
+				} else {
+					path.WriteString("-")
+				}
+
+				if j == 0 && seg.SegType == records.AsPathSegmentTypeUnOrdered {
+					path.WriteString("(")
+				}
+
+				fmt.Fprintf(&path, "%d", as)
+				dstAs = as
+			}
+
+			if seg.SegType == records.AsPathSegmentTypeUnOrdered {
+				path.WriteString(")")
+			}
+		}
+
+		p.printf("dst_as_path %s\n", path.String())
+	}
+
+	p.printf("dst_as %d\n", dstAs)
+	p.printf("dst_peer_as %d\n", dstPeerAs)
+
+	if len(r.Communities) > 0 {
+		communities := make([]string, len(r.Communities))
+		for i, c := range r.Communities {
+			communities[i] = fmt.Sprint(c)
+		}
+
+		p.printf("BGP_communities %s\n", strings.Join(communities, "-"))
+	}
+
+	p.printf("BGP_localpref %d\n", r.LocalPref)
+}
+
+func writeSocketText(p *printer, prefix string, protocol uint32, local, remote interface{}, localPort, remotePort uint32) {
+	p.printf("%s_ip_protocol %d\n", prefix, protocol)
+	p.printf("%s_local_ip %s\n", prefix, local)
+	p.printf("%s_remote_ip %s\n", prefix, remote)
+	p.printf("%s_local_port %d\n", prefix, localPort)
+	p.printf("%s_remote_port %d\n", prefix, remotePort)
+}
+
+var httpMethodNames = []string{"-", "OPTIONS", "GET", "HEAD", "POST", "PUT", "DELETE", "TRACE", "CONNECT"}
+
+func writeHTTPRequestText(p *printer, r records.HTTPRequestFlow) {
+	method := "-"
+	if int(r.Method) < len(httpMethodNames) {
+		method = httpMethodNames[r.Method]
+	}
+
+	p.printf("http_method %s\n", method)
+	p.printf("http_protocol %d\n", r.Protocol)
+	p.printf("http_uri %s\n", r.URI)
+	p.printf("http_host %s\n", r.Host)
+	p.printf("http_referrer %s\n", r.Referer)
+	p.printf("http_useragent %s\n", r.UserAgent)
+	p.printf("http_xff %s\n", r.XFF)
+	p.printf("http_authuser %s\n", r.AuthUser)
+	p.printf("http_mime_type %s\n", r.MimeType)
+	p.printf("http_request_bytes %d\n", r.ReqBytes)
+	p.printf("http_bytes %d\n", r.RespBytes)
+	p.printf("http_duration_uS %d\n", r.Duration)
+	p.printf("http_status %d\n", r.Status)
+}
+
+func writeCounterSampleText(p *printer, s *sflow.CounterSample) {
+	p.printf("sampleType_tag %s\n", tag(sflow.TypeCounterSample))
+	p.printf("sampleType COUNTERSSAMPLE\n")
+	p.printf("sampleSequenceNo %d\n", s.SequenceNum)
+	p.printf("sourceId %d:%d\n", s.SourceIdType, s.SourceIdIndexVal)
+
+	for _, rec := range s.Records {
+		p.printf("counterBlock_tag %s\n", tag(rec.RecordType()))
+
+		switch r := rec.(type) {
+		case sflow.GenericInterfaceCounters:
+			values(p, []string{
+				"ifIndex", "networkType", "ifSpeed", "ifDirection", "ifStatus",
+				"ifInOctets", "ifInUcastPkts", "ifInMulticastPkts", "ifInBroadcastPkts",
+				"ifInDiscards", "ifInErrors", "ifInUnknownProtos",
+				"ifOutOctets", "ifOutUcastPkts", "ifOutMulticastPkts", "ifOutBroadcastPkts",
+				"ifOutDiscards", "ifOutErrors", "ifPromiscuousMode",
+			}, r.Index, r.Type, r.Speed, r.Direction, r.Status,
+				r.InOctets, r.InUnicastPackets, r.InMulticastPackets, r.InBroadcastPackets,
+				r.InDiscards, r.InErrors, r.InUnknownProtocols,
+				r.OutOctets, r.OutUnicastPackets, r.OutMulticastPackets, r.OutBroadcastPackets,
+				r.OutDiscards, r.OutErrors, r.PromiscuousMode)
+
+		case sflow.EthernetCounters:
+			values(p, []string{
+				"dot3StatsAlignmentErrors", "dot3StatsFCSErrors",
+				"dot3StatsSingleCollisionFrames", "dot3StatsMultipleCollisionFrames",
+				"dot3StatsSQETestErrors", "dot3StatsDeferredTransmissions",
+				"dot3StatsLateCollisions", "dot3StatsExcessiveCollisions",
+				"dot3StatsInternalMacTransmitErrors", "dot3StatsCarrierSenseErrors",
+				"dot3StatsFrameTooLongs", "dot3StatsInternalMacReceiveErrors",
+				"dot3StatsSymbolErrors",
+			}, r.AlignmentErrors, r.FCSErrors,
+				r.SingleCollisionFrames, r.MultipleCollisionFrames,
+				r.SQETestErrors, r.DeferredTransmissions,
+				r.LateCollisions, r.ExcessiveCollisions,
+				r.InternalMACTransmitErrors, r.CarrierSenseErrors,
+				r.FrameTooLongs, r.InternalMACReceiveErrors,
+				r.SymbolErrors)
+
+		case sflow.TokenRingCounters:
+			values(p, []string{
+				"dot5StatsLineErrors", "dot5StatsBurstErrors", "dot5StatsACErrors",
+				"dot5StatsAbortTransErrors", "dot5StatsInternalErrors", "dot5StatsLostFrameErrors",
+				"dot5StatsReceiveCongestions", "dot5StatsFrameCopiedErrors", "dot5StatsTokenErrors",
+				"dot5StatsSoftErrors", "dot5StatsHardErrors", "dot5StatsSignalLoss",
+				"dot5StatsTransmitBeacons", "dot5StatsRecoverys", "dot5StatsLobeWires",
+				"dot5StatsRemoves", "dot5StatsSingles", "dot5StatsFreqErrors",
+			}, r.LineErrors, r.BurstErrors, r.ACErrors,
+				r.AbortTransErrors, r.InternalErrors, r.LostFrameErrors,
+				r.ReceiveCongestions, r.FrameCopiedErrors, r.TokenErrors,
+				r.SoftErrors, r.HardErrors, r.SignalLoss,
+				r.TransmitBeacons, r.Recoverys, r.LobeWires,
+				r.Removes, r.Singles, r.FreqErrors)
+
+		case sflow.VgCounters:
+			values(p, []string{
+				"dot12InHighPriorityFrames", "dot12InHighPriorityOctets",
+				"dot12InNormPriorityFrames", "dot12InNormPriorityOctets",
+				"dot12InIPMErrors", "dot12InOversizeFrameErrors",
+				"dot12InDataErrors", "dot12InNullAddressedFrames",
+				"dot12OutHighPriorityFrames", "dot12OutHighPriorityOctets",
+				"dot12TransitionIntoTrainings", "dot12HCInHighPriorityOctets",
+				"dot12HCInNormPriorityOctets", "dot12HCOutHighPriorityOctets",
+			}, r.InHighPriorityFrames, r.InHighPriorityOctets,
+				r.InNormPriorityFrames, r.InNormPriorityOctets,
+				r.InIPMErrors, r.InOversizeFrameErrors,
+				r.InDataErrors, r.InNullAddressedFrames,
+				r.OutHighPriorityFrames, r.OutHighPriorityOctets,
+				r.TransitionIntoTrainings, r.HCInHighPriorityOctets,
+				r.HCInNormPriorityOctets, r.HCOutHighPriorityOctets)
+
+		case sflow.VlanCounters:
+			values(p, []string{
+				"in_vlan", "octets", "ucastPkts", "multicastPkts", "broadcastPkts", "discards",
+			}, r.ID, r.Octets, r.UnicastPackets, r.MulticastPackets, r.BroadcastPackets, r.Discards)
+
+		case sflow.ProcessorCounters:
+			values(p, []string{
+				"5s_cpu", "1m_cpu", "5m_cpu", "total_memory_bytes", "free_memory_bytes",
+			}, percentage(r.CPU5s), percentage(r.CPU1m), percentage(r.CPU5m), r.TotalMemory, r.FreeMemory)
+
+		case sflow.HostCPUCounters:
+			values(p, []string{
+				"cpu_load_one", "cpu_load_five", "cpu_load_fifteen",
+				"cpu_proc_run", "cpu_proc_total", "cpu_num", "cpu_speed", "cpu_uptime",
+				"cpu_user", "cpu_nice", "cpu_system", "cpu_idle", "cpu_wio",
+				"cpuintr", "cpu_sintr", "cpuinterrupts", "cpu_contexts",
+				"cpu_steal", "cpu_guest", "cpu_guest_nice",
+			}, fmt.Sprintf("%.3f", r.Load1m), fmt.Sprintf("%.3f", r.Load5m), fmt.Sprintf("%.3f", r.Load15m),
+				r.ProcessesRunning, r.ProcessesTotal, r.NumCPU, r.SpeedCPU, r.Uptime,
+				r.CPUUser, r.CPUNice, r.CPUSys, r.CPUIdle, r.CPUWio,
+				r.CPUIntr, r.CPUSoftIntr, r.Interrupts, r.ContextSwitches,
+				r.CPUSteal, r.CPUGuest, r.CPUGuestNice)
+
+		case sflow.HostMemoryCounters:
+			values(p, []string{
+				"mem_total", "mem_free", "mem_shared", "mem_buffers", "mem_cached",
+				"swap_total", "swap_free", "page_in", "page_out", "swap_in", "swap_out",
+			}, r.Total, r.Free, r.Shared, r.Buffers, r.Cached,
+				r.SwapTotal, r.SwapFree, r.PageIn, r.PageOut, r.SwapIn, r.SwapOut)
+
+		case sflow.HostDiskCounters:
+			// part_max_used is a percentage in hundredths on the wire,
+			// which the decoder stores in a float32 field.
+			values(p, []string{
+				"disk_total", "disk_free", "disk_partition_max_used",
+				"disk_reads", "disk_bytes_read", "disk_read_time",
+				"disk_writes", "disk_bytes_written", "disk_write_time",
+			}, r.Total, r.Free, percentage(math.Float32bits(r.MaxUsedPercent)),
+				r.Reads, r.BytesRead, r.ReadTime,
+				r.Writes, r.BytesWritten, r.WriteTime)
+
+		case sflow.HostNetCounters:
+			values(p, []string{
+				"nio_bytes_in", "nio_pkts_in", "nio_errs_in", "nio_drops_in",
+				"nio_bytes_out", "nio_pkts_out", "nio_errs_out", "nio_drops_out",
+			}, r.BytesIn, r.PacketsIn, r.ErrorsIn, r.DropsIn,
+				r.BytesOut, r.PacketsOut, r.ErrorsOut, r.DropsOut)
+
+		case records.HTTPCounter:
+			values(p, []string{
+				"http_method_option_count", "http_method_get_count", "http_method_head_count",
+				"http_method_post_count", "http_method_put_count", "http_method_delete_count",
+				"http_method_trace_count", "http_method_connect_count", "http_method_other_count",
+				"http_status_1XX_count", "http_status_2XX_count", "http_status_3XX_count",
+				"http_status_4XX_count", "http_status_5XX_count", "http_status_other_count",
+			}, r.MethodOptionCount, r.MethodGetCount, r.MethodHeadCount,
+				r.MethodPostCount, r.MethodPutCount, r.MethodDeleteCount,
+				r.MethodTraceCount, r.MethodConnectCount, r.MethodOtherCount,
+				r.Status1XXCount, r.Status2XXCount, r.Status3XXCount,
+				r.Status4XXCount, r.Status5XXCount, r.StatusOtherCount)
+
+		case sflow.IEEE80211Counters:
+			values(p, []string{
+				"dot11TransmittedFragmentCount", "dot11MulticastTransmittedFrameCount",
+				"dot11FailedCount", "dot11RetryCount", "dot11MultipleRetryCount",
+				"dot11FrameDuplicateCount", "dot11RTSSuccessCount", "dot11RTSFailureCount",
+				"dot11ACKFailureCount", "dot11ReceivedFragmentCount",
+				"dot11MulticastReceivedFrameCount", "dot11FCSErrorCount",
+				"dot11TransmittedFrameCount", "dot11WEPUndecryptableCount",
+				"dot11QoSDiscardedFragmentCount", "dot11AssociatedStationCount",
+				"dot11QoSCFPollsReceivedCount", "dot11QoSCFPollsUnusedCount",
+				"dot11QoSCFPollsUnusableCount", "dot11QoSCFPollsLostCount",
+			}, r.TransmittedFragmentCount, r.MulticastTransmittedFrameCount,
+				r.FailedCount, r.RetryCount, r.MultipleRetryCount,
+				r.FrameDuplicateCount, r.RTSSuccessCount, r.RTSFailureCount,
+				r.ACKFailureCount, r.ReceivedFragmentCount,
+				r.MulticastReceivedFrameCount, r.FCSErrorCount,
+				r.TransmittedFrameCount, r.WEPUndecryptableCount,
+				r.QoSDiscardedFragmentCount, r.AssociatedStationCount,
+				r.QoSCFPollsReceivedCount, r.QoSCFPollsUnusedCount,
+				r.QoSCFPollsUnusableCount, r.QoSCFPollsLostCount)
+
+		case sflow.LAGPortCounters:
+			values(p, []string{
+				"actorSystemID", "partnerSystemID", "attachedAggID",
+				"actorAdminState", "actorOperState", "partnerAdminState", "partnerOperState",
+				"LACPDUsRx", "markerPDUsRx", "markerResponsePDUsRx", "unknownRx", "illegalRx",
+				"LACPDUsTx", "markerPDUsTx", "markerResponsePDUsTx",
+			}, fmt.Sprintf("%x", []byte(r.ActorSystemID)), fmt.Sprintf("%x", []byte(r.PartnerOperSystemID)), r.AttachedAggID,
+				r.ActorAdminState, r.ActorOperState, r.PartnerAdminState, r.PartnerOperState,
+				r.LACPDUsRx, r.MarkerPDUsRx, r.MarkerResponsePDUsRx, r.UnknownRx, r.IllegalRx,
+				r.LACPDUsTx, r.MarkerPDUsTx, r.MarkerResponsePDUsTx)
+
+		case sflow.RadioUtilizationCounters:
+			values(p, []string{
+				"radio_elapsed_time", "radio_on_channel_time", "radio_on_channel_busy_time",
+			}, r.ElapsedTime, r.OnChannelTime, r.OnChannelBusyTime)
+
+		case sflow.PortNameCounters:
+			p.printf("portName %s\n", r.Name)
+
+		case sflow.MIB2IPGroupCounters:
+			values(p, []string{
+				"ipForwarding", "ipDefaultTTL", "ipInReceives", "ipInHdrErrors", "ipInAddrErrors",
+				"ipForwDatagrams", "ipInUnknownProtos", "ipInDiscards", "ipInDelivers",
+				"ipOutRequests", "ipOutDiscards", "ipOutNoRoutes",
+				"ipReasmTimeout", "ipReasmReqds", "ipReasmOKs", "ipReasmFails",
+				"ipFragOKs", "ipFragFails", "ipFragCreates",
+			}, r.Forwarding, r.DefaultTTL, r.InReceives, r.InHdrErrors, r.InAddrErrors,
+				r.ForwDatagrams, r.InUnknownProtos, r.InDiscards, r.InDelivers,
+				r.OutRequests, r.OutDiscards, r.OutNoRoutes,
+				r.ReasmTimeout, r.ReasmReqds, r.ReasmOKs, r.ReasmFails,
+				r.FragOKs, r.FragFails, r.FragCreates)
+
+		case sflow.MIB2ICMPGroupCounters:
+			values(p, []string{
+				"icmpInMsgs", "icmpInErrors", "icmpInDestUnreachs", "icmpInTimeExcds",
+				"icmpInParamProbs", "icmpInSrcQuenchs", "icmpInRedirects", "icmpInEchos",
+				"icmpInEchoReps", "icmpInTimestamps", "icmpInAddrMasks", "icmpInAddrMaskReps",
+				"icmpOutMsgs", "icmpOutErrors", "icmpOutDestUnreachs", "icmpOutTimeExcds",
+				"icmpOutParamProbs", "icmpOutSrcQuenchs", "icmpOutRedirects", "icmpOutEchos",
+				"icmpOutEchoReps", "icmpOutTimestamps", "icmpOutTimestampReps",
+				"icmpOutAddrMasks", "icmpOutAddrMaskReps",
+			}, r.InMsgs, r.InErrors, r.InDestUnreachs, r.InTimeExcds,
+				r.InParamProbs, r.InSrcQuenchs, r.InRedirects, r.InEchos,
+				r.InEchoReps, r.InTimestamps, r.InAddrMasks, r.InAddrMaskReps,
+				r.OutMsgs, r.OutErrors, r.OutDestUnreachs, r.OutTimeExcds,
+				r.OutParamProbs, r.OutSrcQuenchs, r.OutRedirects, r.OutEchos,
+				r.OutEchoReps, r.OutTimestamps, r.OutTimestampReps,
+				r.OutAddrMasks, r.OutAddrMaskReps)
+
+		case sflow.MIB2TCPGroupCounters:
+			values(p, []string{
+				"tcpRtoAlgorithm", "tcpRtoMin", "tcpRtoMax", "tcpMaxConn",
+				"tcpActiveOpens", "tcpPassiveOpens", "tcpAttemptFails", "tcpEstabResets",
+				"tcpCurrEstab", "tcpInSegs", "tcpOutSegs", "tcpRetransSegs",
+				"tcpInErrs", "tcpOutRsts", "tcpInCsumErrors",
+			}, r.RtoAlgorithm, r.RtoMin, r.RtoMax, r.MaxConn,
+				r.ActiveOpens, r.PassiveOpens, r.AttemptFails, r.EstabResets,
+				r.CurrEstab, r.InSegs, r.OutSegs, r.RetransSegs,
+				r.InErrs, r.OutRsts, r.InCsumErrors)
+
+		case sflow.MIB2UDPGroupCounters:
+			values(p, []string{
+				"udpInDatagrams", "udpNoPorts", "udpInErrors", "udpOutDatagrams",
+				"udpRcvbufErrors", "udpSndbufErrors", "udpInCsumErrors",
+			}, r.InDatagrams, r.NoPorts, r.InErrors, r.OutDatagrams,
+				r.RcvbufErrors, r.SndbufErrors, r.InCsumErrors)
+
+		case sflow.JMXRuntimeCounters:
+			values(p, []string{
+				"jvm_name", "jvm_vendor", "jvm_version",
+			}, r.VMName, r.VMVendor, r.VMVersion)
+
+		case sflow.JMXStatisticsCounters:
+			values(p, []string{
+				"heap_mem_initial", "heap_mem_used", "heap_mem_committed", "heap_mem_max",
+				"non_heap_mem_initial", "non_heap_mem_used", "non_heap_mem_committed", "non_heap_mem_max",
+				"gc_count", "gc_mS", "classes_loaded", "classes_total", "classes_unloaded",
+				"compilation_mS", "threads_live", "threads_daemon", "threads_started",
+				"fds_open", "fds_max",
+			}, r.HeapInitial, r.HeapUsed, r.HeapCommitted, r.HeapMax,
+				r.NonHeapInitial, r.NonHeapUsed, r.NonHeapCommitted, r.NonHeapMax,
+				r.GCCount, r.GCTime, r.ClassesLoaded, r.ClassesTotal, r.ClassesUnloaded,
+				r.CompilationTime, r.ThreadsLive, r.ThreadsDaemon, r.ThreadsStarted,
+				r.OpenFileDescriptors, r.MaxFileDescriptors)
+
+		case sflow.OVSDPStatsCounters:
+			values(p, []string{
+				"OVS_dp_hits", "OVS_dp_misses", "OVS_dp_lost",
+				"OVS_dp_mask_hits", "OVS_dp_flows", "OVS_dp_masks",
+			}, r.Hits, r.Misses, r.Lost, r.MaskHits, r.Flows, r.Masks)
+
+		case sflow.BroadcomHardwareTablesCounters:
+			values(p, []string{
+				"bcm_host_entries", "bcm_host_entries_max",
+				"bcm_ipv4_entries", "bcm_ipv4_entries_max",
+				"bcm_ipv6_entries", "bcm_ipv6_entries_max",
+				"bcm_ipv4_ipv6_entries", "bcm_ipv4_ipv6_entries_max",
+				"bcm_long_ipv6_entries", "bcm_long_ipv6_entries_max",
+				"bcm_total_routes", "bcm_total_routes_max",
+				"bcm_ecmp_nexthops", "bcm_ecmp_nexthops_max",
+				"bcm_mac_entries", "bcm_mac_entries_max",
+				"bcm_ipv4_neighbors", "bcm_ipv6_neighbors",
+				"bcm_ipv4_routes", "bcm_ipv6_routes",
+				"bcm_acl_ingress_entries", "bcm_acl_ingress_entries_max",
+				"bcm_acl_ingress_counters", "bcm_acl_ingress_counters_max",
+				"bcm_acl_ingress_meters", "bcm_acl_ingress_meters_max",
+				"bcm_acl_ingress_slices", "bcm_acl_ingress_slices_max",
+				"bcm_acl_egress_entries", "bcm_acl_egress_entries_max",
+				"bcm_acl_egress_counters", "bcm_acl_egress_counters_max",
+				"bcm_acl_egress_meters", "bcm_acl_egress_meters_max",
+				"bcm_acl_egress_slices", "bcm_acl_egress_slices_max",
+			}, r.HostEntries, r.HostEntriesMax,
+				r.IPv4Entries, r.IPv4EntriesMax,
+				r.IPv6Entries, r.IPv6EntriesMax,
+				r.IPv4IPv6Entries, r.IPv4IPv6EntriesMax,
+				r.LongIPv6Entries, r.LongIPv6EntriesMax,
+				r.TotalRoutes, r.TotalRoutesMax,
+				r.ECMPNexthops, r.ECMPNexthopsMax,
+				r.MACEntries, r.MACEntriesMax,
+				r.IPv4Neighbors, r.IPv6Neighbors,
+				r.IPv4Routes, r.IPv6Routes,
+				r.ACLIngressEntries, r.ACLIngressEntriesMax,
+				r.ACLIngressCounters, r.ACLIngressCountersMax,
+				r.ACLIngressMeters, r.ACLIngressMetersMax,
+				r.ACLIngressSlices, r.ACLIngressSlicesMax,
+				r.ACLEgressEntries, r.ACLEgressEntriesMax,
+				r.ACLEgressCounters, r.ACLEgressCountersMax,
+				r.ACLEgressMeters, r.ACLEgressMetersMax,
+				r.ACLEgressSlices, r.ACLEgressSlicesMax)
+
+		case sflow.NvidiaGPUCounters:
+			values(p, []string{
+				"nvml_device_count", "nvml_processes", "nvml_gpu_mS", "nvml_mem_mS",
+				"nvml_mem_bytes_total", "nvml_mem_bytes_free", "nvml_ecc_errors",
+				"nvml_energy_mJ", "nvml_temperature_C", "nvml_fan_speed_pc",
+			}, r.DeviceCount, r.Processes, r.GPUTime, r.MemTime,
+				r.MemTotal, r.MemFree, r.ECCErrors,
+				r.Energy, r.Temperature, r.FanSpeed)
+
+		default:
+			writeFieldsText(p, rec)
+		}
+	}
+}
+
+// values prints each name with the value at the same position.
+func values(p *printer, names []string, vals ...interface{}) {
+	for i, name := range names {
+		p.printf("%s %v\n", name, vals[i])
+	}
+}
+
+// percentage formats a percentage expressed in hundredths.
+func percentage(v uint32) string {
+	return fmt.Sprintf("%d.%02d", v/100, v%100)
+}
+
+// writeFieldsText prints the exported fields of a record sflowtool has
+// no names for, keyed by their JSON names.
+func writeFieldsText(p *printer, rec records.Record) {
+	v := reflect.ValueOf(rec)
+	if v.Kind() == reflect.Ptr {
+		v = v.Elem()
+	}
+
+	if v.Kind() != reflect.Struct {
+		return
+	}
+
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.PkgPath != "" {
+			continue
+		}
+
+		name := strings.Split(field.Tag.Get("json"), ",")[0]
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		switch value := v.Field(i).Interface().(type) {
+		case []byte:
+			p.printf("%s %s\n", name, value)
+		case records.HardwareAddr:
+			p.printf("%s %x\n", name, []byte(value))
+		default:
+			p.printf("%s %v\n", name, value)
+		}
+	}
+}
@@ -0,0 +1,163 @@
+package traffic
+
+import (
+	"encoding/binary"
+	"math/rand"
+	"net"
+
+	"github.com/kanocz/sflow/records"
+)
+
+const ipProtocolICMPv6 = 58
+
+// headersLength returns the length of the IP and transport headers.
+func headersLength(proto uint8, ipv6 bool) int {
+	n := 20
+	if ipv6 {
+		n = 40
+	}
+
+	switch proto {
+	case records.IPProtocolTCP:
+		return n + 20
+	default:
+		// UDP and ICMP echo
+		return n + 8
+	}
+}
+
+// buildFrame returns an Ethernet frame carrying an IP packet of
+// ipLength bytes for p, with valid checksums.
+func buildFrame(r *rand.Rand, p *Packet, ipLength int, srcMAC, dstMAC net.HardwareAddr) []byte {
+	ipv6 := p.SrcIP.To4() == nil
+
+	ethLength := 14
+	if p.VLAN != 0 {
+		ethLength += 4
+	}
+
+	frame := make([]byte, ethLength+ipLength)
+	copy(frame[0:], dstMAC)
+	copy(frame[6:], srcMAC)
+
+	etherType := uint16(0x0800)
+	if ipv6 {
+		etherType = 0x86dd
+	}
+
+	if p.VLAN != 0 {
+		binary.BigEndian.PutUint16(frame[12:], 0x8100)
+		binary.BigEndian.PutUint16(frame[14:], p.VLAN&0x0fff)
+		binary.BigEndian.PutUint16(frame[16:], etherType)
+	} else {
+		binary.BigEndian.PutUint16(frame[12:], etherType)
+	}
+
+	ip := frame[ethLength:]
+
+	var l4 []byte
+	if ipv6 {
+		ip[0] = 0x60
+		binary.BigEndian.PutUint16(ip[4:], uint16(ipLength-40))
+		ip[6] = p.Protocol
+		ip[7] = 64
+		copy(ip[8:], p.SrcIP.To16())
+		copy(ip[24:], p.DstIP.To16())
+		l4 = ip[40:]
+	} else {
+		ip[0] = 0x45
+		binary.BigEndian.PutUint16(ip[2:], uint16(ipLength))
+		binary.BigEndian.PutUint16(ip[4:], uint16(r.Intn(1<<16)))
+		// don't fragment
+		binary.BigEndian.PutUint16(ip[6:], 0x4000)
+		ip[8] = 64
+		ip[9] = p.Protocol
+		copy(ip[12:], p.SrcIP.To4())
+		copy(ip[16:], p.DstIP.To4())
+		binary.BigEndian.PutUint16(ip[10:], checksum(ip[:20], 0))
+		l4 = ip[20:]
+	}
+
+	// random payload after the transport header
+	r.Read(l4[headersLength(p.Protocol, ipv6)-(ipLength-len(l4)):])
+
+	switch p.Protocol {
+	case records.IPProtocolTCP:
+		binary.BigEndian.PutUint16(l4[0:], p.SrcPort)
+		binary.BigEndian.PutUint16(l4[2:], p.DstPort)
+		binary.BigEndian.PutUint32(l4[4:], r.Uint32())
+		binary.BigEndian.PutUint32(l4[8:], r.Uint32())
+		l4[12] = 5 << 4
+		l4[13] = 0x10 // ACK
+		if len(l4) > 20 {
+			l4[13] |= 0x08 // PSH
+		}
+		binary.BigEndian.PutUint16(l4[14:], 65535)
+		binary.BigEndian.PutUint16(l4[16:], checksum(l4, pseudoHeaderSum(p, len(l4))))
+
+	case records.IPProtocolUDP:
+		binary.BigEndian.PutUint16(l4[0:], p.SrcPort)
+		binary.BigEndian.PutUint16(l4[2:], p.DstPort)
+		binary.BigEndian.PutUint16(l4[4:], uint16(len(l4)))
+		sum := checksum(l4, pseudoHeaderSum(p, len(l4)))
+		if sum == 0 {
+			sum = 0xffff
+		}
+		binary.BigEndian.PutUint16(l4[6:], sum)
+
+	case records.IPProtocolICMP:
+		l4[0] = 8 // echo request
+		binary.BigEndian.PutUint32(l4[4:], r.Uint32())
+		binary.BigEndian.PutUint16(l4[2:], checksum(l4, 0))
+
+	case ipProtocolICMPv6:
+		l4[0] = 128 // echo request
+		binary.BigEndian.PutUint32(l4[4:], r.Uint32())
+		binary.BigEndian.PutUint16(l4[2:], checksum(l4, pseudoHeaderSum(p, len(l4))))
+	}
+
+	return frame
+}
+
+// pseudoHeaderSum returns the unfolded sum of the pseudo header
+// covered by transport checksums.
+func pseudoHeaderSum(p *Packet, length int) uint32 {
+	var b []byte
+
+	if src := p.SrcIP.To4(); src != nil {
+		b = make([]byte, 12)
+		copy(b[0:], src)
+		copy(b[4:], p.DstIP.To4())
+		b[9] = p.Protocol
+		binary.BigEndian.PutUint16(b[10:], uint16(length))
+	} else {
+		b = make([]byte, 40)
+		copy(b[0:], p.SrcIP.To16())
+		copy(b[16:], p.DstIP.To16())
+		binary.BigEndian.PutUint32(b[32:], uint32(length))
+		b[39] = p.Protocol
+	}
+
+	return sum(b, 0)
+}
+
+func sum(b []byte, initial uint32) uint32 {
+	s := initial
+	for i := 0; i+1 < len(b); i += 2 {
+		s += uint32(binary.BigEndian.Uint16(b[i:]))
+	}
+	if len(b)%2 == 1 {
+		s += uint32(b[len(b)-1]) << 8
+	}
+	return s
+}
+
+// checksum returns the Internet checksum of b, starting from
+// the unfolded sum initial. The checksum field in b must be zero.
+func checksum(b []byte, initial uint32) uint16 {
+	s := sum(b, initial)
+	for s>>16 != 0 {
+		s = s&0xffff + s>>16
+	}
+	return ^uint16(s)
+}
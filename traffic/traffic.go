@@ -0,0 +1,336 @@
+// Package traffic synthesises sampled traffic for benchmarks and for
+// end-to-end tests of code that estimates traffic from samples.
+//
+// A Generator draws packets between hosts whose popularity follows a
+// Zipf distribution, with a configurable mix of protocols, packet sizes
+// and VLANs. Sampled packets are returned as FlowSamples carrying a
+// RawPacketFlow with a valid Ethernet, IPv4 or IPv6, and TCP, UDP or
+// ICMP header, checksums included, and sample pools and sequence numbers
+// that are consistent with the sampling rate. The generator also keeps
+// the totals of the whole, unsampled population for comparison with
+// estimates.
+package traffic
+
+import (
+	"math/rand"
+	"net"
+	"sort"
+
+	"github.com/kanocz/sflow"
+	"github.com/kanocz/sflow/records"
+)
+
+// Protocol is an entry of the protocol mix.
+type Protocol struct {
+	// Number is the IP protocol number: records.IPProtocolTCP,
+	// records.IPProtocolUDP or records.IPProtocolICMP.
+	Number uint8
+
+	// Weight is the relative share of packets using the protocol.
+	Weight float64
+
+	// Ports are the destination ports to choose from
+	// for TCP and UDP.
+	Ports []uint16
+}
+
+// Size is an entry of the packet size distribution.
+type Size struct {
+	// Length is the length of the IP packet in bytes. It is raised to
+	// the size of the headers where needed.
+	Length int
+
+	// Weight is the relative share of packets of this length.
+	Weight float64
+}
+
+// DefaultProtocols is a typical protocol mix.
+var DefaultProtocols = []Protocol{
+	{Number: records.IPProtocolTCP, Weight: 80, Ports: []uint16{443, 80, 22, 8080}},
+	{Number: records.IPProtocolUDP, Weight: 18, Ports: []uint16{53, 443, 123, 4789}},
+	{Number: records.IPProtocolICMP, Weight: 2},
+}
+
+// DefaultSizes is the simple IMIX packet size distribution.
+var DefaultSizes = []Size{
+	{Length: 40, Weight: 7},
+	{Length: 576, Weight: 4},
+	{Length: 1500, Weight: 1},
+}
+
+// Config configures a Generator. Zero values select the defaults.
+type Config struct {
+	// Seed seeds the random source, so equal configurations
+	// produce equal traffic.
+	Seed int64
+
+	// Hosts is the number of hosts. Default 1000.
+	Hosts int
+
+	// ZipfS is the Zipf exponent of host popularity, which must
+	// be greater than 1. Default 1.2.
+	ZipfS float64
+
+	// IPv6Fraction is the share of packets sent over IPv6.
+	IPv6Fraction float64
+
+	Protocols []Protocol
+	Sizes     []Size
+
+	// VLANs, if set, are assigned to hosts round robin. Packets are
+	// tagged with the VLAN of their source host and samples carry an
+	// ExtendedSwitchFlow record.
+	VLANs []uint16
+
+	// SamplingRate is the mean number of packets per sample.
+	// Default 1024.
+	SamplingRate uint32
+
+	// HeaderSize is the maximum number of bytes of each packet
+	// kept in the RawPacketFlow. Default 128.
+	HeaderSize int
+
+	// SourceID, Input and Output identify the data source
+	// and interfaces of the samples. Default 1 for each.
+	SourceID uint32
+	Input    uint32
+	Output   uint32
+}
+
+// Generator produces sampled traffic. It is not safe for concurrent use.
+type Generator struct {
+	c    Config
+	rand *rand.Rand
+	zipf *rand.Zipf
+
+	// cumulative weights of the protocols and sizes
+	protocols []float64
+	sizes     []float64
+
+	samplePool  uint32
+	sequenceNum uint32
+
+	packets uint64
+	octets  uint64
+}
+
+// New returns a Generator for c.
+func New(c Config) *Generator {
+	if c.Hosts < 2 {
+		c.Hosts = 1000
+	}
+	if c.ZipfS <= 1 {
+		c.ZipfS = 1.2
+	}
+	if len(c.Protocols) == 0 {
+		c.Protocols = DefaultProtocols
+	}
+	if len(c.Sizes) == 0 {
+		c.Sizes = DefaultSizes
+	}
+	if c.SamplingRate == 0 {
+		c.SamplingRate = 1024
+	}
+	if c.HeaderSize <= 0 {
+		c.HeaderSize = 128
+	}
+	if c.HeaderSize > records.MaximumHeaderLength {
+		c.HeaderSize = records.MaximumHeaderLength
+	}
+	if c.SourceID == 0 {
+		c.SourceID = 1
+	}
+	if c.Input == 0 {
+		c.Input = 1
+	}
+	if c.Output == 0 {
+		c.Output = 1
+	}
+
+	r := rand.New(rand.NewSource(c.Seed))
+
+	g := &Generator{
+		c:    c,
+		rand: r,
+		zipf: rand.NewZipf(r, c.ZipfS, 1, uint64(c.Hosts-1)),
+	}
+
+	sum := 0.0
+	for _, p := range c.Protocols {
+		sum += p.Weight
+		g.protocols = append(g.protocols, sum)
+	}
+
+	sum = 0
+	for _, s := range c.Sizes {
+		sum += s.Weight
+		g.sizes = append(g.sizes, sum)
+	}
+
+	return g
+}
+
+// Packet is a generated packet.
+type Packet struct {
+	// Frame is the complete Ethernet frame, without FCS.
+	Frame []byte
+
+	Src, Dst         int
+	SrcIP, DstIP     net.IP
+	Protocol         uint8
+	SrcPort, DstPort uint16
+	VLAN             uint16
+}
+
+// HostIP returns the address of host i.
+func HostIP(i int, ipv6 bool) net.IP {
+	if ipv6 {
+		ip := net.ParseIP("2001:db8::")
+		ip[12], ip[13], ip[14], ip[15] = byte(i>>24), byte(i>>16), byte(i>>8), byte(i)
+		return ip
+	}
+
+	return net.IPv4(10, byte(i>>16), byte(i>>8), byte(i)).To4()
+}
+
+// HostMAC returns the locally administered MAC address of host i.
+func HostMAC(i int) net.HardwareAddr {
+	return net.HardwareAddr{0x02, 0, byte(i >> 24), byte(i >> 16), byte(i >> 8), byte(i)}
+}
+
+// Packet generates a packet. It does not count towards the population.
+func (g *Generator) Packet() Packet {
+	src := int(g.zipf.Uint64())
+	dst := int(g.zipf.Uint64())
+	if dst == src {
+		dst = (src + 1 + g.rand.Intn(g.c.Hosts-1)) % g.c.Hosts
+	}
+
+	proto, ipv6, length := g.draw()
+
+	p := Packet{
+		Src:      src,
+		Dst:      dst,
+		SrcIP:    HostIP(src, ipv6),
+		DstIP:    HostIP(dst, ipv6),
+		Protocol: proto.Number,
+	}
+
+	if len(g.c.VLANs) > 0 {
+		p.VLAN = g.c.VLANs[src%len(g.c.VLANs)]
+	}
+
+	if proto.Number == records.IPProtocolTCP || proto.Number == records.IPProtocolUDP {
+		p.SrcPort = uint16(32768 + g.rand.Intn(28232))
+		if len(proto.Ports) > 0 {
+			p.DstPort = proto.Ports[g.rand.Intn(len(proto.Ports))]
+		}
+	}
+
+	if ipv6 && proto.Number == records.IPProtocolICMP {
+		p.Protocol = ipProtocolICMPv6
+	}
+
+	p.Frame = buildFrame(g.rand, &p, length, HostMAC(src), HostMAC(dst))
+
+	return p
+}
+
+// draw chooses the protocol, address family and IP length of a packet.
+func (g *Generator) draw() (Protocol, bool, int) {
+	proto := g.c.Protocols[pick(g.rand, g.protocols)]
+	ipv6 := g.rand.Float64() < g.c.IPv6Fraction
+
+	length := g.c.Sizes[pick(g.rand, g.sizes)].Length
+	if n := headersLength(proto.Number, ipv6); length < n {
+		length = n
+	}
+
+	return proto, ipv6, length
+}
+
+// Sample generates the next flow sample. The packets skipped
+// before it are counted in the population with random sizes.
+func (g *Generator) Sample() *sflow.FlowSample {
+	skip := uint32(1)
+	if g.c.SamplingRate > 1 {
+		skip = 1 + uint32(g.rand.Int63n(2*int64(g.c.SamplingRate)-1))
+	}
+
+	for i := uint32(1); i < skip; i++ {
+		_, _, length := g.draw()
+		g.packets++
+		g.octets += uint64(length)
+	}
+
+	p := g.Packet()
+	ipLength := len(p.Frame) - 14
+	if p.VLAN != 0 {
+		ipLength -= 4
+	}
+
+	g.packets++
+	g.octets += uint64(ipLength)
+
+	g.samplePool += skip
+	g.sequenceNum++
+
+	header := p.Frame
+	if len(header) > g.c.HeaderSize {
+		header = header[:g.c.HeaderSize]
+	}
+
+	s := &sflow.FlowSample{
+		SequenceNum:      g.sequenceNum,
+		SourceIdIndexVal: g.c.SourceID,
+		SamplingRate:     g.c.SamplingRate,
+		SamplePool:       g.samplePool,
+		Input:            g.c.Input,
+		Output:           g.c.Output,
+		Records: []records.Record{
+			records.RawPacketFlow{
+				Protocol:    records.HeaderProtocolEthernetISO8023,
+				FrameLength: uint32(len(p.Frame)) + 4,
+				Stripped:    4,
+				HeaderSize:  uint32(len(header)),
+				Header:      append([]byte(nil), header...),
+			},
+		},
+	}
+
+	if len(g.c.VLANs) > 0 {
+		s.Records = append(s.Records, records.ExtendedSwitchFlow{
+			SourceVlan:      uint32(p.VLAN),
+			DestinationVlan: uint32(g.c.VLANs[p.Dst%len(g.c.VLANs)]),
+		})
+	}
+
+	return s
+}
+
+// Samples generates n flow samples.
+func (g *Generator) Samples(n int) []sflow.Sample {
+	samples := make([]sflow.Sample, n)
+	for i := range samples {
+		samples[i] = g.Sample()
+	}
+	return samples
+}
+
+// Population returns the number of packets and IP octets of the
+// population the samples so far were taken from.
+func (g *Generator) Population() (packets, octets uint64) {
+	return g.packets, g.octets
+}
+
+// pick returns an index into cumulative weights chosen with
+// probability proportional to its weight.
+func pick(r *rand.Rand, cumulative []float64) int {
+	x := r.Float64() * cumulative[len(cumulative)-1]
+
+	i := sort.SearchFloat64s(cumulative, x)
+	if i >= len(cumulative) {
+		i = len(cumulative) - 1
+	}
+	return i
+}
@@ -0,0 +1,131 @@
+package traffic
+
+import (
+	"bytes"
+	"encoding/binary"
+	"net"
+	"reflect"
+	"testing"
+
+	"github.com/kanocz/sflow"
+	"github.com/kanocz/sflow/records"
+)
+
+func TestChecksums(t *testing.T) {
+	g := New(Config{
+		Seed:         1,
+		IPv6Fraction: 0.5,
+		Sizes:        []Size{{Length: 64, Weight: 1}, {Length: 333, Weight: 1}},
+		VLANs:        []uint16{10, 20},
+		HeaderSize:   records.MaximumHeaderLength,
+	})
+
+	for i := 0; i < 500; i++ {
+		p := g.Packet()
+
+		ip := p.Frame[18:]
+		if binary.BigEndian.Uint16(p.Frame[12:]) != 0x8100 || binary.BigEndian.Uint16(p.Frame[14:]) != p.VLAN {
+			t.Fatalf("packet %d: expected VLAN %d tag, got % x", i, p.VLAN, p.Frame[12:18])
+		}
+
+		var l4 []byte
+		if p.SrcIP.To4() != nil {
+			if checksum(ip[:20], 0) != 0 {
+				t.Fatalf("packet %d: invalid IPv4 header checksum", i)
+			}
+			l4 = ip[20:]
+		} else {
+			l4 = ip[40:]
+		}
+
+		pseudo := pseudoHeaderSum(&p, len(l4))
+		if p.Protocol == records.IPProtocolICMP {
+			pseudo = 0
+		}
+
+		if checksum(l4, pseudo) != 0 {
+			t.Fatalf("packet %d: invalid checksum for protocol %d", i, p.Protocol)
+		}
+	}
+}
+
+func TestSamplePool(t *testing.T) {
+	g := New(Config{Seed: 2, SamplingRate: 100})
+
+	const n = 2000
+	for i := 1; i <= n; i++ {
+		s := g.Sample()
+
+		packets, _ := g.Population()
+		if s.SequenceNum != uint32(i) || s.SamplePool != uint32(packets) {
+			t.Fatalf("sample %d: sequence number %d, sample pool %d, population %d",
+				i, s.SequenceNum, s.SamplePool, packets)
+		}
+	}
+
+	packets, octets := g.Population()
+	if mean := float64(packets) / n; mean < 95 || mean > 105 {
+		t.Errorf("expected about 100 packets per sample, got %.1f", mean)
+	}
+
+	if octets < packets*40 || octets > packets*1500 {
+		t.Errorf("unexpected population octets %d for %d packets", octets, packets)
+	}
+}
+
+func TestZipfHosts(t *testing.T) {
+	g := New(Config{Seed: 3, Hosts: 100})
+
+	counts := make([]int, 100)
+	for i := 0; i < 5000; i++ {
+		counts[g.Packet().Src]++
+	}
+
+	for i := 1; i < len(counts); i++ {
+		if counts[i] > counts[0] {
+			t.Fatalf("host %d sent %d packets, more than host 0 with %d", i, counts[i], counts[0])
+		}
+	}
+
+	if counts[0] < counts[50]*10 {
+		t.Errorf("expected a skewed distribution, got %d vs %d", counts[0], counts[50])
+	}
+}
+
+func TestSamplesEncodeDecode(t *testing.T) {
+	samples := New(Config{Seed: 4, VLANs: []uint16{7}}).Samples(5)
+
+	if !reflect.DeepEqual(samples, New(Config{Seed: 4, VLANs: []uint16{7}}).Samples(5)) {
+		t.Error("expected equal seeds to produce equal samples")
+	}
+
+	buf := &bytes.Buffer{}
+	err := sflow.NewEncoder(net.IPv4(192, 0, 2, 1), 0, 1).Encode(buf, samples)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dgram, err := sflow.NewPacketDecoder().Decode(buf.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(dgram.Samples) != 5 {
+		t.Fatalf("expected 5 samples, got %d", len(dgram.Samples))
+	}
+
+	s := dgram.Samples[0].(*sflow.FlowSample)
+	raw, ok := s.Records[0].(records.RawPacketFlow)
+	if !ok || raw.HeaderSize > 128 || len(raw.Header) != int(raw.HeaderSize) || raw.DecodedHeader["ip"] == nil {
+		t.Errorf("unexpected raw packet record %v", s.Records[0])
+	}
+}
+
+func BenchmarkSample(b *testing.B) {
+	g := New(Config{Seed: 5})
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		g.Sample()
+	}
+}